@@ -0,0 +1,182 @@
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/feed"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/preferences"
+)
+
+// DefaultInterval is how often the worker looks for new posts
+const DefaultInterval = time.Minute
+
+// settleDelay is how old posts must be before they are matched. Post IDs are taken when
+// the post is inserted, so a post can commit after one with a higher ID. Matching only
+// posts older than this leaves their transactions time to commit below the watermark.
+const settleDelay = time.Minute
+
+// StartWorker matches new posts against saved searches with alerts enabled in the
+// background, so that CreatePost doesn't wait for it
+func StartWorker(db *gorm.DB, m mailer.Mailer, interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            if err := Run(db, m); err != nil {
+                log.Printf("[ALERTS] Failed to match saved searches: %v", err)
+            }
+        }
+    }()
+}
+
+// Run matches the posts created since the last run against every saved search with alerts
+func Run(db *gorm.DB, m mailer.Mailer) error {
+    var maxPostID uint
+    if err := db.Model(&models.Post{}).
+        Select("COALESCE(MAX(id), 0)").
+        Where("created_at <= ?", time.Now().Add(-settleDelay)).
+        Scan(&maxPostID).Error; err != nil {
+        return err
+    }
+
+    var searches []models.SavedSearch
+    if err := db.Where("alerts_enabled = ? AND last_post_id < ?", true, maxPostID).Find(&searches).Error; err != nil {
+        return err
+    }
+
+    for _, search := range searches {
+        if err := matchSearch(db, m, search, maxPostID); err != nil {
+            log.Printf("[ALERTS] Failed to match saved search %d: %v", search.ID, err)
+        }
+    }
+    return nil
+}
+
+// matchSearch creates alerts for the posts up to maxPostID matching a saved search and
+// emails them if the user asked for it
+func matchSearch(db *gorm.DB, m mailer.Mailer, search models.SavedSearch, maxPostID uint) error {
+    // Claim the posts first, when several instances run the worker only the one that
+    // moves the watermark on from what it read matches them
+    claim := db.Model(&models.SavedSearch{}).
+        Where("id = ? AND last_post_id = ?", search.ID, search.LastPostID).
+        Update("last_post_id", maxPostID)
+    if claim.Error != nil {
+        return claim.Error
+    }
+    if claim.RowsAffected == 0 {
+        return nil
+    }
+
+    // The watermark stays moved on if the search no longer parses (e.g. its user filter
+    // was deleted), so a broken search isn't retried on every run
+    filters, err := feed.ParseFilters(db, feed.DecodeParams(search.Params))
+    if err != nil {
+        return err
+    }
+
+    if err := alertPosts(db, m, search, filters, maxPostID); err != nil {
+        // Give the posts back so the next run retries them
+        if release := db.Model(&models.SavedSearch{}).
+            Where("id = ? AND last_post_id = ?", search.ID, maxPostID).
+            Update("last_post_id", search.LastPostID).Error; release != nil {
+            log.Printf("[ALERTS] Failed to release saved search %d: %v", search.ID, release)
+        }
+        return err
+    }
+    return nil
+}
+
+// alertPosts creates the alerts of a claimed saved search and sends the email
+func alertPosts(db *gorm.DB, m mailer.Mailer, search models.SavedSearch, filters feed.Filters, maxPostID uint) error {
+    if err := filters.ResolveSearch(); err != nil {
+        return err
+    }
+
+    // Users aren't alerted about their own posts
+    query := db.Model(&models.Post{}).
+        Where("posts.id > ? AND posts.id <= ? AND posts.user_id <> ?", search.LastPostID, maxPostID, search.UserID)
+    query = filters.Apply(query, search.UserID)
+
+    var posts []models.Post
+    if err := query.Select("posts.id", "posts.title").Order("posts.id ASC").Find(&posts).Error; err != nil {
+        return err
+    }
+    if len(posts) == 0 {
+        return nil
+    }
+
+    // Split the posts by the channels the user wants them on
+    userPreferences, err := preferences.Load(db, search.UserID)
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+    alerts := make([]models.SearchAlert, 0, len(posts))
+    var emailPosts []models.Post
+    for _, post := range posts {
+        event := preferences.Event{Type: models.NotificationSavedSearch, PostID: post.ID}
+        inApp, err := userPreferences.ShouldNotify(event, preferences.ChannelInApp, now)
+        if err != nil {
+            return err
+        }
+        if inApp {
+            alerts = append(alerts, models.SearchAlert{
+                UserID:        search.UserID,
+                SavedSearchID: search.ID,
+                PostID:        post.ID,
+            })
+        }
+        if search.EmailAlerts {
+            email, err := userPreferences.ShouldNotify(event, preferences.ChannelEmail, now)
+            if err != nil {
+                return err
+            }
+            if email {
+                emailPosts = append(emailPosts, post)
+            }
+        }
+    }
+
+    if len(alerts) > 0 {
+        if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&alerts).Error; err != nil {
+            return err
+        }
+    }
+    if len(emailPosts) > 0 {
+        return emailAlerts(db, m, search, emailPosts)
+    }
+    return nil
+}
+
+// emailAlerts sends the user one email listing the new posts matching a saved search
+func emailAlerts(db *gorm.DB, m mailer.Mailer, search models.SavedSearch, posts []models.Post) error {
+    var user models.User
+    if err := db.Select("id", "email", "username").First(&user, search.UserID).Error; err != nil {
+        return err
+    }
+
+    var body strings.Builder
+    fmt.Fprintf(&body, "Hi %s,\n\nNew posts match your saved search \"%s\":\n\n", user.Username, search.Name)
+    for _, post := range posts {
+        fmt.Fprintf(&body, "- %s\n  %s\n", post.Title, mailer.Link(fmt.Sprintf("/post/%d", post.ID)))
+    }
+    body.WriteString("\nYou can turn these emails off in your saved searches.\n")
+
+    subject := fmt.Sprintf("%d new posts for \"%s\"", len(posts), search.Name)
+    if len(posts) == 1 {
+        subject = fmt.Sprintf("1 new post for \"%s\"", search.Name)
+    }
+    return m.Send(mailer.Message{
+        To:      user.Email,
+        Subject: subject,
+        Body:    body.String(),
+    })
+}
@@ -0,0 +1,136 @@
+package badges
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Rule kinds, what a rule counts against its threshold
+const (
+    KindSolutions    = "solutions"     // Comments of the user marked as the solution
+    KindTagSolutions = "tag_solutions" // Solutions on posts with a tag, awarded for every tag
+    KindCommentLikes = "comment_likes" // Likes on a single comment of the user
+    KindPostMetoos   = "post_metoos"   // MeToos on a single question of the user
+    KindActiveDays   = "active_days"   // Days the user posted, commented, reacted or MeToo'd
+)
+
+// Rule awards a badge to the users whose count reaches the threshold
+type Rule struct {
+    Badge       string `json:"badge"` // Identifier stored with the awards, e.g. "first-solution"
+    Name        string `json:"name"`
+    Description string `json:"description"`
+    Kind        string `json:"kind"`
+    Threshold   int    `json:"threshold"`
+}
+
+// PerTag reports whether the badge is awarded once for every tag
+func (r Rule) PerTag() bool {
+    return r.Kind == KindTagSolutions
+}
+
+// DefaultRules is the catalog used when BADGE_RULES isn't set
+var DefaultRules = []Rule{
+    {
+        Badge:       "first-solution",
+        Name:        "First Solution",
+        Description: "Had a comment marked as the solution for the first time",
+        Kind:        KindSolutions,
+        Threshold:   1,
+    },
+    {
+        Badge:       "tag-expert",
+        Name:        "Tag Expert",
+        Description: "Had 10 comments marked as the solution on posts with the tag",
+        Kind:        KindTagSolutions,
+        Threshold:   10,
+    },
+    {
+        Badge:       "popular-comment",
+        Name:        "Popular Comment",
+        Description: "Wrote a comment with 25 likes",
+        Kind:        KindCommentLikes,
+        Threshold:   25,
+    },
+    {
+        Badge:       "common-problem",
+        Name:        "Common Problem",
+        Description: "Asked a question 50 users had too",
+        Kind:        KindPostMetoos,
+        Threshold:   50,
+    },
+    {
+        Badge:       "regular",
+        Name:        "Regular",
+        Description: "Was active on 100 different days",
+        Kind:        KindActiveDays,
+        Threshold:   100,
+    },
+}
+
+// Catalog is the list of badges awarded, set up at startup
+var Catalog = DefaultRules
+
+// Find returns the rule of a badge in the catalog
+func Find(badge string) (Rule, bool) {
+    for _, rule := range Catalog {
+        if rule.Badge == badge {
+            return rule, true
+        }
+    }
+    return Rule{}, false
+}
+
+var badgePattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// LoadRules loads the catalog from the JSON file at BADGE_RULES, a list of rules, or
+// returns the default rules. Removing a rule stops awarding its badge, users keep the
+// awards they have.
+func LoadRules() ([]Rule, error) {
+    path := os.Getenv("BADGE_RULES")
+    if path == "" {
+        return DefaultRules, nil
+    }
+
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var rules []Rule
+    if err := json.Unmarshal(data, &rules); err != nil {
+        return nil, fmt.Errorf("invalid badge rules in %s: %v", path, err)
+    }
+    if err := Validate(rules); err != nil {
+        return nil, fmt.Errorf("invalid badge rules in %s: %v", path, err)
+    }
+    return rules, nil
+}
+
+// Validate checks that rules have unique identifiers, a name, a known kind and a
+// positive threshold
+func Validate(rules []Rule) error {
+    seen := make(map[string]bool, len(rules))
+    for _, rule := range rules {
+        if !badgePattern.MatchString(rule.Badge) {
+            return fmt.Errorf("badge %q must be lowercase letters, digits and dashes", rule.Badge)
+        }
+        if seen[rule.Badge] {
+            return fmt.Errorf("badge %q is defined twice", rule.Badge)
+        }
+        seen[rule.Badge] = true
+
+        if rule.Name == "" {
+            return fmt.Errorf("badge %q has no name", rule.Badge)
+        }
+        switch rule.Kind {
+        case KindSolutions, KindTagSolutions, KindCommentLikes, KindPostMetoos, KindActiveDays:
+        default:
+            return fmt.Errorf("badge %q has unknown kind %q", rule.Badge, rule.Kind)
+        }
+        if rule.Threshold < 1 {
+            return fmt.Errorf("badge %q needs a threshold of at least 1", rule.Badge)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,169 @@
+package badges
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestValidate(t *testing.T) {
+    valid := Rule{Badge: "first-solution", Name: "First Solution", Kind: KindSolutions, Threshold: 1}
+    with := func(change func(*Rule)) Rule {
+        rule := valid
+        change(&rule)
+        return rule
+    }
+
+    tests := []struct {
+        name    string
+        rules   []Rule
+        wantErr string
+    }{
+        {"default rules", DefaultRules, ""},
+        {"no rules", nil, ""},
+        {"valid", []Rule{valid}, ""},
+        {"duplicate id", []Rule{valid, with(func(r *Rule) { r.Name = "Again" })}, "defined twice"},
+        {"uppercase id", []Rule{with(func(r *Rule) { r.Badge = "First" })}, "lowercase"},
+        {"id with spaces", []Rule{with(func(r *Rule) { r.Badge = "first solution" })}, "lowercase"},
+        {"trailing dash", []Rule{with(func(r *Rule) { r.Badge = "first-" })}, "lowercase"},
+        {"empty id", []Rule{with(func(r *Rule) { r.Badge = "" })}, "lowercase"},
+        {"no name", []Rule{with(func(r *Rule) { r.Name = "" })}, "no name"},
+        {"unknown kind", []Rule{with(func(r *Rule) { r.Kind = "posts" })}, "unknown kind"},
+        {"threshold 0", []Rule{with(func(r *Rule) { r.Threshold = 0 })}, "threshold"},
+        {"negative threshold", []Rule{with(func(r *Rule) { r.Threshold = -5 })}, "threshold"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := Validate(tt.rules)
+            if tt.wantErr == "" {
+                if err != nil {
+                    t.Errorf("Validate() = %v, want nil", err)
+                }
+                return
+            }
+            if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                t.Errorf("Validate() = %v, want an error about %q", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestLoadRules(t *testing.T) {
+    dir := t.TempDir()
+    write := func(name, content string) string {
+        path := filepath.Join(dir, name)
+        if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+            t.Fatal(err)
+        }
+        return path
+    }
+
+    tests := []struct {
+        name      string
+        path      string
+        wantRules int
+        wantErr   bool
+    }{
+        {"unset", "", len(DefaultRules), false},
+        {"valid file", write("valid.json", `[{"badge": "helper", "name": "Helper", "kind": "solutions", "threshold": 5}]`), 1, false},
+        {"missing file", filepath.Join(dir, "missing.json"), 0, true},
+        {"invalid JSON", write("broken.json", `[{"badge": `), 0, true},
+        {"invalid rule", write("invalid.json", `[{"badge": "helper", "name": "Helper", "kind": "solutions", "threshold": 0}]`), 0, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            t.Setenv("BADGE_RULES", tt.path)
+            rules, err := LoadRules()
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("LoadRules() error = %v, want error %v", err, tt.wantErr)
+            }
+            if len(rules) != tt.wantRules {
+                t.Errorf("LoadRules() = %d rules, want %d", len(rules), tt.wantRules)
+            }
+        })
+    }
+}
+
+// dryRun returns a database that builds statements without running them
+func dryRun(t *testing.T) *gorm.DB {
+    db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=invalid"}), &gorm.Config{
+        DryRun:               true,
+        DisableAutomaticPing: true,
+        Logger:               logger.Default.LogMode(logger.Silent),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return db
+}
+
+func TestEarnersQuery(t *testing.T) {
+    since := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+    tests := []struct {
+        kind       string
+        want       []string // Parts of the query, in order
+        userColumn string   // Column restricted to the active users
+    }{
+        {KindSolutions, []string{
+            `SELECT user_id FROM "comments" WHERE is_solution = true`,
+            `GROUP BY "user_id" HAVING COUNT(*) >= 3`,
+        }, "user_id"},
+        {KindTagSolutions, []string{
+            `SELECT comments.user_id, tag FROM "comments"`,
+            `CROSS JOIN UNNEST(posts.tags) AS tag WHERE comments.is_solution = true`,
+            `GROUP BY comments.user_id, tag HAVING COUNT(*) >= 3`,
+        }, "comments.user_id"},
+        {KindCommentLikes, []string{
+            `SELECT DISTINCT user_id FROM "comments" WHERE likes >= 3`,
+        }, "user_id"},
+        {KindPostMetoos, []string{
+            `SELECT DISTINCT posts.user_id FROM "posts" JOIN me_toos ON me_toos.post_id = posts.id`,
+            `GROUP BY posts.id, posts.user_id HAVING COUNT(*) >= 3`,
+        }, "posts.user_id"},
+        {KindActiveDays, []string{
+            `SELECT user_id FROM (SELECT user_id, created_at FROM posts`,
+            `UNION ALL SELECT user_id, created_at FROM me_toos) AS activity`,
+            `GROUP BY "user_id" HAVING COUNT(DISTINCT DATE(created_at)) >= 3`,
+        }, "user_id"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.kind, func(t *testing.T) {
+            rule := Rule{Badge: "b", Name: "B", Kind: tt.kind, Threshold: 3}
+            db := dryRun(t)
+
+            everyone := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+                var earners []earner
+                return earnersQuery(tx, rule, time.Time{}).Scan(&earners)
+            })
+            rest := everyone
+            for _, part := range tt.want {
+                i := strings.Index(rest, part)
+                if i < 0 {
+                    t.Fatalf("query %s\nlacks %s", everyone, part)
+                }
+                rest = rest[i+len(part):]
+            }
+            if strings.Contains(everyone, "updated_at") {
+                t.Errorf("query %s\nis restricted to active users without a time", everyone)
+            }
+
+            active := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+                var earners []earner
+                return earnersQuery(tx, rule, since).Scan(&earners)
+            })
+            restriction := tt.userColumn + " IN (SELECT user_id FROM comments WHERE updated_at >= '2025-01-02 03:04:05'"
+            if !strings.Contains(active, restriction) {
+                t.Errorf("query %s\nisn't restricted to the active users by %s", active, tt.userColumn)
+            }
+        })
+    }
+}
@@ -0,0 +1,184 @@
+package badges
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/models"
+)
+
+// DefaultInterval is how often the evaluator runs
+const DefaultInterval = 10 * time.Minute
+
+// settleDelay is how far runs overlap. Rows are stamped before their transaction
+// commits, so a run may not see activity stamped shortly before it started yet.
+const settleDelay = time.Minute
+
+// StartWorker awards the badges of the catalog in the background. Badges reward
+// milestones rather than single actions, so they needn't be awarded on the spot. The
+// first run checks every user, later ones the users active since the previous run.
+func StartWorker(db *gorm.DB, interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        var since time.Time
+        for range ticker.C {
+            now := time.Now()
+            if err := Run(db, since, now); err != nil {
+                // Check the same users again next time
+                log.Printf("[BADGES] Failed to award badges: %v", err)
+                continue
+            }
+            since = now.Add(-settleDelay)
+        }
+    }()
+}
+
+// Run awards the badges of the catalog to the users active since a time who earned them
+// and don't have them yet, or to every user when since is zero. Badges are never taken
+// back, even when the count drops again.
+func Run(db *gorm.DB, since, now time.Time) error {
+    failed := 0
+    for _, rule := range Catalog {
+        if err := evaluate(db, rule, since, now); err != nil {
+            log.Printf("[BADGES] Failed to evaluate badge %s: %v", rule.Badge, err)
+            failed++
+        }
+    }
+    if failed > 0 {
+        return fmt.Errorf("%d of %d badges couldn't be evaluated", failed, len(Catalog))
+    }
+    return nil
+}
+
+// earner is a user who reached the threshold of a rule, in a tag for per-tag rules
+type earner struct {
+    UserID uint
+    Tag    string
+}
+
+// evaluate awards a badge to the users active since a time who reached its threshold
+func evaluate(db *gorm.DB, rule Rule, since, now time.Time) error {
+    var earners []earner
+    if err := earnersQuery(db, rule, since).Scan(&earners).Error; err != nil {
+        return err
+    }
+    if len(earners) == 0 {
+        return nil
+    }
+
+    userIDs := make(pq.Int64Array, 0, len(earners))
+    for _, earner := range earners {
+        userIDs = append(userIDs, int64(earner.UserID))
+    }
+    var awarded []models.UserBadge
+    if err := db.Select("user_id", "tag").
+        Where("badge = ? AND user_id = ANY(?)", rule.Badge, userIDs).
+        Find(&awarded).Error; err != nil {
+        return err
+    }
+    has := make(map[earner]bool, len(awarded))
+    for _, award := range awarded {
+        has[earner{UserID: award.UserID, Tag: award.Tag}] = true
+    }
+
+    for _, earner := range earners {
+        if has[earner] {
+            continue
+        }
+        award := models.UserBadge{
+            UserID:    earner.UserID,
+            Badge:     rule.Badge,
+            Tag:       earner.Tag,
+            AwardedAt: now,
+        }
+        // Another instance may have awarded it in the meantime
+        result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&award)
+        if result.Error != nil {
+            return result.Error
+        }
+        if result.RowsAffected == 0 {
+            continue
+        }
+        events.Publish(events.Event{
+            Type:      events.BadgeAwarded,
+            SubjectID: award.UserID,
+            Detail:    award.Badge,
+            At:        now,
+        })
+    }
+    return nil
+}
+
+// activeUsersQuery selects the users whose counts may have changed since @since: the
+// authors of new, edited, liked or solved comments, of solutions on edited posts and of
+// questions with new MeToos, and everyone who posted, reacted or MeToo'd. Liking a
+// comment or marking a solution updates the comment.
+const activeUsersQuery = `SELECT user_id FROM comments WHERE updated_at >= @since
+    UNION SELECT comments.user_id FROM comments JOIN posts ON posts.id = comments.post_id
+        WHERE comments.is_solution = true AND posts.updated_at >= @since
+    UNION SELECT posts.user_id FROM me_toos JOIN posts ON posts.id = me_toos.post_id
+        WHERE me_toos.created_at >= @since
+    UNION SELECT user_id FROM posts WHERE created_at >= @since
+    UNION SELECT user_id FROM reactions WHERE created_at >= @since
+    UNION SELECT user_id FROM me_toos WHERE created_at >= @since`
+
+// activityQuery is a row for every post, comment, reaction and MeToo of a user
+const activityQuery = `SELECT user_id, created_at FROM posts
+    UNION ALL SELECT user_id, created_at FROM comments
+    UNION ALL SELECT user_id, created_at FROM reactions
+    UNION ALL SELECT user_id, created_at FROM me_toos`
+
+// earnersQuery selects the users whose count reaches the threshold of a rule, among the
+// users active since a time unless it is zero
+func earnersQuery(db *gorm.DB, rule Rule, since time.Time) *gorm.DB {
+    var query *gorm.DB
+    userColumn := "user_id"
+    switch rule.Kind {
+    case KindSolutions:
+        query = db.Table("comments").
+            Select("user_id").
+            Where("is_solution = ?", true).
+            Group("user_id").
+            Having("COUNT(*) >= ?", rule.Threshold)
+    case KindTagSolutions:
+        userColumn = "comments.user_id"
+        query = db.Table("comments").
+            Select("comments.user_id, tag").
+            Joins("JOIN posts ON posts.id = comments.post_id").
+            Joins("CROSS JOIN UNNEST(posts.tags) AS tag").
+            Where("comments.is_solution = ?", true).
+            Group("comments.user_id, tag").
+            Having("COUNT(*) >= ?", rule.Threshold)
+    case KindCommentLikes:
+        query = db.Table("comments").
+            Distinct("user_id").
+            Where("likes >= ?", rule.Threshold)
+    case KindPostMetoos:
+        userColumn = "posts.user_id"
+        query = db.Table("posts").
+            Distinct("posts.user_id").
+            Joins("JOIN me_toos ON me_toos.post_id = posts.id").
+            Group("posts.id, posts.user_id").
+            Having("COUNT(*) >= ?", rule.Threshold)
+    case KindActiveDays:
+        query = db.Table("(?) AS activity", db.Raw(activityQuery)).
+            Select("user_id").
+            Group("user_id").
+            Having("COUNT(DISTINCT DATE(created_at)) >= ?", rule.Threshold)
+    default:
+        // Validate rejects unknown kinds, this one matches nobody
+        return db.Table("users").Select("id AS user_id").Where("false")
+    }
+    if !since.IsZero() {
+        query = query.Where(userColumn+" IN (?)", db.Raw(activeUsersQuery, sql.Named("since", since)))
+    }
+    return query
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"techquire-backend/internal/events"
+)
+
+// Cache stores rendered responses. Backends may drop entries at any time, a miss just
+// means the response is built again.
+type Cache interface {
+    // Get returns the value of a key and whether it was found
+    Get(key string) ([]byte, bool, error)
+    // Set stores a value that expires after ttl
+    Set(key string, value []byte, ttl time.Duration) error
+    // Delete removes keys
+    Delete(keys ...string) error
+    Close() error
+}
+
+// Default is the cache used by the handlers, set up at startup. Nil disables caching.
+var Default Cache
+
+// Open opens the backend configured with CACHE_BACKEND: "memory" (default), an LRU of
+// CACHE_SIZE entries, "redis", a Redis server at REDIS_ADDR, or "none"
+func Open() (Cache, error) {
+    switch backend := os.Getenv("CACHE_BACKEND"); backend {
+    case "", "memory":
+        size := 1000
+        if value := os.Getenv("CACHE_SIZE"); value != "" {
+            parsed, err := strconv.Atoi(value)
+            if err != nil || parsed < 1 {
+                return nil, fmt.Errorf("invalid CACHE_SIZE %q", value)
+            }
+            size = parsed
+        }
+        return NewLRU(size), nil
+    case "redis":
+        addr := os.Getenv("REDIS_ADDR")
+        if addr == "" {
+            addr = "localhost:6379"
+        }
+        db := 0
+        if value := os.Getenv("REDIS_DB"); value != "" {
+            parsed, err := strconv.Atoi(value)
+            if err != nil {
+                return nil, fmt.Errorf("invalid REDIS_DB %q", value)
+            }
+            db = parsed
+        }
+        return NewRedis(addr, os.Getenv("REDIS_PASSWORD"), db), nil
+    case "none":
+        return nil, nil
+    default:
+        return nil, fmt.Errorf("unknown cache backend %q", backend)
+    }
+}
+
+// Groups of keys invalidated together. Listings show every post, so any change drops
+// them all, while a post's detail only changes with the post itself.
+const ListingsGroup = "posts"
+
+// PostGroup is the group of the cached details of a post
+func PostGroup(postID uint) string {
+    return fmt.Sprintf("post:%d", postID)
+}
+
+// UsersGroup changes with the names and pictures shown next to posts and comments
+const UsersGroup = "users"
+
+// ViewerGroup changes with what a user's own responses show about their watchlist and
+// tag preferences
+func ViewerGroup(userID uint) string {
+    return fmt.Sprintf("viewer:%d", userID)
+}
+
+// VersionPrefix starts the keys of group versions. Caches must not evict them before
+// they expire, a group would fall back to a new version and miss its cached entries.
+const VersionPrefix = "version:"
+
+// groupVersionTTL outlives every cached response, a version can't expire while keys
+// under it are still cached
+const groupVersionTTL = 24 * time.Hour
+
+// Version returns the current version of a group, starting one when the group has none.
+// It only changes when the group is invalidated, so it also validates the responses
+// built from the group.
+func Version(c Cache, group string) (string, error) {
+    version, found, err := c.Get(VersionPrefix + group)
+    if err != nil {
+        return "", err
+    }
+    if found {
+        return string(version), nil
+    }
+    return newVersion(c, group)
+}
+
+// VersionTime returns when a version was started
+func VersionTime(version string) (time.Time, bool) {
+    nanos, err := strconv.ParseInt(version, 36, 64)
+    if err != nil {
+        return time.Time{}, false
+    }
+    return time.Unix(0, nanos), true
+}
+
+// newVersion moves a group to a version named after the current time
+func newVersion(c Cache, group string) (string, error) {
+    version := strconv.FormatInt(time.Now().UnixNano(), 36)
+    if err := c.Set(VersionPrefix+group, []byte(version), groupVersionTTL); err != nil {
+        return "", err
+    }
+    return version, nil
+}
+
+// Key returns the key of an entry in a group. It includes the group's current version,
+// so invalidating the group moves it to keys that haven't been cached yet. It returns ""
+// when the version can't be read, the entry mustn't be cached then.
+func Key(c Cache, group, key string) string {
+    version, err := Version(c, group)
+    if err != nil {
+        log.Printf("Failed to read cache version of %s: %v", group, err)
+        return ""
+    }
+    return group + ":" + version + ":" + key
+}
+
+// Invalidate drops every entry of a group by moving it to a new version. Old entries
+// are never read again and expire on their own.
+func Invalidate(c Cache, group string) error {
+    _, err := newVersion(c, group)
+    return err
+}
+
+// invalidatedGroups returns the groups an event changes
+func invalidatedGroups(event events.Event) []string {
+    switch event.Type {
+    case events.PostCreated, events.PostUpdated, events.PostDeleted,
+        events.CommentCreated, events.CommentUpdated, events.CommentDeleted,
+        events.SolutionMarked, events.MetooAdded, events.MetooRemoved,
+        events.ReactionAdded, events.ReactionRemoved:
+        return []string{ListingsGroup, PostGroup(event.PostID)}
+    case events.SynonymsChanged:
+        // Tag filters of listings resolve synonyms
+        return []string{ListingsGroup}
+    case events.UserUpdated:
+        return []string{ListingsGroup, UsersGroup}
+    case events.WatchlistChanged, events.TagPreferenceChanged:
+        return []string{ViewerGroup(event.UserID)}
+    }
+    return nil
+}
+
+// Sync invalidates the cached posts on the changes that show in them
+func Sync(c Cache) {
+    events.Subscribe(func(event events.Event) {
+        for _, group := range invalidatedGroups(event) {
+            if err := Invalidate(c, group); err != nil {
+                log.Printf("[CACHE] Failed to invalidate %s on %s: %v", group, event.Type, err)
+            }
+        }
+    })
+}
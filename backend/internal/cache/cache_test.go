@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"reflect"
+	"testing"
+
+	"techquire-backend/internal/events"
+)
+
+func TestInvalidatedGroups(t *testing.T) {
+    tests := []struct {
+        event events.Event
+        want  []string
+    }{
+        {events.Event{Type: events.CommentCreated, PostID: 3}, []string{ListingsGroup, "post:3"}},
+        {events.Event{Type: events.SynonymsChanged}, []string{ListingsGroup}},
+        {events.Event{Type: events.UserUpdated, UserID: 5}, []string{ListingsGroup, UsersGroup}},
+        {events.Event{Type: events.WatchlistChanged, PostID: 3, UserID: 5}, []string{"viewer:5"}},
+        {events.Event{Type: events.TagPreferenceChanged, UserID: 5}, []string{"viewer:5"}},
+        {events.Event{Type: events.NotificationCreated, UserID: 5}, nil},
+    }
+
+    for _, tt := range tests {
+        if got := invalidatedGroups(tt.event); !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("invalidatedGroups(%s) = %v, want %v", tt.event.Type, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache holding up to a fixed number of entries, evicting the
+// least recently used ones first. Each server instance has its own. Group versions are
+// kept apart and only dropped when they expire.
+type LRU struct {
+    mu       sync.Mutex
+    size     int
+    order    *list.List // Front is the most recently used
+    entries  map[string]*list.Element
+    versions map[string]lruEntry
+    sweepAt  int // Number of versions that triggers dropping the expired ones
+}
+
+type lruEntry struct {
+    key     string
+    value   []byte
+    expires time.Time
+}
+
+// NewLRU creates an in-memory cache of the given number of entries
+func NewLRU(size int) *LRU {
+    return &LRU{
+        size:     size,
+        order:    list.New(),
+        entries:  make(map[string]*list.Element),
+        versions: make(map[string]lruEntry),
+        sweepAt:  size,
+    }
+}
+
+// isVersion reports whether a key holds a group version
+func isVersion(key string) bool {
+    return strings.HasPrefix(key, VersionPrefix)
+}
+
+func (l *LRU) Get(key string) ([]byte, bool, error) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    if isVersion(key) {
+        entry, ok := l.versions[key]
+        if !ok {
+            return nil, false, nil
+        }
+        if time.Now().After(entry.expires) {
+            delete(l.versions, key)
+            return nil, false, nil
+        }
+        return entry.value, true, nil
+    }
+
+    element, ok := l.entries[key]
+    if !ok {
+        return nil, false, nil
+    }
+    entry := element.Value.(*lruEntry)
+    if time.Now().After(entry.expires) {
+        l.remove(element)
+        return nil, false, nil
+    }
+    l.order.MoveToFront(element)
+    return entry.value, true, nil
+}
+
+func (l *LRU) Set(key string, value []byte, ttl time.Duration) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    expires := time.Now().Add(ttl)
+    if isVersion(key) {
+        l.versions[key] = lruEntry{key: key, value: value, expires: expires}
+        if len(l.versions) > l.sweepAt {
+            l.sweepVersions()
+        }
+        return nil
+    }
+    if element, ok := l.entries[key]; ok {
+        entry := element.Value.(*lruEntry)
+        entry.value = value
+        entry.expires = expires
+        l.order.MoveToFront(element)
+        return nil
+    }
+
+    l.entries[key] = l.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+    for l.order.Len() > l.size {
+        l.remove(l.order.Back())
+    }
+    return nil
+}
+
+func (l *LRU) Delete(keys ...string) error {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    for _, key := range keys {
+        delete(l.versions, key)
+        if element, ok := l.entries[key]; ok {
+            l.remove(element)
+        }
+    }
+    return nil
+}
+
+func (l *LRU) Close() error {
+    return nil
+}
+
+// sweepVersions drops the expired versions, the lock must be held. The next sweep waits
+// until the versions doubled, so sweeping takes constant time per Set.
+func (l *LRU) sweepVersions() {
+    now := time.Now()
+    for key, entry := range l.versions {
+        if now.After(entry.expires) {
+            delete(l.versions, key)
+        }
+    }
+    l.sweepAt = 2 * len(l.versions)
+    if l.sweepAt < l.size {
+        l.sweepAt = l.size
+    }
+}
+
+// remove drops an element, the lock must be held
+func (l *LRU) remove(element *list.Element) {
+    l.order.Remove(element)
+    delete(l.entries, element.Value.(*lruEntry).key)
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+    lru := NewLRU(2)
+    lru.Set("a", []byte("1"), time.Minute)
+    lru.Set("b", []byte("2"), time.Minute)
+    lru.Get("a")
+    lru.Set("c", []byte("3"), time.Minute)
+
+    if _, found, _ := lru.Get("b"); found {
+        t.Error("b should have been evicted")
+    }
+    for _, key := range []string{"a", "c"} {
+        if _, found, _ := lru.Get(key); !found {
+            t.Errorf("%s should still be cached", key)
+        }
+    }
+}
+
+func TestLRUExpires(t *testing.T) {
+    lru := NewLRU(2)
+    lru.Set("a", []byte("1"), -time.Second)
+    lru.Set(VersionPrefix+"g", []byte("v"), -time.Second)
+
+    for _, key := range []string{"a", VersionPrefix + "g"} {
+        if _, found, _ := lru.Get(key); found {
+            t.Errorf("%s should have expired", key)
+        }
+    }
+}
+
+func TestLRUKeepsVersions(t *testing.T) {
+    lru := NewLRU(2)
+    if err := Invalidate(lru, "g"); err != nil {
+        t.Fatal(err)
+    }
+    key := Key(lru, "g", "k")
+    if key == "g:0:k" {
+        t.Fatalf("Key() = %q, want the invalidated version", key)
+    }
+
+    // Fill the cache far beyond its size, the version must survive
+    for i := 0; i < 10; i++ {
+        lru.Set(fmt.Sprintf("entry%d", i), []byte("x"), time.Minute)
+        Invalidate(lru, fmt.Sprintf("other%d", i))
+    }
+    if got := Key(lru, "g", "k"); got != key {
+        t.Errorf("Key() = %q after evictions, want %q", got, key)
+    }
+    if len(lru.entries) > 2 {
+        t.Errorf("%d entries cached, want at most 2", len(lru.entries))
+    }
+}
+
+func TestLRUSweepsExpiredVersions(t *testing.T) {
+    lru := NewLRU(2)
+    for i := 0; i < 10; i++ {
+        lru.Set(fmt.Sprintf("%sg%d", VersionPrefix, i), []byte("v"), -time.Second)
+    }
+    if len(lru.versions) > 2 {
+        t.Errorf("%d expired versions kept, want at most 2", len(lru.versions))
+    }
+}
+
+func TestKeyInvalidate(t *testing.T) {
+    lru := NewLRU(10)
+    before := Key(lru, "g", "k")
+    if again := Key(lru, "g", "k"); again != before {
+        t.Errorf("Key() = %q, then %q without invalidation", before, again)
+    }
+
+    Invalidate(lru, "g")
+    if after := Key(lru, "g", "k"); after == before {
+        t.Errorf("Key() = %q after Invalidate, want a new key", after)
+    }
+}
+
+func TestVersion(t *testing.T) {
+    lru := NewLRU(10)
+    start := time.Now()
+    version, err := Version(lru, "g")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if again, _ := Version(lru, "g"); again != version {
+        t.Errorf("Version() = %q, then %q without invalidation", version, again)
+    }
+    started, ok := VersionTime(version)
+    if !ok || started.Before(start) || started.After(time.Now()) {
+        t.Errorf("VersionTime(%q) = %v, %v, want the time it was started", version, started, ok)
+    }
+
+    Invalidate(lru, "g")
+    if after, _ := Version(lru, "g"); after == version {
+        t.Errorf("Version() = %q after Invalidate, want a new version", after)
+    }
+}
+
+// failingCache fails every read
+type failingCache struct{ *LRU }
+
+func (failingCache) Get(string) ([]byte, bool, error) {
+    return nil, false, errors.New("unavailable")
+}
+
+func TestKeyUnreadableVersion(t *testing.T) {
+    if got := Key(failingCache{NewLRU(1)}, "g", "k"); got != "" {
+        t.Errorf("Key() = %q, want \"\" when the version can't be read", got)
+    }
+}
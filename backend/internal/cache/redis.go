@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Redis is a cache on a Redis server, shared by every server instance. It speaks the
+// Redis protocol (RESP) itself and only needs the GET, SET, DEL, AUTH and SELECT
+// commands, so any compatible server works. Responses are cached under the ETag of their
+// content, so an evicted group version only delays the invalidation of outdated entries.
+type Redis struct {
+    addr     string
+    password string
+    db       int
+    idle     chan *redisConn
+}
+
+// Timeouts of the Redis connections. A slow cache is worse than none, requests fall
+// back to building the response.
+const (
+    redisDialTimeout = 2 * time.Second
+    redisIOTimeout   = time.Second
+    redisMaxIdle     = 8
+)
+
+// errNil is the nil bulk reply of a missing key
+var errNil = errors.New("redis: nil")
+
+type redisConn struct {
+    conn   net.Conn
+    reader *bufio.Reader
+}
+
+// NewRedis creates a cache on the Redis server at addr. Connections are opened on
+// first use.
+func NewRedis(addr, password string, db int) *Redis {
+    return &Redis{
+        addr:     addr,
+        password: password,
+        db:       db,
+        idle:     make(chan *redisConn, redisMaxIdle),
+    }
+}
+
+func (r *Redis) Get(key string) ([]byte, bool, error) {
+    reply, err := r.do("GET", key)
+    if err == errNil {
+        return nil, false, nil
+    }
+    if err != nil {
+        return nil, false, err
+    }
+    value, ok := reply.([]byte)
+    if !ok {
+        return nil, false, fmt.Errorf("redis: unexpected GET reply %v", reply)
+    }
+    return value, true, nil
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) error {
+    _, err := r.do("SET", key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+    return err
+}
+
+func (r *Redis) Delete(keys ...string) error {
+    if len(keys) == 0 {
+        return nil
+    }
+    _, err := r.do(append([]string{"DEL"}, keys...)...)
+    return err
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+    for {
+        select {
+        case conn := <-r.idle:
+            conn.conn.Close()
+        default:
+            return nil
+        }
+    }
+}
+
+// do runs a command on a pooled connection. Connections are discarded after any error,
+// as the protocol state is unknown.
+func (r *Redis) do(args ...string) (interface{}, error) {
+    conn, err := r.get()
+    if err != nil {
+        return nil, err
+    }
+    reply, err := conn.command(args...)
+    if err != nil && err != errNil {
+        if _, isServerError := err.(redisError); !isServerError {
+            conn.conn.Close()
+            return nil, err
+        }
+    }
+    r.put(conn)
+    return reply, err
+}
+
+// get takes an idle connection or opens a new one
+func (r *Redis) get() (*redisConn, error) {
+    select {
+    case conn := <-r.idle:
+        return conn, nil
+    default:
+    }
+
+    netConn, err := net.DialTimeout("tcp", r.addr, redisDialTimeout)
+    if err != nil {
+        return nil, err
+    }
+    conn := &redisConn{conn: netConn, reader: bufio.NewReader(netConn)}
+    if r.password != "" {
+        if _, err := conn.command("AUTH", r.password); err != nil {
+            netConn.Close()
+            return nil, err
+        }
+    }
+    if r.db != 0 {
+        if _, err := conn.command("SELECT", strconv.Itoa(r.db)); err != nil {
+            netConn.Close()
+            return nil, err
+        }
+    }
+    return conn, nil
+}
+
+// put returns a connection to the pool, closing it when the pool is full
+func (r *Redis) put(conn *redisConn) {
+    select {
+    case r.idle <- conn:
+    default:
+        conn.conn.Close()
+    }
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string {
+    return "redis: " + string(e)
+}
+
+// command sends a command as an array of bulk strings and reads the reply
+func (c *redisConn) command(args ...string) (interface{}, error) {
+    c.conn.SetDeadline(time.Now().Add(redisIOTimeout))
+
+    var request strings.Builder
+    fmt.Fprintf(&request, "*%d\r\n", len(args))
+    for _, arg := range args {
+        fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+    }
+    if _, err := io.WriteString(c.conn, request.String()); err != nil {
+        return nil, err
+    }
+    return c.readReply()
+}
+
+// readReply reads one reply: a simple string, error, integer, bulk string or array
+func (c *redisConn) readReply() (interface{}, error) {
+    line, err := c.reader.ReadString('\n')
+    if err != nil {
+        return nil, err
+    }
+    if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+        return nil, fmt.Errorf("redis: malformed reply %q", line)
+    }
+    kind, payload := line[0], line[1:len(line)-2]
+
+    switch kind {
+    case '+':
+        return payload, nil
+    case '-':
+        return nil, redisError(payload)
+    case ':':
+        return strconv.ParseInt(payload, 10, 64)
+    case '$':
+        length, err := strconv.Atoi(payload)
+        if err != nil {
+            return nil, fmt.Errorf("redis: malformed bulk length %q", payload)
+        }
+        if length < 0 {
+            return nil, errNil
+        }
+        data := make([]byte, length+2)
+        if _, err := io.ReadFull(c.reader, data); err != nil {
+            return nil, err
+        }
+        return data[:length], nil
+    case '*':
+        count, err := strconv.Atoi(payload)
+        if err != nil {
+            return nil, fmt.Errorf("redis: malformed array length %q", payload)
+        }
+        if count < 0 {
+            return nil, errNil
+        }
+        items := make([]interface{}, 0, count)
+        for i := 0; i < count; i++ {
+            item, err := c.readReply()
+            if err != nil && err != errNil {
+                return nil, err
+            }
+            items = append(items, item)
+        }
+        return items, nil
+    default:
+        return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+    }
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a server speaking enough RESP for the client. reply returns the raw
+// reply to a command, or "" to close the connection without one.
+type fakeRedis struct {
+    listener net.Listener
+    reply    func(args []string) string
+
+    mu       sync.Mutex
+    commands [][]string
+    conns    int
+}
+
+func newFakeRedis(t *testing.T, reply func(args []string) string) *fakeRedis {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    server := &fakeRedis{listener: listener, reply: reply}
+    t.Cleanup(func() { listener.Close() })
+
+    go func() {
+        for {
+            conn, err := listener.Accept()
+            if err != nil {
+                return
+            }
+            server.mu.Lock()
+            server.conns++
+            server.mu.Unlock()
+            go server.serve(conn)
+        }
+    }()
+    return server
+}
+
+// serve answers the commands of a connection until it fails or a reply closes it
+func (s *fakeRedis) serve(conn net.Conn) {
+    defer conn.Close()
+    reader := bufio.NewReader(conn)
+    for {
+        args, err := readCommand(reader)
+        if err != nil {
+            return
+        }
+        s.mu.Lock()
+        s.commands = append(s.commands, args)
+        s.mu.Unlock()
+
+        reply := s.reply(args)
+        if reply == "" {
+            return
+        }
+        if _, err := io.WriteString(conn, reply); err != nil {
+            return
+        }
+    }
+}
+
+// readCommand reads an array of bulk strings
+func readCommand(reader *bufio.Reader) ([]string, error) {
+    readLength := func(prefix byte) (int, error) {
+        line, err := reader.ReadString('\n')
+        if err != nil {
+            return 0, err
+        }
+        if line[0] != prefix {
+            return 0, fmt.Errorf("expected %c, got %q", prefix, line)
+        }
+        return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+    }
+
+    count, err := readLength('*')
+    if err != nil {
+        return nil, err
+    }
+    args := make([]string, count)
+    for i := range args {
+        length, err := readLength('$')
+        if err != nil {
+            return nil, err
+        }
+        data := make([]byte, length+2)
+        if _, err := io.ReadFull(reader, data); err != nil {
+            return nil, err
+        }
+        args[i] = string(data[:length])
+    }
+    return args, nil
+}
+
+func (s *fakeRedis) received() ([][]string, int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([][]string(nil), s.commands...), s.conns
+}
+
+// bulk encodes a bulk string reply
+func bulk(value string) string {
+    return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
+
+func TestRedisGet(t *testing.T) {
+    server := newFakeRedis(t, func(args []string) string {
+        if args[1] == "hit" {
+            // Values may contain line breaks, bulk strings are read by length
+            return bulk("line\r\nbreak")
+        }
+        return "$-1\r\n"
+    })
+    redis := NewRedis(server.listener.Addr().String(), "", 0)
+    defer redis.Close()
+
+    value, found, err := redis.Get("hit")
+    if err != nil || !found || string(value) != "line\r\nbreak" {
+        t.Errorf("Get(hit) = %q, %v, %v, want the value", value, found, err)
+    }
+    value, found, err = redis.Get("miss")
+    if err != nil || found || value != nil {
+        t.Errorf("Get(miss) = %q, %v, %v, want a miss", value, found, err)
+    }
+
+    commands, conns := server.received()
+    want := [][]string{{"GET", "hit"}, {"GET", "miss"}}
+    if !reflect.DeepEqual(commands, want) {
+        t.Errorf("commands = %q, want %q", commands, want)
+    }
+    if conns != 1 {
+        t.Errorf("%d connections, want 1 reused", conns)
+    }
+}
+
+func TestRedisSetAndDelete(t *testing.T) {
+    server := newFakeRedis(t, func(args []string) string {
+        if args[0] == "DEL" {
+            return ":2\r\n"
+        }
+        return "+OK\r\n"
+    })
+    redis := NewRedis(server.listener.Addr().String(), "", 0)
+    defer redis.Close()
+
+    if err := redis.Set("key", []byte("value"), 1500*time.Millisecond); err != nil {
+        t.Fatal(err)
+    }
+    if err := redis.Delete("a", "b"); err != nil {
+        t.Fatal(err)
+    }
+    // Nothing to delete sends nothing
+    if err := redis.Delete(); err != nil {
+        t.Fatal(err)
+    }
+
+    commands, _ := server.received()
+    want := [][]string{{"SET", "key", "value", "PX", "1500"}, {"DEL", "a", "b"}}
+    if !reflect.DeepEqual(commands, want) {
+        t.Errorf("commands = %q, want %q", commands, want)
+    }
+}
+
+func TestRedisAuthAndSelect(t *testing.T) {
+    server := newFakeRedis(t, func(args []string) string {
+        if args[0] == "GET" {
+            return "$-1\r\n"
+        }
+        return "+OK\r\n"
+    })
+    redis := NewRedis(server.listener.Addr().String(), "secret", 2)
+    defer redis.Close()
+
+    if _, _, err := redis.Get("key"); err != nil {
+        t.Fatal(err)
+    }
+    commands, _ := server.received()
+    want := [][]string{{"AUTH", "secret"}, {"SELECT", "2"}, {"GET", "key"}}
+    if !reflect.DeepEqual(commands, want) {
+        t.Errorf("commands = %q, want %q", commands, want)
+    }
+}
+
+func TestRedisErrorReplyKeepsConnection(t *testing.T) {
+    server := newFakeRedis(t, func(args []string) string {
+        if args[1] == "wrong" {
+            return "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+        }
+        return bulk("value")
+    })
+    redis := NewRedis(server.listener.Addr().String(), "", 0)
+    defer redis.Close()
+
+    _, _, err := redis.Get("wrong")
+    if _, isServerError := err.(redisError); !isServerError {
+        t.Fatalf("Get(wrong) error = %v, want the error reply", err)
+    }
+    // The reply was read completely, the connection is still in sync
+    if value, found, err := redis.Get("right"); err != nil || !found || string(value) != "value" {
+        t.Errorf("Get(right) = %q, %v, %v, want the value", value, found, err)
+    }
+    if _, conns := server.received(); conns != 1 {
+        t.Errorf("%d connections, want 1 kept after the error reply", conns)
+    }
+}
+
+func TestRedisIOErrorDiscardsConnection(t *testing.T) {
+    var mu sync.Mutex
+    first := true
+    server := newFakeRedis(t, func(args []string) string {
+        mu.Lock()
+        defer mu.Unlock()
+        if first {
+            // Drop the connection instead of replying
+            first = false
+            return ""
+        }
+        return bulk("value")
+    })
+    redis := NewRedis(server.listener.Addr().String(), "", 0)
+    defer redis.Close()
+
+    if _, _, err := redis.Get("key"); err == nil {
+        t.Fatal("Get() on a closed connection = nil error, want the I/O error")
+    }
+    if value, found, err := redis.Get("key"); err != nil || !found || string(value) != "value" {
+        t.Errorf("Get() after reconnecting = %q, %v, %v, want the value", value, found, err)
+    }
+    if _, conns := server.received(); conns != 2 {
+        t.Errorf("%d connections, want the broken one replaced", conns)
+    }
+}
+
+func TestRedisUnreachable(t *testing.T) {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    addr := listener.Addr().String()
+    listener.Close()
+
+    if _, _, err := NewRedis(addr, "", 0).Get("key"); err == nil {
+        t.Error("Get() without a server = nil error, want the dial error")
+    }
+}
@@ -0,0 +1,39 @@
+package chat
+
+import (
+	"strings"
+
+	"techquire-backend/internal/models"
+)
+
+// Response is the reply to a slash command, in the format Slack and Mattermost share
+type Response struct {
+    ResponseType string `json:"response_type"` // "ephemeral" or "in_channel"
+    Text         string `json:"text"`
+}
+
+// Ephemeral returns a reply only the user who ran the command sees
+func Ephemeral(text string) Response {
+    return Response{ResponseType: "ephemeral", Text: text}
+}
+
+// InChannel returns a reply everyone in the channel sees
+func InChannel(text string) Response {
+    return Response{ResponseType: "in_channel", Text: text}
+}
+
+// Link formats a link in the markup of a platform: Slack's mrkdwn or Mattermost's Markdown
+func Link(platform, url, text string) string {
+    if platform == models.ChatSlack {
+        return "<" + url + "|" + Escape(platform, text) + ">"
+    }
+    return "[" + Escape(platform, text) + "](" + url + ")"
+}
+
+// Escape makes user content safe to include in a reply on a platform
+func Escape(platform, text string) string {
+    if platform == models.ChatSlack {
+        return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+    }
+    return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(text)
+}
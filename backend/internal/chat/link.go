@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// LinkTokenTTL is how long a link to connect a chat user to an account stays valid
+const LinkTokenTTL = time.Hour
+
+// ErrInvalidLinkToken is returned for tokens that are malformed, forged or expired
+var ErrInvalidLinkToken = errors.New("invalid or expired link token")
+
+// LinkClaims identify the chat user a link token was issued to
+type LinkClaims struct {
+    Platform   string `json:"p"`
+    TeamID     string `json:"t"`
+    ChatUserID string `json:"u"`
+    UserName   string `json:"n"`
+    ExpiresAt  int64  `json:"e"`
+}
+
+// LinkToken signs the claims of a chat user for the link that connects them to the
+// account of whoever opens it while logged in
+func LinkToken(claims LinkClaims) string {
+    payload, _ := json.Marshal(claims)
+    encoded := base64.RawURLEncoding.EncodeToString(payload)
+    return encoded + "." + signLink(encoded)
+}
+
+// ParseLinkToken verifies a link token and returns its claims
+func ParseLinkToken(token string, now time.Time) (LinkClaims, error) {
+    var claims LinkClaims
+    encoded, signature, found := strings.Cut(token, ".")
+    if !found || os.Getenv("JWT_SECRET") == "" || !hmac.Equal([]byte(signLink(encoded)), []byte(signature)) {
+        return claims, ErrInvalidLinkToken
+    }
+    payload, err := base64.RawURLEncoding.DecodeString(encoded)
+    if err != nil {
+        return claims, ErrInvalidLinkToken
+    }
+    if err := json.Unmarshal(payload, &claims); err != nil {
+        return claims, ErrInvalidLinkToken
+    }
+    if now.Unix() > claims.ExpiresAt {
+        return claims, ErrInvalidLinkToken
+    }
+    return claims, nil
+}
+
+func signLink(encoded string) string {
+    mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+    mac.Write([]byte("chat-link:" + encoded))
+    return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLinkToken(t *testing.T) {
+    t.Setenv("JWT_SECRET", "secret")
+    now := time.Unix(1700000000, 0)
+    claims := LinkClaims{Platform: "slack", TeamID: "T1", ChatUserID: "U1", UserName: "ann", ExpiresAt: now.Add(LinkTokenTTL).Unix()}
+    token := LinkToken(claims)
+    encoded, signature, _ := strings.Cut(token, ".")
+    forged := LinkToken(LinkClaims{Platform: "slack", TeamID: "T1", ChatUserID: "U2", ExpiresAt: claims.ExpiresAt})
+    forgedClaims, _, _ := strings.Cut(forged, ".")
+
+    tests := []struct {
+        name    string
+        token   string
+        now     time.Time
+        wantErr bool
+    }{
+        {"valid", token, now, false},
+        {"at expiry", token, time.Unix(claims.ExpiresAt, 0), false},
+        {"expired", token, time.Unix(claims.ExpiresAt+1, 0), true},
+        {"claims of another user", forgedClaims + "." + signature, now, true},
+        {"missing signature", encoded, now, true},
+        {"bad encoding", "!!!." + signLink("!!!"), now, true},
+        {"not JSON", "bm90IGpzb24." + signLink("bm90IGpzb24"), now, true},
+        {"empty", "", now, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := ParseLinkToken(tt.token, tt.now)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("ParseLinkToken() error = %v, want error %v", err, tt.wantErr)
+            }
+            if err == nil && got != claims {
+                t.Errorf("ParseLinkToken() = %+v, want %+v", got, claims)
+            }
+        })
+    }
+}
+
+func TestParseLinkTokenWithoutSecret(t *testing.T) {
+    t.Setenv("JWT_SECRET", "")
+    token := LinkToken(LinkClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()})
+    if _, err := ParseLinkToken(token, time.Now()); err == nil {
+        t.Error("tokens must not be valid without a secret")
+    }
+}
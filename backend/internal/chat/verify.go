@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// maxRequestAge is how old a signed Slack request may be, older ones may be replayed
+const maxRequestAge = 5 * time.Minute
+
+// Errors of request verification
+var (
+    ErrNotConfigured = errors.New("chat commands are not configured")
+    ErrBadSignature  = errors.New("invalid request signature")
+    ErrStaleRequest  = errors.New("request timestamp is too old")
+)
+
+// VerifySlack checks the signature of a Slack request: the X-Slack-Signature header is
+// "v0=" and the hex HMAC-SHA256 of "v0:<timestamp>:<body>" keyed with the app's signing
+// secret, and X-Slack-Request-Timestamp must be recent
+func VerifySlack(secret, timestamp, signature string, body []byte, now time.Time) error {
+    if secret == "" {
+        return ErrNotConfigured
+    }
+    seconds, err := strconv.ParseInt(timestamp, 10, 64)
+    if err != nil {
+        return ErrBadSignature
+    }
+    age := now.Sub(time.Unix(seconds, 0))
+    if age > maxRequestAge || age < -maxRequestAge {
+        return ErrStaleRequest
+    }
+
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write([]byte("v0:" + timestamp + ":"))
+    mac.Write(body)
+    expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+    if !hmac.Equal([]byte(expected), []byte(signature)) {
+        return ErrBadSignature
+    }
+    return nil
+}
+
+// VerifyMattermost checks the token Mattermost sends with every slash command request.
+// Mattermost doesn't sign requests, the token is the shared secret.
+func VerifyMattermost(expected, token string) error {
+    if expected == "" {
+        return ErrNotConfigured
+    }
+    if subtle.ConstantTimeCompare([]byte(expected), []byte(token)) != 1 {
+        return ErrBadSignature
+    }
+    return nil
+}
@@ -0,0 +1,72 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifySlack(t *testing.T) {
+    const (
+        secret    = "8f742231b10e8888abcd99yyyzzz85a5"
+        timestamp = "1531420618"
+        body      = "token=xyzz0WbapA4vBCDEbOTZUnLoHbCfEDHfcnFG&team_id=T1DC2JH3J&team_domain=testteamnow&channel_id=G8PSS9T3V&channel_name=foobar&user_id=U2CERLKJA&user_name=roadrunner&command=%2Fwebhook-collect&text=&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1DC2JH3J%2F397700885554%2F96rGlfmibIGlgcZRskXaIFfN&trigger_id=398738663015.47445629121.803a0bc887a14d10d2c447fce8b6703c"
+        // Computed with: printf '%s' "v0:$timestamp:$body" | openssl dgst -sha256 -hmac "$secret"
+        signature = "v0=11607f4e0133614a67b48ba34bcd47161d7f83724dd75f6832a736d1056bff53"
+    )
+    sent := time.Unix(1531420618, 0)
+
+    tests := []struct {
+        name      string
+        secret    string
+        timestamp string
+        signature string
+        body      string
+        now       time.Time
+        want      error
+    }{
+        {"valid", secret, timestamp, signature, body, sent, nil},
+        {"a little later", secret, timestamp, signature, body, sent.Add(maxRequestAge), nil},
+        {"clock skew", secret, timestamp, signature, body, sent.Add(-time.Minute), nil},
+        {"stale", secret, timestamp, signature, body, sent.Add(maxRequestAge + time.Second), ErrStaleRequest},
+        {"from the future", secret, timestamp, signature, body, sent.Add(-maxRequestAge - time.Second), ErrStaleRequest},
+        {"not configured", "", timestamp, signature, body, sent, ErrNotConfigured},
+        {"other secret", "other", timestamp, signature, body, sent, ErrBadSignature},
+        {"tampered body", secret, timestamp, signature, body + "&x=1", sent, ErrBadSignature},
+        {"replayed with a new timestamp", secret, "1531420619", signature, body, sent, ErrBadSignature},
+        {"malformed timestamp", secret, "yesterday", signature, body, sent, ErrBadSignature},
+        {"missing version", secret, timestamp, signature[3:], body, sent, ErrBadSignature},
+        {"missing signature", secret, timestamp, "", body, sent, ErrBadSignature},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := VerifySlack(tt.secret, tt.timestamp, tt.signature, []byte(tt.body), tt.now)
+            if !errors.Is(err, tt.want) {
+                t.Errorf("VerifySlack() = %v, want %v", err, tt.want)
+            }
+        })
+    }
+}
+
+func TestVerifyMattermost(t *testing.T) {
+    tests := []struct {
+        name     string
+        expected string
+        token    string
+        want     error
+    }{
+        {"valid", "abc123", "abc123", nil},
+        {"wrong token", "abc123", "abc124", ErrBadSignature},
+        {"missing token", "abc123", "", ErrBadSignature},
+        {"not configured", "", "", ErrNotConfigured},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := VerifyMattermost(tt.expected, tt.token); !errors.Is(err, tt.want) {
+                t.Errorf("VerifyMattermost() = %v, want %v", err, tt.want)
+            }
+        })
+    }
+}
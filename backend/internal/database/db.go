@@ -18,7 +18,7 @@ var DB *gorm.DB
 
 // ClearDB truncates the relevant tables
 func ClearDB() {
-    tables := []string{"users", "posts", "comments", "reactions", "me_toos", "user_watchlist"}
+    tables := []string{"users", "posts", "comments", "reactions", "me_toos", "user_watchlist", "mentions", "tags", "tag_synonyms", "tag_preferences", "saved_searches", "search_alerts", "notifications", "digest_preferences", "webhooks", "webhook_deliveries", "notification_preferences", "notification_mutes", "quiet_hours", "chat_accounts", "reputation_events", "user_badges"}
     for _, table := range tables {
         err := DB.Exec(fmt.Sprintf("TRUNCATE TABLE %s RESTART IDENTITY CASCADE", table)).Error
         if err != nil {
@@ -28,18 +28,23 @@ func ClearDB() {
     }
 }
 
-// ConnectDB initializes the connection to PostgreSQL
+// ConnectDB initializes the connection to PostgreSQL, then clears and seeds the
+// tables for development
 func ConnectDB() {
-    dbHost := getEnv("DB_HOST", "localhost")
-    dbUser := getEnv("DB_USER", "postgres")
-    dbPass := getEnv("DB_PASS", "postgres")
-    dbName := getEnv("DB_NAME", "techquire_db")
-    dbPort := getEnv("DB_PORT", "5432")
+    Connect()
 
-    dsn := fmt.Sprintf(
-        "host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-        dbHost, dbUser, dbPass, dbName, dbPort,
-    )
+    // Clear tables and seed data for development
+    log.Println("[DB] Clearing tables...")
+    ClearDB()
+    log.Println("[DB] Seeding tables...")
+    SeedAll()
+    log.Println("[DB] Seeding completed!")
+}
+
+// Connect initializes the connection to PostgreSQL and migrates the schema, keeping the
+// existing data. Used by commands that work on the current database.
+func Connect() {
+    dsn := DSN()
 
     // Set up a new logger with custom configuration
     // This logger will not log SQL statements, only errors and warnings
@@ -63,17 +68,27 @@ func ConnectDB() {
 
     // Auto-migrate the schema
     log.Println("[DB] Starting auto-migration...")
-    if err := DB.AutoMigrate(&models.User{}, &models.Post{}, &models.Comment{}, &models.Reaction{}, &models.MeToo{}, &models.UserWatchlist{}); err != nil {
+    if err := DB.AutoMigrate(&models.User{}, &models.Post{}, &models.Comment{}, &models.Reaction{}, &models.MeToo{}, &models.UserWatchlist{}, &models.Mention{}, &models.Tag{}, &models.TagSynonym{}, &models.TagPreference{}, &models.SavedSearch{}, &models.SearchAlert{}, &models.Notification{}, &models.DigestPreference{}, &models.Webhook{}, &models.WebhookDelivery{}, &models.NotificationPreference{}, &models.NotificationMute{}, &models.QuietHours{}, &models.ChatAccount{}, &models.ReputationEvent{}, &models.UserBadge{}); err != nil {
         log.Fatalf("[ERROR] Failed to migrate database: %v", err)
     }
+    if err := MigrateSearch(); err != nil {
+        log.Fatalf("[ERROR] Failed to migrate search index: %v", err)
+    }
     log.Println("[DB] Auto-migration completed!")
+}
 
-    // Clear tables and seed data for development
-    log.Println("[DB] Clearing tables...")
-    ClearDB()
-    log.Println("[DB] Seeding tables...")
-    SeedAll()
-    log.Println("[DB] Seeding completed!")
+// DSN returns the connection string of the database, for connections outside of GORM
+func DSN() string {
+    dbHost := getEnv("DB_HOST", "localhost")
+    dbUser := getEnv("DB_USER", "postgres")
+    dbPass := getEnv("DB_PASS", "postgres")
+    dbName := getEnv("DB_NAME", "techquire_db")
+    dbPort := getEnv("DB_PORT", "5432")
+
+    return fmt.Sprintf(
+        "host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+        dbHost, dbUser, dbPass, dbName, dbPort,
+    )
 }
 
 // getEnv gets an environment variable or returns a fallback
@@ -0,0 +1,47 @@
+package database
+
+// searchMigrations add the full-text search columns to posts and comments. They are kept
+// up to date by triggers because the posts expression (tags included) is not immutable and
+// can't be a generated column. Title is weighted above tags, tags above content.
+var searchMigrations = []string{
+    `ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+    `CREATE OR REPLACE FUNCTION posts_search_vector_update() RETURNS trigger AS $$
+    BEGIN
+        NEW.search_vector :=
+            setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+            setweight(to_tsvector('english', coalesce(array_to_string(NEW.tags, ' '), '')), 'B') ||
+            setweight(to_tsvector('english', coalesce(NEW.content, '')), 'C');
+        RETURN NEW;
+    END
+    $$ LANGUAGE plpgsql`,
+    `DROP TRIGGER IF EXISTS posts_search_vector_trigger ON posts`,
+    `CREATE TRIGGER posts_search_vector_trigger
+        BEFORE INSERT OR UPDATE OF title, content, tags ON posts
+        FOR EACH ROW EXECUTE FUNCTION posts_search_vector_update()`,
+    `CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)`,
+    `ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector tsvector`,
+    `CREATE OR REPLACE FUNCTION comments_search_vector_update() RETURNS trigger AS $$
+    BEGIN
+        NEW.search_vector := to_tsvector('english', coalesce(NEW.content, ''));
+        RETURN NEW;
+    END
+    $$ LANGUAGE plpgsql`,
+    `DROP TRIGGER IF EXISTS comments_search_vector_trigger ON comments`,
+    `CREATE TRIGGER comments_search_vector_trigger
+        BEFORE INSERT OR UPDATE OF content ON comments
+        FOR EACH ROW EXECUTE FUNCTION comments_search_vector_update()`,
+    `CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector)`,
+    // Backfill existing rows, the updates fire the triggers
+    `UPDATE posts SET title = title WHERE search_vector IS NULL`,
+    `UPDATE comments SET content = content WHERE search_vector IS NULL`,
+}
+
+// MigrateSearch creates or updates the full-text search columns, triggers and indexes
+func MigrateSearch() error {
+    for _, statement := range searchMigrations {
+        if err := DB.Exec(statement).Error; err != nil {
+            return err
+        }
+    }
+    return nil
+}
@@ -10,8 +10,22 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"techquire-backend/internal/models"
+	"techquire-backend/internal/reputation"
+	"techquire-backend/internal/tagging"
 )
 
+// seedTags are the tags assigned to fake posts, with their descriptions
+var seedTags = map[string]string{
+    "go":         "The Go programming language.",
+    "postgresql": "PostgreSQL, the open source relational database.",
+    "docker":     "Building and running containers with Docker.",
+    "kubernetes": "Deploying and operating workloads on Kubernetes.",
+    "react":      "The React UI library.",
+    "typescript": "TypeScript, typed JavaScript.",
+    "networking": "Connectivity, DNS, proxies and everything between services.",
+    "ci-cd":      "Build and deployment pipelines.",
+}
+
 // SeedUsers creates a default and a bunch of fake users for testing purposes.
 func SeedUsers() {
     // Create a default user
@@ -52,6 +66,19 @@ func SeedUsers() {
     }
 }
 
+// SeedTags creates the tags used by fake posts along with their descriptions.
+func SeedTags() {
+    for name, description := range seedTags {
+        tag := models.Tag{
+            Name:        name,
+            Description: description,
+        }
+        if err := DB.Create(&tag).Error; err != nil {
+            log.Printf("Error creating tag: %v", err)
+        }
+    }
+}
+
 // SeedPosts creates a bunch of fake posts for testing purposes.
 func SeedPosts() {
     var users []models.User
@@ -62,11 +89,23 @@ func SeedPosts() {
         return
     }
 
+    tagNames := make([]string, 0, len(seedTags))
+    for name := range seedTags {
+        tagNames = append(tagNames, name)
+    }
+
     for _, user := range users {
         for j := 0; j < rand.Intn(5)+1; j++ {
+            // Pick 0-3 random tags
+            rand.Shuffle(len(tagNames), func(i, j int) {
+                tagNames[i], tagNames[j] = tagNames[j], tagNames[i]
+            })
+            tags := append([]string{}, tagNames[:rand.Intn(4)]...)
+
             post := models.Post{
                 Title:     faker.Sentence(),
                 Content:   faker.Paragraph(),
+                Tags:      tags,
                 UserID:    user.ID,
             }
             if err := DB.Create(&post).Error; err != nil {
@@ -241,28 +280,35 @@ func SeedWatchlist() {
 // SeedAll calls all the individual seed functions
 func SeedAll() {
     SeedUsers()
+    SeedTags()
     SeedPosts()
     SeedComments()
     SeedReactions()
     SeedWatchlist()
 
-    // Update the number of posts, solutions, likes, and dislikes for each user
+    // Update the number of posts and solutions for each user
     var users []models.User
     DB.Find(&users)
     for _, user := range users {
         var postCount int64
         var solutionCount int64
-        var likes int64
-        var dislikes int64
 
         DB.Model(&models.Post{}).Where("user_id = ?", user.ID).Count(&postCount)
         DB.Model(&models.Comment{}).Where("user_id = ? AND is_solution = true", user.ID).Count(&solutionCount)
-        DB.Model(&models.Comment{}).Where("user_id = ?", user.ID).Select("SUM(likes)").Scan(&likes)
-        DB.Model(&models.Comment{}).Where("user_id = ?", user.ID).Select("SUM(dislikes)").Scan(&dislikes)
 
-        user.NumberOfPosts = int(postCount)
-        user.NumberOfSolutions = int(solutionCount)
-        user.Reputation = int(likes - dislikes + solutionCount * 10)
-        DB.Save(&user)
+        DB.Model(&user).Updates(map[string]interface{}{
+            "number_of_posts":     int(postCount),
+            "number_of_solutions": int(solutionCount),
+        })
+    }
+
+    // Derive reputations from the seeded reactions and solutions
+    if err := reputation.Rebuild(DB); err != nil {
+        log.Printf("Error rebuilding reputation: %v", err)
+    }
+
+    // Update tag usage counts from the seeded posts
+    if err := tagging.RecountUsage(DB); err != nil {
+        log.Printf("Error counting tag usage: %v", err)
     }
 }
\ No newline at end of file
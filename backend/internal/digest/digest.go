@@ -0,0 +1,221 @@
+package digest
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/preferences"
+)
+
+// DefaultInterval is how often the worker looks for digests that are due
+const DefaultInterval = time.Hour
+
+// Periods covered by the digests of each frequency
+var periods = map[string]time.Duration{
+    models.DigestDaily:  24 * time.Hour,
+    models.DigestWeekly: 7 * 24 * time.Hour,
+}
+
+// Limits of the sections of a digest, it summarizes rather than lists everything
+const (
+    maxWatchedPosts = 10
+    maxSolvedPosts  = 10
+    maxTopPosts     = 5
+)
+
+// Digest is the activity of a period that concerns a user
+type Digest struct {
+    User      models.User
+    Frequency string
+    Since     time.Time
+    Until     time.Time
+
+    WatchedPosts []PostActivity // Watchlisted posts with new comments
+    SolvedPosts  []PostActivity // MeToo'd posts that got a solution
+    TopPosts     []PostActivity // Most active new posts in followed tags
+
+    UnsubscribeURL string
+}
+
+// PostActivity is a post in a digest with the number of new comments on it
+type PostActivity struct {
+    ID          uint
+    Title       string
+    NewComments int
+    URL         string
+}
+
+// Empty reports whether nothing happened in the period, empty digests aren't sent
+func (d Digest) Empty() bool {
+    return len(d.WatchedPosts) == 0 && len(d.SolvedPosts) == 0 && len(d.TopPosts) == 0
+}
+
+// StartWorker sends the digests that are due in the background
+func StartWorker(db *gorm.DB, m mailer.Mailer, interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            if err := Run(db, m, time.Now()); err != nil {
+                log.Printf("[DIGEST] Failed to send digests: %v", err)
+            }
+        }
+    }()
+}
+
+// Run sends the digests due at now: those whose period has passed since the last one
+func Run(db *gorm.DB, m mailer.Mailer, now time.Time) error {
+    var preferences []models.DigestPreference
+    if err := db.Where("frequency IN ?", []string{models.DigestDaily, models.DigestWeekly}).Find(&preferences).Error; err != nil {
+        return err
+    }
+
+    for _, preference := range preferences {
+        period := periods[preference.Frequency]
+        since := now.Add(-period)
+        if preference.LastSentAt != nil {
+            if now.Sub(*preference.LastSentAt) < period {
+                continue
+            }
+            since = *preference.LastSentAt
+        }
+        if err := send(db, m, preference, since, now); err != nil {
+            log.Printf("[DIGEST] Failed to send the digest of user %d: %v", preference.UserID, err)
+        }
+    }
+    return nil
+}
+
+// send builds and sends the digest of a period. The period counts as covered even when
+// there was nothing to send, so it isn't looked at again.
+func send(db *gorm.DB, m mailer.Mailer, preference models.DigestPreference, since, until time.Time) error {
+    // Claim the period first, when several instances run the worker only the one that
+    // moves last_sent_at on from what it read sends the digest
+    claim := db.Model(&models.DigestPreference{}).
+        Where("id = ? AND last_sent_at IS NOT DISTINCT FROM ?", preference.ID, preference.LastSentAt).
+        Update("last_sent_at", until)
+    if claim.Error != nil {
+        return claim.Error
+    }
+    if claim.RowsAffected == 0 {
+        return nil
+    }
+
+    if err := deliver(db, m, preference, since, until); err != nil {
+        // Give the period back so the next run retries it
+        if release := db.Model(&models.DigestPreference{}).
+            Where("id = ? AND last_sent_at = ?", preference.ID, until).
+            Update("last_sent_at", preference.LastSentAt).Error; release != nil {
+            log.Printf("[DIGEST] Failed to release the digest of user %d: %v", preference.UserID, release)
+        }
+        return err
+    }
+    return nil
+}
+
+// deliver builds a claimed digest and sends it unless it's empty
+func deliver(db *gorm.DB, m mailer.Mailer, preference models.DigestPreference, since, until time.Time) error {
+    var user models.User
+    if err := db.Select("id", "email", "username").First(&user, preference.UserID).Error; err != nil {
+        return err
+    }
+
+    digest, err := Build(db, user, since, until)
+    if err != nil {
+        return err
+    }
+    if digest.Empty() {
+        return nil
+    }
+    digest.Frequency = preference.Frequency
+    digest.UnsubscribeURL = UnsubscribeURL(preference, until)
+
+    message, err := Render(digest)
+    if err != nil {
+        return err
+    }
+    return m.Send(message)
+}
+
+// Build collects the activity between since and until for a user's digest. The user's
+// own comments and posts are left out.
+func Build(db *gorm.DB, user models.User, since, until time.Time) (Digest, error) {
+    digest := Digest{
+        User:  user,
+        Since: since,
+        Until: until,
+    }
+
+    // New comments on watchlisted posts
+    if err := db.Table("comments").
+        Select("posts.id, posts.title, COUNT(comments.id) AS new_comments").
+        Joins("JOIN posts ON posts.id = comments.post_id").
+        Joins("JOIN user_watchlist ON user_watchlist.post_id = posts.id").
+        Where("user_watchlist.user_id = ? AND comments.user_id <> ?", user.ID, user.ID).
+        Where("comments.created_at > ? AND comments.created_at <= ?", since, until).
+        Group("posts.id, posts.title").
+        Order("new_comments DESC, posts.id DESC").
+        Limit(maxWatchedPosts).
+        Scan(&digest.WatchedPosts).Error; err != nil {
+        return digest, err
+    }
+
+    // Solutions on posts the user has the same problem as. Marking a solution updates
+    // the comment, its update time is the closest to when it was marked.
+    if err := db.Table("comments").
+        Select("posts.id, posts.title").
+        Joins("JOIN posts ON posts.id = comments.post_id").
+        Joins("JOIN me_toos ON me_toos.post_id = posts.id").
+        Where("me_toos.user_id = ? AND comments.is_solution = ?", user.ID, true).
+        Where("comments.updated_at > ? AND comments.updated_at <= ?", since, until).
+        Order("comments.updated_at DESC").
+        Limit(maxSolvedPosts).
+        Scan(&digest.SolvedPosts).Error; err != nil {
+        return digest, err
+    }
+
+    // Most active new posts in followed tags
+    if err := db.Table("posts").
+        Select("posts.id, posts.title, (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id) AS new_comments").
+        Where("posts.user_id <> ? AND posts.created_at > ? AND posts.created_at <= ?", user.ID, since, until).
+        Where(`COALESCE(posts.tags, '{}') && ARRAY(SELECT tags.name FROM tag_preferences
+            JOIN tags ON tags.id = tag_preferences.tag_id
+            WHERE tag_preferences.user_id = ? AND tag_preferences.type = ?)`, user.ID, models.TagPreferenceFollow).
+        Order("(SELECT COUNT(*) FROM me_toos WHERE me_toos.post_id = posts.id) + (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id) DESC, posts.id DESC").
+        Limit(maxTopPosts).
+        Scan(&digest.TopPosts).Error; err != nil {
+        return digest, err
+    }
+
+    // Leave out what the user doesn't want in digests or muted
+    userPreferences, err := preferences.Load(db, user.ID)
+    if err != nil {
+        return digest, err
+    }
+    sections := map[string]*[]PostActivity{
+        models.NotificationWatchedComment: &digest.WatchedPosts,
+        models.NotificationMetooSolution:  &digest.SolvedPosts,
+        models.NotificationFollowedTag:    &digest.TopPosts,
+    }
+    for eventType, section := range sections {
+        kept := (*section)[:0]
+        for _, post := range *section {
+            event := preferences.Event{Type: eventType, PostID: post.ID}
+            include, err := userPreferences.ShouldNotify(event, preferences.ChannelDigest, until)
+            if err != nil {
+                return digest, err
+            }
+            if include {
+                post.URL = mailer.Link(fmt.Sprintf("/post/%d", post.ID))
+                kept = append(kept, post)
+            }
+        }
+        *section = kept
+    }
+    return digest, nil
+}
@@ -0,0 +1,59 @@
+package digest
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// plural formats a count with the singular or plural noun
+func plural(count int, singular, plural string) string {
+    if count == 1 {
+        return "1 " + singular
+    }
+    return fmt.Sprintf("%d %s", count, plural)
+}
+
+var (
+    textTemplate = texttemplate.Must(texttemplate.New("digest.txt").
+        Funcs(texttemplate.FuncMap{"plural": plural}).
+        ParseFS(templateFiles, "templates/digest.txt"))
+    htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").
+        Funcs(htmltemplate.FuncMap{"plural": plural}).
+        ParseFS(templateFiles, "templates/digest.html"))
+)
+
+// Render renders a digest as an email with text and HTML versions
+func Render(digest Digest) (mailer.Message, error) {
+    var text, html bytes.Buffer
+    if err := textTemplate.Execute(&text, digest); err != nil {
+        return mailer.Message{}, err
+    }
+    if err := htmlTemplate.Execute(&html, digest); err != nil {
+        return mailer.Message{}, err
+    }
+
+    subject := "Your daily TechQuire digest"
+    if digest.Frequency == models.DigestWeekly {
+        subject = "Your weekly TechQuire digest"
+    }
+    return mailer.Message{
+        To:      digest.User.Email,
+        Subject: subject,
+        Body:    text.String(),
+        HTML:    html.String(),
+        Headers: map[string]string{
+            // One-click unsubscribe (RFC 8058): mail clients POST to the link
+            "List-Unsubscribe":      "<" + digest.UnsubscribeURL + ">",
+            "List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+        },
+    }, nil
+}
@@ -0,0 +1,68 @@
+package digest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"net/url"
+	"os"
+	"time"
+
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+)
+
+// unsubscribeLinkLifetime is how long the unsubscribe link of a digest works. Digests
+// are at most weekly, so newer emails carry fresh links long before it ends.
+const unsubscribeLinkLifetime = 60 * 24 * time.Hour
+
+// UnsubscribeToken signs a user ID for the unsubscribe links of the digests. The links
+// work without logging in, the token proves that the email was sent to the user. It
+// expires and is bound to the preference's unsubscribe version, bumping it revokes every
+// link sent before.
+func UnsubscribeToken(userID uint, version int, expires time.Time) string {
+    mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET")))
+    fmt.Fprintf(mac, "digest-unsubscribe:%d:%d:%d", userID, version, expires.Unix())
+    return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidUnsubscribeToken reports whether a token was issued for a user and version and is
+// still valid at now
+func ValidUnsubscribeToken(userID uint, version int, expires time.Time, token string, now time.Time) bool {
+    if os.Getenv("JWT_SECRET") == "" || !now.Before(expires) {
+        return false
+    }
+    return hmac.Equal([]byte(UnsubscribeToken(userID, version, expires)), []byte(token))
+}
+
+// UnsubscribeURL returns the link that turns off a user's digests, valid for a while
+// after sent
+func UnsubscribeURL(preference models.DigestPreference, sent time.Time) string {
+    expires := sent.Add(unsubscribeLinkLifetime)
+    query := url.Values{}
+    query.Set("user", fmt.Sprint(preference.UserID))
+    query.Set("expires", fmt.Sprint(expires.Unix()))
+    query.Set("token", UnsubscribeToken(preference.UserID, preference.UnsubscribeVersion, expires))
+    return mailer.APILink("/digests/unsubscribe") + "?" + query.Encode()
+}
+
+var unsubscribeTemplate = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/unsubscribe.html"))
+
+// UnsubscribePage is the page of an unsubscribe link
+type UnsubscribePage struct {
+    Action string // URL the confirmation is posted to, "" once unsubscribed
+    Error  string
+}
+
+// RenderUnsubscribePage renders the page that asks to confirm unsubscribing. Opening
+// the link doesn't unsubscribe, link scanners of mail providers open every link.
+func RenderUnsubscribePage(page UnsubscribePage) ([]byte, error) {
+    var html bytes.Buffer
+    if err := unsubscribeTemplate.Execute(&html, page); err != nil {
+        return nil, err
+    }
+    return html.Bytes(), nil
+}
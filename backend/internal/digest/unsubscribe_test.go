@@ -0,0 +1,105 @@
+package digest
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"techquire-backend/internal/models"
+)
+
+func TestValidUnsubscribeToken(t *testing.T) {
+    t.Setenv("JWT_SECRET", "secret")
+    now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+    expires := now.Add(time.Hour)
+    token := UnsubscribeToken(7, 2, expires)
+    tampered := "0" + token[1:]
+    if token[0] == '0' {
+        tampered = "1" + token[1:]
+    }
+
+    tests := []struct {
+        name    string
+        userID  uint
+        version int
+        expires time.Time
+        token   string
+        now     time.Time
+        want    bool
+    }{
+        {"valid", 7, 2, expires, token, now, true},
+        {"other user", 8, 2, expires, token, now, false},
+        {"revoked version", 7, 3, expires, token, now, false},
+        {"extended expiry", 7, 2, expires.Add(time.Hour), token, now, false},
+        {"expired", 7, 2, expires, token, expires, false},
+        {"tampered token", 7, 2, expires, tampered, now, false},
+        {"empty token", 7, 2, expires, "", now, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := ValidUnsubscribeToken(tt.userID, tt.version, tt.expires, tt.token, tt.now); got != tt.want {
+                t.Errorf("ValidUnsubscribeToken() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestValidUnsubscribeTokenWithoutSecret(t *testing.T) {
+    t.Setenv("JWT_SECRET", "")
+    now := time.Now()
+    expires := now.Add(time.Hour)
+    if ValidUnsubscribeToken(7, 0, expires, UnsubscribeToken(7, 0, expires), now) {
+        t.Error("tokens must not be valid without a secret")
+    }
+}
+
+func TestUnsubscribeURL(t *testing.T) {
+    t.Setenv("JWT_SECRET", "secret")
+    sent := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+    link, err := url.Parse(UnsubscribeURL(models.DigestPreference{UserID: 7, UnsubscribeVersion: 2}, sent))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    query := link.Query()
+    if query.Get("user") != "7" {
+        t.Errorf("user = %q, want 7", query.Get("user"))
+    }
+    expires := sent.Add(unsubscribeLinkLifetime)
+    if want := strconv.FormatInt(expires.Unix(), 10); query.Get("expires") != want {
+        t.Errorf("expires = %q, want %q", query.Get("expires"), want)
+    }
+    if !ValidUnsubscribeToken(7, 2, expires, query.Get("token"), sent) {
+        t.Error("token of the link should be valid when sent")
+    }
+    if ValidUnsubscribeToken(7, 2, expires, query.Get("token"), expires) {
+        t.Error("token of the link should expire")
+    }
+}
+
+func TestRenderUnsubscribePage(t *testing.T) {
+    tests := []struct {
+        name string
+        page UnsubscribePage
+        want string
+    }{
+        {"confirmation", UnsubscribePage{Action: "http://api/digests/unsubscribe?user=1&token=x"}, `action="http://api/digests/unsubscribe?user=1&amp;token=x"`},
+        {"done", UnsubscribePage{}, "no longer receive"},
+        {"error", UnsubscribePage{Error: "<b>bad</b>"}, "&lt;b&gt;bad&lt;/b&gt;"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            body, err := RenderUnsubscribePage(tt.page)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if !strings.Contains(string(body), tt.want) {
+                t.Errorf("page doesn't contain %q:\n%s", tt.want, body)
+            }
+        })
+    }
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Type identifies what happened
+type Type string
+
+// Event types published by the handlers after their changes are committed
+const (
+    PostCreated     Type = "post.created"
+    PostUpdated     Type = "post.updated"
+    PostDeleted     Type = "post.deleted"
+    CommentCreated  Type = "comment.created"
+    CommentUpdated  Type = "comment.updated"
+    CommentDeleted  Type = "comment.deleted"
+    SolutionMarked  Type = "comment.solution_marked"
+    MetooAdded      Type = "metoo.added"
+    MetooRemoved    Type = "metoo.removed"
+    ReactionAdded   Type = "reaction.added"
+    ReactionRemoved Type = "reaction.removed"
+    RoleChanged     Type = "user.role_changed"
+    UserUpdated     Type = "user.updated" // Username or profile picture
+
+    // Changes to what a user's own post responses show, UserID is the user
+    WatchlistChanged     Type = "watchlist.changed"
+    TagPreferenceChanged Type = "tag.preference_changed"
+
+    // Synonyms were added or removed, Detail is the tag
+    SynonymsChanged Type = "tag.synonyms_changed"
+
+    // Published by the notifications package for every notification it creates
+    NotificationCreated Type = "notification.created"
+
+    // Published by the badges evaluator for every badge it awards
+    BadgeAwarded Type = "user.badge_awarded"
+)
+
+// Event describes a change to a post or comment. CommentID is 0 for post and metoo events,
+// user events have neither.
+type Event struct {
+    Type      Type
+    PostID    uint
+    CommentID uint
+    UserID    uint   // User who made the change
+    SubjectID uint   // User the change is about, for user and notification events
+    Detail    string // Reaction type, new role, notification type or badge
+    At        time.Time
+}
+
+// Handler is called for every published event
+type Handler func(Event)
+
+var (
+    mu       sync.RWMutex
+    handlers []Handler
+)
+
+// Subscribe registers a handler for all events
+func Subscribe(handler Handler) {
+    mu.Lock()
+    defer mu.Unlock()
+    handlers = append(handlers, handler)
+}
+
+// Publish calls every handler with the event, in the order they subscribed.
+// Handlers run synchronously so they see events in order; slow work belongs in a goroutine.
+// A panicking handler is logged and doesn't stop the others.
+func Publish(event Event) {
+    if event.At.IsZero() {
+        event.At = time.Now()
+    }
+
+    mu.RLock()
+    subscribed := handlers
+    mu.RUnlock()
+
+    for _, handler := range subscribed {
+        func() {
+            defer func() {
+                if r := recover(); r != nil {
+                    log.Printf("[EVENTS] Handler panicked on %s: %v", event.Type, r)
+                }
+            }()
+            handler(event)
+        }()
+    }
+}
@@ -0,0 +1,291 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/search"
+	"techquire-backend/internal/tagging"
+)
+
+// FilterError is returned for invalid filter parameters and maps to a 400 response.
+// Errors in the q parameter also point at the offending token and its byte offset.
+type FilterError struct {
+    Param    string
+    Message  string
+    Token    string
+    Position int
+}
+
+func (e *FilterError) Error() string {
+    if e.Token != "" {
+        return fmt.Sprintf("invalid %s: %s (at %q, position %d)", e.Param, e.Message, e.Token, e.Position)
+    }
+    return fmt.Sprintf("invalid %s: %s", e.Param, e.Message)
+}
+
+// IntRange is an inclusive range of counts, nil bounds are open
+type IntRange struct {
+    Min *int64
+    Max *int64
+}
+
+// Filters are the post filters shared by the post listing endpoints
+type Filters struct {
+    Search        string   // Full-text query in websearch syntax, matched against title, tags and content
+    SearchIn      string   // Where Search matches: search.ScopePosts, ScopeComments or ScopeAll
+    SolutionsOnly bool     // Only match comments that are accepted solutions
+    Tags          []string // Canonical tag names, all of which must be present
+    AuthorID      uint     // Only posts by this user
+    IsMetoo       bool     // Only posts the viewer said "me too" to
+    IsWatchlisted bool     // Only posts on the viewer's watchlist
+    HasSolution   *bool    // Only posts with (true) or without (false) an accepted solution
+    Personal      bool     // Hide posts with ignored tags and boost followed tags (feed=personal)
+    MetooCount    IntRange  // Only posts with this many "me too"s
+    CommentCount  IntRange  // Only posts with this many comments
+    CreatedAfter  time.Time // Only posts created at or after this time, if set
+    CreatedBefore time.Time // Only posts created before this time, if set
+
+    Match *search.Match // Posts matching Search in the search index, set by ResolveSearch
+}
+
+// Fingerprint identifies what the filters select, independent of the order tags were
+// given in. It ties cursors to the filters of the listing they were created for.
+func (f Filters) Fingerprint() string {
+    normalized := f
+    normalized.Match = nil
+    normalized.Tags = append([]string(nil), f.Tags...)
+    sort.Strings(normalized.Tags)
+    // Only plain values, marshaling can't fail
+    data, _ := json.Marshal(normalized)
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:8])
+}
+
+// FilterParams are the GetPosts query parameters read by ParseFilters
+var FilterParams = []string{"search", "q", "tags", "user_id", "is_metoo", "is_watchlisted", "has_solution", "feed", "search_in", "solutions_only"}
+
+// EncodeParams URL encodes the filter parameters among params, for storing a search
+func EncodeParams(params map[string]string) string {
+    values := url.Values{}
+    for _, param := range FilterParams {
+        if value := strings.TrimSpace(params[param]); value != "" {
+            values.Set(param, value)
+        }
+    }
+    return values.Encode()
+}
+
+// DecodeParams decodes parameters encoded with EncodeParams
+func DecodeParams(encoded string) map[string]string {
+    params := make(map[string]string)
+    values, _ := url.ParseQuery(encoded)
+    for param := range values {
+        params[param] = values.Get(param)
+    }
+    return params
+}
+
+// ParseFilters reads the filters from GetPosts query parameters
+func ParseFilters(db *gorm.DB, params map[string]string) (Filters, error) {
+    var filters Filters
+
+    filters.Search = strings.TrimSpace(params["search"])
+
+    filters.SolutionsOnly = parseBool(params["solutions_only"])
+    switch searchIn := params["search_in"]; searchIn {
+    case "":
+        // Restricting to solutions implies searching comments
+        filters.SearchIn = search.ScopePosts
+        if filters.SolutionsOnly {
+            filters.SearchIn = search.ScopeComments
+        }
+    case search.ScopePosts:
+        if filters.SolutionsOnly {
+            return filters, &FilterError{Param: "solutions_only", Message: "requires search_in to be 'comments' or 'all'"}
+        }
+        filters.SearchIn = searchIn
+    case search.ScopeComments, search.ScopeAll:
+        filters.SearchIn = searchIn
+    default:
+        return filters, &FilterError{Param: "search_in", Message: "must be 'posts', 'comments' or 'all'"}
+    }
+
+    if tagsQuery := params["tags"]; tagsQuery != "" {
+        // Tags are normalized and mapped the same way as when posts are saved
+        tags, err := tagging.Canonicalize(db, strings.Split(tagsQuery, ","))
+        if err != nil {
+            return filters, err
+        }
+        filters.Tags = tags
+    }
+
+    if userIDParam := params["user_id"]; userIDParam != "" {
+        authorID, err := strconv.ParseUint(userIDParam, 10, 64)
+        if err != nil {
+            return filters, &FilterError{Param: "user_id", Message: "must be a user ID"}
+        }
+        filters.AuthorID = uint(authorID)
+    }
+
+    filters.IsMetoo = parseBool(params["is_metoo"])
+    filters.IsWatchlisted = parseBool(params["is_watchlisted"])
+
+    if hasSolutionQuery := params["has_solution"]; hasSolutionQuery != "" {
+        hasSolution := hasSolutionQuery == "true"
+        filters.HasSolution = &hasSolution
+    }
+
+    switch params["feed"] {
+    case "", "all":
+    case "personal":
+        filters.Personal = true
+    default:
+        return filters, &FilterError{Param: "feed", Message: "must be 'all' or 'personal'"}
+    }
+
+    // Structured search query, see ParseQuery
+    if q := strings.TrimSpace(params["q"]); q != "" {
+        if err := ParseQuery(db, q, &filters); err != nil {
+            return filters, err
+        }
+    }
+
+    return filters, nil
+}
+
+// ResolveSearch runs the full-text search of the filters in the index, once for both
+// counting and listing the posts. It's apart from ParseFilters, so requests answered
+// from a cache don't pay for it.
+func (f *Filters) ResolveSearch() error {
+    if f.Search == "" || f.Match != nil {
+        return nil
+    }
+    match, err := search.Default.Match(f.SearchRequest())
+    if err != nil {
+        return err
+    }
+    f.Match = &match
+    return nil
+}
+
+// parseBool parses a boolean query parameter the way fiber's QueryBool does, defaulting to false
+func parseBool(value string) bool {
+    b, err := strconv.ParseBool(value)
+    return err == nil && b
+}
+
+// SearchRequest returns the full-text search of the filters
+func (f Filters) SearchRequest() search.Request {
+    return search.Request{
+        Query:         f.Search,
+        Scope:         f.SearchIn,
+        SolutionsOnly: f.SolutionsOnly,
+    }
+}
+
+// SearchesComments reports whether the search matches comments, whose best matches are
+// returned by the index's CommentMatches
+func (f Filters) SearchesComments() bool {
+    return f.Search != "" && f.SearchRequest().SearchesComments()
+}
+
+// RequiresViewer reports whether the filters can only match anything for an authenticated user
+func (f Filters) RequiresViewer() bool {
+    return f.IsMetoo || f.IsWatchlisted
+}
+
+// Apply adds the filters to a query on the posts table.
+// viewerID is the authenticated user, or 0 for anonymous requests, in which case the
+// viewer-specific filters are skipped.
+func (f Filters) Apply(query *gorm.DB, viewerID uint) *gorm.DB {
+    // Filter by author
+    if f.AuthorID > 0 {
+        query = query.Where("posts.user_id = ?", f.AuthorID)
+    }
+
+    // Full-text search in posts and/or their comments
+    if f.Match != nil {
+        query = query.Where(f.Match.Condition, f.Match.Args...)
+    }
+
+    // Filter by tags
+    for _, tag := range f.Tags {
+        query = query.Where("? = ANY(posts.tags)", tag)
+    }
+
+    // Apply user-specific filters if authenticated
+    if viewerID > 0 {
+        // Filter by "metoo" status
+        if f.IsMetoo {
+            query = query.Where("EXISTS (SELECT 1 FROM me_toos WHERE me_toos.post_id = posts.id AND me_toos.user_id = ?)", viewerID)
+        }
+
+        // Filter by watchlisted status
+        if f.IsWatchlisted {
+            query = query.Where("EXISTS (SELECT 1 FROM user_watchlist WHERE user_watchlist.post_id = posts.id AND user_watchlist.user_id = ?)", viewerID)
+        }
+
+        // Hide posts with ignored tags in the personal feed
+        if f.Personal {
+            query = query.Where("NOT (COALESCE(posts.tags, '{}') && ARRAY("+tagPreferenceNames+"))", viewerID, models.TagPreferenceIgnore)
+        }
+    }
+
+    // Filter by solution status
+    if f.HasSolution != nil {
+        if *f.HasSolution {
+            query = query.Where("EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true)")
+        } else {
+            query = query.Where("NOT EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true)")
+        }
+    }
+
+    // Filter by counts
+    query = applyRange(query, "(SELECT COUNT(*) FROM me_toos WHERE me_toos.post_id = posts.id)", f.MetooCount)
+    query = applyRange(query, "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id)", f.CommentCount)
+
+    // Filter by creation date
+    if !f.CreatedAfter.IsZero() {
+        query = query.Where("posts.created_at >= ?", f.CreatedAfter)
+    }
+    if !f.CreatedBefore.IsZero() {
+        query = query.Where("posts.created_at < ?", f.CreatedBefore)
+    }
+
+    return query
+}
+
+// applyRange restricts a count expression to a range
+func applyRange(query *gorm.DB, expression string, r IntRange) *gorm.DB {
+    if r.Min != nil {
+        query = query.Where(expression+" >= ?", *r.Min)
+    }
+    if r.Max != nil {
+        query = query.Where(expression+" <= ?", *r.Max)
+    }
+    return query
+}
+
+// tagPreferenceNames selects the names of the tags a user follows or ignores
+const tagPreferenceNames = `SELECT tags.name FROM tag_preferences
+    JOIN tags ON tags.id = tag_preferences.tag_id
+    WHERE tag_preferences.user_id = ? AND tag_preferences.type = ?`
+
+// PersonalOrder returns the ORDER BY expression that puts posts with tags followed by
+// the viewer first. It is applied before the regular sort of the personal feed.
+func PersonalOrder(viewerID uint) string {
+    return fmt.Sprintf(`CASE WHEN COALESCE(posts.tags, '{}') && ARRAY(SELECT tags.name FROM tag_preferences
+        JOIN tags ON tags.id = tag_preferences.tag_id
+        WHERE tag_preferences.user_id = %d AND tag_preferences.type = '%s') THEN 0 ELSE 1 END`, viewerID, models.TagPreferenceFollow)
+}
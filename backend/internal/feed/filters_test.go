@@ -0,0 +1,49 @@
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"techquire-backend/internal/search"
+)
+
+func TestFingerprint(t *testing.T) {
+    solved := true
+    base := Filters{Search: "timeout", Tags: []string{"go", "postgres"}, HasSolution: &solved}
+
+    same := []struct {
+        name    string
+        filters Filters
+    }{
+        {"tags in another order", Filters{Search: "timeout", Tags: []string{"postgres", "go"}, HasSolution: &solved}},
+        {"resolved search", Filters{Search: "timeout", Tags: []string{"go", "postgres"}, HasSolution: &solved, Match: &search.Match{}}},
+    }
+    for _, tt := range same {
+        if tt.filters.Fingerprint() != base.Fingerprint() {
+            t.Errorf("%s: fingerprint differs", tt.name)
+        }
+    }
+
+    unsolved := false
+    different := []struct {
+        name    string
+        filters Filters
+    }{
+        {"no filters", Filters{}},
+        {"other search", Filters{Search: "timeouts", Tags: []string{"go", "postgres"}, HasSolution: &solved}},
+        {"fewer tags", Filters{Search: "timeout", Tags: []string{"go"}, HasSolution: &solved}},
+        {"unsolved", Filters{Search: "timeout", Tags: []string{"go", "postgres"}, HasSolution: &unsolved}},
+        {"author", Filters{Search: "timeout", Tags: []string{"go", "postgres"}, HasSolution: &solved, AuthorID: 3}},
+        {"created", Filters{Search: "timeout", Tags: []string{"go", "postgres"}, HasSolution: &solved, CreatedAfter: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}},
+    }
+    for _, tt := range different {
+        if tt.filters.Fingerprint() == base.Fingerprint() {
+            t.Errorf("%s: same fingerprint as other filters", tt.name)
+        }
+    }
+
+    // Sorting the tags leaves the filters alone
+    if base.Tags[0] != "go" || same[0].filters.Tags[0] != "postgres" {
+        t.Errorf("Fingerprint() reordered the tags of the filters")
+    }
+}
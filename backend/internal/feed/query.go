@@ -0,0 +1,302 @@
+package feed
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/tagging"
+)
+
+// QueryOperators are the operators understood in the q parameter
+var QueryOperators = []string{"tag", "is", "user", "metoo", "comments", "created"}
+
+// Operator keys are plain words, anything else (URLs, "-word") is searched as text
+var operatorKey = regexp.MustCompile(`^[A-Za-z]+$`)
+
+// queryToken is a word, quoted phrase or operator of a query with its byte offset
+type queryToken struct {
+    Text     string
+    Position int
+}
+
+// ParseQuery parses a structured search query like
+// `tag:go is:solved user:alice metoo:>5 created:>2025-01-01 "connection reset"`
+// into filters. Operators narrow the filters, the remaining words and quoted phrases
+// are added to the full-text search.
+func ParseQuery(db *gorm.DB, q string, filters *Filters) error {
+    tokens, err := tokenizeQuery(q)
+    if err != nil {
+        return err
+    }
+
+    var words []string
+    for _, token := range tokens {
+        key, value, isOperator := splitOperator(token.Text)
+        if !isOperator {
+            words = append(words, token.Text)
+            continue
+        }
+        if err := applyOperator(db, filters, strings.ToLower(key), value); err != nil {
+            err.Param = "q"
+            err.Token = token.Text
+            err.Position = token.Position
+            return err
+        }
+    }
+
+    if len(words) > 0 {
+        filters.Search = strings.TrimSpace(filters.Search + " " + strings.Join(words, " "))
+    }
+    return nil
+}
+
+// tokenizeQuery splits a query on whitespace, keeping quoted phrases together
+func tokenizeQuery(q string) ([]queryToken, error) {
+    var tokens []queryToken
+    start := -1
+    quoteStart := -1
+    for i, r := range q {
+        switch {
+        case r == '"':
+            if start < 0 {
+                start = i
+            }
+            if quoteStart < 0 {
+                quoteStart = i
+            } else {
+                quoteStart = -1
+            }
+        case unicode.IsSpace(r) && quoteStart < 0:
+            if start >= 0 {
+                tokens = append(tokens, queryToken{Text: q[start:i], Position: start})
+                start = -1
+            }
+        default:
+            if start < 0 {
+                start = i
+            }
+        }
+    }
+    if quoteStart >= 0 {
+        return nil, &FilterError{Param: "q", Message: "unterminated quote", Token: q[quoteStart:], Position: quoteStart}
+    }
+    if start >= 0 {
+        tokens = append(tokens, queryToken{Text: q[start:], Position: start})
+    }
+    return tokens, nil
+}
+
+// splitOperator splits a "key:value" token. Tokens that aren't operators are searched as text.
+func splitOperator(token string) (string, string, bool) {
+    key, value, found := strings.Cut(token, ":")
+    if !found || value == "" || strings.HasPrefix(value, "//") || !operatorKey.MatchString(key) {
+        return "", "", false
+    }
+    return key, strings.Trim(value, `"`), true
+}
+
+// applyOperator narrows the filters by one operator
+func applyOperator(db *gorm.DB, filters *Filters, key, value string) *FilterError {
+    switch key {
+    case "tag":
+        tags, err := tagging.Canonicalize(db, []string{value})
+        if err != nil || len(tags) == 0 {
+            return &FilterError{Message: "invalid tag"}
+        }
+        filters.Tags = append(filters.Tags, tags...)
+
+    case "is":
+        switch strings.ToLower(value) {
+        case "solved":
+            hasSolution := true
+            filters.HasSolution = &hasSolution
+        case "unsolved":
+            hasSolution := false
+            filters.HasSolution = &hasSolution
+        case "watchlisted":
+            filters.IsWatchlisted = true
+        case "metoo":
+            filters.IsMetoo = true
+        default:
+            return &FilterError{Message: "is: must be 'solved', 'unsolved', 'watchlisted' or 'metoo'"}
+        }
+
+    case "user":
+        var users []models.User
+        if err := db.Select("id", "username").Where("LOWER(username) = ?", strings.ToLower(value)).Find(&users).Error; err != nil {
+            return &FilterError{Message: "user '" + value + "' couldn't be looked up"}
+        }
+        userID, err := matchUser(value, users)
+        if err != nil {
+            return err
+        }
+        filters.AuthorID = userID
+
+    case "metoo":
+        countRange, err := parseCountRange(value)
+        if err != nil {
+            return &FilterError{Message: "metoo: " + err.Error()}
+        }
+        filters.MetooCount = countRange
+
+    case "comments":
+        countRange, err := parseCountRange(value)
+        if err != nil {
+            return &FilterError{Message: "comments: " + err.Error()}
+        }
+        filters.CommentCount = countRange
+
+    case "created":
+        after, before, err := parseDateRange(value)
+        if err != nil {
+            return &FilterError{Message: "created: " + err.Error()}
+        }
+        filters.CreatedAfter = after
+        filters.CreatedBefore = before
+
+    default:
+        return &FilterError{Message: "unknown operator '" + key + "', expected one of " + strings.Join(QueryOperators, ", ")}
+    }
+    return nil
+}
+
+// matchUser picks the user a user: operator means among the users whose name matches
+// it ignoring case. Usernames are case-sensitive, so an exact match wins and otherwise
+// the match has to be unique.
+func matchUser(value string, candidates []models.User) (uint, *FilterError) {
+    for _, user := range candidates {
+        if user.Username == value {
+            return user.ID, nil
+        }
+    }
+    switch len(candidates) {
+    case 0:
+        return 0, &FilterError{Message: "user '" + value + "' not found"}
+    case 1:
+        return candidates[0].ID, nil
+    }
+    names := make([]string, 0, len(candidates))
+    for _, user := range candidates {
+        names = append(names, user.Username)
+    }
+    return 0, &FilterError{Message: "user '" + value + "' is ambiguous, use one of " + strings.Join(names, ", ")}
+}
+
+// splitComparison splits a leading comparison (>, >=, <, <=) off a value
+func splitComparison(value string) (string, string) {
+    for _, op := range []string{">=", "<=", ">", "<"} {
+        if strings.HasPrefix(value, op) {
+            return op, value[len(op):]
+        }
+    }
+    return "", value
+}
+
+// parseCountRange parses ">5", ">=5", "<5", "<=5", "5" or "2..10" into an inclusive range
+func parseCountRange(value string) (IntRange, error) {
+    var countRange IntRange
+    parse := func(s string) (int64, error) {
+        n, err := strconv.ParseInt(s, 10, 64)
+        if err != nil || n < 0 {
+            return 0, errors.New("expected a count like 5, >5, <=5 or 2..10")
+        }
+        return n, nil
+    }
+
+    if low, high, isRange := strings.Cut(value, ".."); isRange {
+        min, err := parse(low)
+        if err != nil {
+            return countRange, err
+        }
+        max, err := parse(high)
+        if err != nil {
+            return countRange, err
+        }
+        if min > max {
+            return countRange, errors.New("range starts above its end")
+        }
+        countRange.Min, countRange.Max = &min, &max
+        return countRange, nil
+    }
+
+    op, number := splitComparison(value)
+    n, err := parse(number)
+    if err != nil {
+        return countRange, err
+    }
+    switch op {
+    case ">":
+        n++
+        countRange.Min = &n
+    case ">=":
+        countRange.Min = &n
+    case "<":
+        if n == 0 {
+            return countRange, errors.New("count can't be below 0")
+        }
+        n--
+        countRange.Max = &n
+    case "<=":
+        countRange.Max = &n
+    default:
+        countRange.Min, countRange.Max = &n, &n
+    }
+    return countRange, nil
+}
+
+// parseDate parses a date (2006-01-02) or timestamp (RFC 3339). It returns the start of
+// the value and the exclusive end of the period it covers: the next day for a date, the
+// next second for a timestamp.
+func parseDate(value string) (time.Time, time.Time, error) {
+    if day, err := time.Parse("2006-01-02", value); err == nil {
+        return day, day.AddDate(0, 0, 1), nil
+    }
+    if t, err := time.Parse(time.RFC3339, value); err == nil {
+        return t, t.Add(time.Second), nil
+    }
+    return time.Time{}, time.Time{}, errors.New("expected a date like 2025-01-01, >2025-01-01 or 2025-01-01..2025-02-01")
+}
+
+// parseDateRange parses ">date", ">=date", "<date", "<=date", "date" or "date..date"
+// into an inclusive start and exclusive end. Zero times are open ends.
+func parseDateRange(value string) (time.Time, time.Time, error) {
+    if low, high, isRange := strings.Cut(value, ".."); isRange {
+        after, _, err := parseDate(low)
+        if err != nil {
+            return time.Time{}, time.Time{}, err
+        }
+        _, before, err := parseDate(high)
+        if err != nil {
+            return time.Time{}, time.Time{}, err
+        }
+        if !after.Before(before) {
+            return time.Time{}, time.Time{}, errors.New("range starts after its end")
+        }
+        return after, before, nil
+    }
+
+    op, date := splitComparison(value)
+    start, end, err := parseDate(date)
+    if err != nil {
+        return time.Time{}, time.Time{}, err
+    }
+    switch op {
+    case ">":
+        return end, time.Time{}, nil
+    case ">=":
+        return start, time.Time{}, nil
+    case "<":
+        return time.Time{}, start, nil
+    case "<=":
+        return time.Time{}, end, nil
+    default:
+        return start, end, nil
+    }
+}
@@ -0,0 +1,234 @@
+package feed
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"techquire-backend/internal/models"
+)
+
+func TestTokenizeQuery(t *testing.T) {
+    tests := []struct {
+        name    string
+        q       string
+        want    []queryToken
+        wantErr bool
+    }{
+        {"empty", "", nil, false},
+        {"words", "connection reset", []queryToken{{"connection", 0}, {"reset", 11}}, false},
+        {"extra whitespace", "  go \t rust ", []queryToken{{"go", 2}, {"rust", 7}}, false},
+        {"quoted phrase", `tag:go "connection reset"`, []queryToken{{"tag:go", 0}, {`"connection reset"`, 7}}, false},
+        {"quoted operator value", `user:"alice" x`, []queryToken{{`user:"alice"`, 0}, {"x", 13}}, false},
+        {"multi-byte offsets", "café tag:go", []queryToken{{"café", 0}, {"tag:go", 6}}, false},
+        {"unterminated quote", `go "connection reset`, nil, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := tokenizeQuery(tt.q)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("tokenizeQuery(%q) error = %v, wantErr %v", tt.q, err, tt.wantErr)
+            }
+            if !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("tokenizeQuery(%q) = %v, want %v", tt.q, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSplitOperator(t *testing.T) {
+    tests := []struct {
+        token      string
+        key, value string
+        isOperator bool
+    }{
+        {"tag:go", "tag", "go", true},
+        {`user:"alice"`, "user", "alice", true},
+        {"metoo:>5", "metoo", ">5", true},
+        {"tag:", "", "", false},
+        {"https://example.com", "", "", false},
+        {"-tag:go", "", "", false},
+        {"plain", "", "", false},
+    }
+
+    for _, tt := range tests {
+        key, value, isOperator := splitOperator(tt.token)
+        if key != tt.key || value != tt.value || isOperator != tt.isOperator {
+            t.Errorf("splitOperator(%q) = %q, %q, %v, want %q, %q, %v",
+                tt.token, key, value, isOperator, tt.key, tt.value, tt.isOperator)
+        }
+    }
+}
+
+func TestParseCountRange(t *testing.T) {
+    count := func(n int64) *int64 { return &n }
+    tests := []struct {
+        value   string
+        want    IntRange
+        wantErr bool
+    }{
+        {"5", IntRange{Min: count(5), Max: count(5)}, false},
+        {">5", IntRange{Min: count(6)}, false},
+        {">=5", IntRange{Min: count(5)}, false},
+        {"<5", IntRange{Max: count(4)}, false},
+        {"<=5", IntRange{Max: count(5)}, false},
+        {"2..10", IntRange{Min: count(2), Max: count(10)}, false},
+        {"3..3", IntRange{Min: count(3), Max: count(3)}, false},
+        {"10..2", IntRange{}, true},
+        {"<0", IntRange{}, true},
+        {"-1", IntRange{}, true},
+        {">", IntRange{}, true},
+        {"five", IntRange{}, true},
+        {"2..", IntRange{}, true},
+    }
+
+    for _, tt := range tests {
+        got, err := parseCountRange(tt.value)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("parseCountRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+            continue
+        }
+        if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("parseCountRange(%q) = %s, want %s", tt.value, formatRange(got), formatRange(tt.want))
+        }
+    }
+}
+
+func formatRange(r IntRange) string {
+    bound := func(n *int64) string {
+        if n == nil {
+            return "open"
+        }
+        return strconv.FormatInt(*n, 10)
+    }
+    return "[" + bound(r.Min) + ", " + bound(r.Max) + "]"
+}
+
+func TestParseDateRange(t *testing.T) {
+    day := func(s string) time.Time {
+        d, _ := time.Parse("2006-01-02", s)
+        return d
+    }
+    tests := []struct {
+        value         string
+        after, before time.Time
+        wantErr       bool
+    }{
+        {"2025-01-01", day("2025-01-01"), day("2025-01-02"), false},
+        {">2025-01-01", day("2025-01-02"), time.Time{}, false},
+        {">=2025-01-01", day("2025-01-01"), time.Time{}, false},
+        {"<2025-01-01", time.Time{}, day("2025-01-01"), false},
+        {"<=2025-01-01", time.Time{}, day("2025-01-02"), false},
+        {"2025-01-01..2025-02-01", day("2025-01-01"), day("2025-02-02"), false},
+        {"2025-01-01..2025-01-01", day("2025-01-01"), day("2025-01-02"), false},
+        {">2025-01-01T10:00:00Z", time.Date(2025, 1, 1, 10, 0, 1, 0, time.UTC), time.Time{}, false},
+        {"2025-02-01..2025-01-01", time.Time{}, time.Time{}, true},
+        {"2025-13-01", time.Time{}, time.Time{}, true},
+        {"yesterday", time.Time{}, time.Time{}, true},
+    }
+
+    for _, tt := range tests {
+        after, before, err := parseDateRange(tt.value)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("parseDateRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+            continue
+        }
+        if !after.Equal(tt.after) || !before.Equal(tt.before) {
+            t.Errorf("parseDateRange(%q) = %v, %v, want %v, %v", tt.value, after, before, tt.after, tt.before)
+        }
+    }
+}
+
+// The operators below don't look anything up, so no database is needed
+func TestParseQuery(t *testing.T) {
+    solved, unsolved := true, false
+    count := func(n int64) *int64 { return &n }
+    tests := []struct {
+        name    string
+        q       string
+        want    Filters
+        wantErr string
+    }{
+        {"text only", "connection reset", Filters{Search: "connection reset"}, ""},
+        {"phrase kept quoted", `"connection reset" go`, Filters{Search: `"connection reset" go`}, ""},
+        {"is solved", "is:solved timeout", Filters{Search: "timeout", HasSolution: &solved}, ""},
+        {"is unsolved, case-insensitive", "IS:Unsolved", Filters{HasSolution: &unsolved}, ""},
+        {"is watchlisted and metoo", "is:watchlisted is:metoo", Filters{IsWatchlisted: true, IsMetoo: true}, ""},
+        {"counts", "metoo:>5 comments:0", Filters{
+            MetooCount:   IntRange{Min: count(6)},
+            CommentCount: IntRange{Min: count(0), Max: count(0)},
+        }, ""},
+        {"created", "created:>=2025-01-01", Filters{CreatedAfter: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}, ""},
+        {"URL is text", "see https://example.com", Filters{Search: "see https://example.com"}, ""},
+        {"unknown operator", "foo:bar", Filters{}, `invalid q: unknown operator 'foo', expected one of tag, is, user, metoo, comments, created (at "foo:bar", position 0)`},
+        {"bad is value", "go is:open", Filters{}, `invalid q: is: must be 'solved', 'unsolved', 'watchlisted' or 'metoo' (at "is:open", position 3)`},
+        {"bad count", "metoo:lots", Filters{}, `invalid q: metoo: expected a count like 5, >5, <=5 or 2..10 (at "metoo:lots", position 0)`},
+        {"unterminated quote", `go "reset`, Filters{}, `invalid q: unterminated quote (at "\"reset", position 3)`},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var filters Filters
+            err := ParseQuery(nil, tt.q, &filters)
+            if tt.wantErr != "" {
+                if err == nil || err.Error() != tt.wantErr {
+                    t.Fatalf("ParseQuery(%q) error = %v, want %s", tt.q, err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("ParseQuery(%q) error = %v", tt.q, err)
+            }
+            if !reflect.DeepEqual(filters, tt.want) {
+                t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.q, filters, tt.want)
+            }
+        })
+    }
+}
+
+func TestParseQueryAppendsToSearch(t *testing.T) {
+    filters := Filters{Search: "timeout"}
+    if err := ParseQuery(nil, "is:solved postgres", &filters); err != nil {
+        t.Fatal(err)
+    }
+    if filters.Search != "timeout postgres" {
+        t.Errorf("Search = %q, want %q", filters.Search, "timeout postgres")
+    }
+}
+
+func TestMatchUser(t *testing.T) {
+    alice := models.User{ID: 1, Username: "alice"}
+    Alice := models.User{ID: 2, Username: "Alice"}
+    ALICE := models.User{ID: 3, Username: "ALICE"}
+
+    tests := []struct {
+        name       string
+        value      string
+        candidates []models.User
+        want       uint
+        wantErr    string
+    }{
+        {"exact", "alice", []models.User{alice}, 1, ""},
+        {"other case", "ALICE", []models.User{alice}, 1, ""},
+        {"exact among case variants", "Alice", []models.User{alice, Alice, ALICE}, 2, ""},
+        {"ambiguous", "aLiCe", []models.User{alice, Alice}, 0, "user 'aLiCe' is ambiguous, use one of alice, Alice"},
+        {"unknown", "bob", nil, 0, "user 'bob' not found"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := matchUser(tt.value, tt.candidates)
+            if tt.wantErr != "" {
+                if err == nil || err.Message != tt.wantErr {
+                    t.Fatalf("matchUser(%q) error = %v, want %s", tt.value, err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil || got != tt.want {
+                t.Errorf("matchUser(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+            }
+        })
+    }
+}
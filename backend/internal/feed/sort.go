@@ -0,0 +1,304 @@
+package feed
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SortKey is one ORDER BY expression of a sort. Cursors hold the value of every key for
+// the last post of a page and continue after it.
+type SortKey struct {
+    Expr string        // SQL expression on the posts table
+    Args []interface{} // Arguments of Expr
+    Type string        // SQL type of Expr, cursor values are cast back to it
+    Desc bool
+}
+
+// Sort is an ordering of posts. posts.id is the final tie-breaker, so the order is total
+// and keyset pagination never skips or repeats a post.
+type Sort struct {
+    Name    string // Identifies the sort in cursors
+    Filters string // Fingerprint of the filters of the listing, see Filters.Fingerprint
+    Keys    []SortKey
+}
+
+// Expressions of the computed sort keys
+const (
+    metooCountExpr   = "COALESCE((SELECT COUNT(*) FROM me_toos WHERE me_toos.post_id = posts.id), 0)"
+    commentCountExpr = "COALESCE((SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id), 0)"
+
+    // Last activity on a post: its latest edit or comment
+    activityExpr = "GREATEST(posts.updated_at, COALESCE((SELECT MAX(comments.created_at) FROM comments WHERE comments.post_id = posts.id), posts.updated_at))"
+
+    // Unanswered posts first: 0 without comments, 1 without a solution, 2 solved
+    unansweredExpr = `CASE
+        WHEN NOT EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id) THEN 0
+        WHEN NOT EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true) THEN 1
+        ELSE 2 END`
+
+    // Hot score: the log of the engagement (comments count double) plus the age bonus
+    // of the post. Every 12.5 hours a post needs e times the engagement to stay level,
+    // and as the bonus only depends on created_at the order doesn't drift over time,
+    // which keeps cursors valid.
+    hotExpr = "CAST(LN(1 + " + metooCountExpr + " + 2 * " + commentCountExpr + ") + EXTRACT(EPOCH FROM posts.created_at) / 45000 AS double precision)"
+)
+
+// newestFirst breaks ties between equally ranked posts
+var newestFirst = SortKey{Expr: "posts.created_at", Type: "timestamptz", Desc: true}
+
+// sortModes are the values of the sort_by parameter. Each returns the sort keys for the
+// direction of sort_dir, descending by default.
+var sortModes = map[string]func(desc bool, filters Filters) []SortKey{
+    "created_at": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: "posts.created_at", Type: "timestamptz", Desc: desc}}
+    },
+    "updated_at": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: "posts.updated_at", Type: "timestamptz", Desc: desc}}
+    },
+    "activity": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: activityExpr, Type: "timestamptz", Desc: desc}}
+    },
+    "metoo_count": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: metooCountExpr, Type: "bigint", Desc: desc}, newestFirst}
+    },
+    "comment_count": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: commentCountExpr, Type: "bigint", Desc: desc}, newestFirst}
+    },
+    "unanswered": func(desc bool, filters Filters) []SortKey {
+        // sort_dir orders the posts by age within each group
+        return []SortKey{{Expr: unansweredExpr, Type: "integer"}, {Expr: "posts.created_at", Type: "timestamptz", Desc: desc}}
+    },
+    "hot": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: hotExpr, Type: "double precision", Desc: desc}}
+    },
+    "relevance": func(desc bool, filters Filters) []SortKey {
+        return []SortKey{{Expr: "CAST((" + filters.Match.Relevance + ") AS double precision)", Args: filters.Match.RelevanceArgs, Type: "double precision", Desc: desc}}
+    },
+}
+
+// SortModes lists the valid sort_by values
+func SortModes() []string {
+    modes := make([]string, 0, len(sortModes))
+    for mode := range sortModes {
+        modes = append(modes, mode)
+    }
+    sort.Strings(modes)
+    return modes
+}
+
+// CheckSort validates the sort_by and sort_dir parameters without resolving the sort
+func CheckSort(sortBy, sortDir string) error {
+    _, _, err := parseSort(sortBy, sortDir)
+    return err
+}
+
+// parseSort returns the sort mode and direction of the sort_by and sort_dir parameters
+func parseSort(sortBy, sortDir string) (string, bool, error) {
+    if sortBy == "" {
+        sortBy = "created_at"
+    }
+    var desc bool
+    switch sortDir {
+    case "", "desc":
+        desc = true
+    case "asc":
+        desc = false
+    default:
+        return "", false, &FilterError{Param: "sort_dir", Message: "must be asc or desc"}
+    }
+    if _, ok := sortModes[sortBy]; !ok {
+        return "", false, &FilterError{Param: "sort_by", Message: "must be one of " + strings.Join(SortModes(), ", ")}
+    }
+    return sortBy, desc, nil
+}
+
+// ResolveSort returns the sort for the sort_by and sort_dir parameters. Posts with
+// followed tags come first in the personal feed, and relevance requires a search, which
+// must have been resolved with ResolveSearch.
+func ResolveSort(sortBy, sortDir string, filters Filters, viewerID uint) (Sort, error) {
+    sortBy, desc, err := parseSort(sortBy, sortDir)
+    if err != nil {
+        return Sort{}, err
+    }
+
+    if sortBy == "relevance" {
+        if filters.Match != nil {
+            // Best matches first, sort_dir doesn't apply
+            desc = true
+        } else {
+            // Relevance only makes sense for a search, fall back to the newest posts otherwise
+            sortBy = "created_at"
+        }
+    }
+    keys := sortModes[sortBy](desc, filters)
+
+    name := sortBy
+    if !desc {
+        name += ":asc"
+    }
+    if filters.Personal && viewerID > 0 {
+        keys = append([]SortKey{{Expr: PersonalOrder(viewerID), Type: "integer"}}, keys...)
+        name = "personal:" + name
+    }
+    return Sort{Name: name, Filters: filters.Fingerprint(), Keys: keys}, nil
+}
+
+// Apply orders a query on the posts table
+func (s Sort) Apply(query *gorm.DB) *gorm.DB {
+    var sql strings.Builder
+    var args []interface{}
+    for _, key := range s.Keys {
+        sql.WriteString(key.Expr)
+        if key.Desc {
+            sql.WriteString(" DESC, ")
+        } else {
+            sql.WriteString(" ASC, ")
+        }
+        args = append(args, key.Args...)
+    }
+    sql.WriteString("posts.id DESC")
+    return query.Order(clause.OrderBy{Expression: clause.Expr{SQL: sql.String(), Vars: args}})
+}
+
+// cursor is the decoded form of the opaque cursor strings
+type cursor struct {
+    Sort    string   `json:"s"`
+    Filters string   `json:"f"`
+    Values  []string `json:"v"`
+    ID      uint     `json:"id"`
+}
+
+// Cursor returns the cursor continuing after the given post
+func (s Sort) Cursor(db *gorm.DB, postID uint) (string, error) {
+    columns := make([]string, 0, len(s.Keys))
+    var args []interface{}
+    for _, key := range s.Keys {
+        columns = append(columns, "CAST(("+key.Expr+") AS text)")
+        args = append(args, key.Args...)
+    }
+
+    values := make([]string, len(s.Keys))
+    if len(columns) > 0 {
+        pointers := make([]interface{}, len(values))
+        for i := range values {
+            pointers[i] = &values[i]
+        }
+        if err := db.Table("posts").
+            Select(strings.Join(columns, ", "), args...).
+            Where("posts.id = ?", postID).
+            Row().Scan(pointers...); err != nil {
+            return "", err
+        }
+    }
+
+    return encodeCursor(cursor{Sort: s.Name, Filters: s.Filters, Values: values, ID: postID})
+}
+
+// encodeCursor encodes a cursor into its opaque string
+func encodeCursor(position cursor) (string, error) {
+    data, err := json.Marshal(position)
+    if err != nil {
+        return "", err
+    }
+    return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor decodes a cursor of the sort. Cursors aren't signed, the values are only
+// ever bound as query arguments and cast to the types of the keys.
+func (s Sort) decodeCursor(encoded string) (cursor, error) {
+    var position cursor
+    invalid := &FilterError{Param: "cursor", Message: "malformed cursor"}
+    data, err := base64.RawURLEncoding.DecodeString(encoded)
+    if err != nil {
+        return position, invalid
+    }
+    if err := json.Unmarshal(data, &position); err != nil || len(position.Values) != len(s.Keys) {
+        return position, invalid
+    }
+    if position.Sort != s.Name {
+        return position, &FilterError{Param: "cursor", Message: "cursor was created for a different sort order"}
+    }
+    // The position of a post in one listing means nothing in another
+    if position.Filters != s.Filters {
+        return position, &FilterError{Param: "cursor", Message: "cursor was created for different filters"}
+    }
+    for i, key := range s.Keys {
+        if !validCursorValue(key.Type, position.Values[i]) {
+            return position, invalid
+        }
+    }
+    return position, nil
+}
+
+// Layouts of timestamptz values cast to text, the offset has minutes or seconds only
+// when they aren't zero
+var timestampLayouts = []string{
+    "2006-01-02 15:04:05.999999999-07",
+    "2006-01-02 15:04:05.999999999-07:00",
+    "2006-01-02 15:04:05.999999999-07:00:00",
+}
+
+// validCursorValue reports whether a cursor value casts to the SQL type of its key, so
+// an edited cursor is rejected instead of failing the query
+func validCursorValue(sqlType, value string) bool {
+    switch sqlType {
+    case "integer", "bigint":
+        _, err := strconv.ParseInt(value, 10, 64)
+        return err == nil
+    case "double precision":
+        _, err := strconv.ParseFloat(value, 64)
+        return err == nil
+    case "timestamptz":
+        for _, layout := range timestampLayouts {
+            if _, err := time.Parse(layout, value); err == nil {
+                return true
+            }
+        }
+        return false
+    }
+    return true
+}
+
+// After restricts a query on the posts table to the posts following a cursor
+func (s Sort) After(query *gorm.DB, encoded string) (*gorm.DB, error) {
+    position, err := s.decodeCursor(encoded)
+    if err != nil {
+        return nil, err
+    }
+
+    // (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... OR (k1 = v1 AND ... AND posts.id < id),
+    // with the comparison flipped for descending keys
+    var alternatives []string
+    var args []interface{}
+    var equalities []string
+    var equalityArgs []interface{}
+    for i, key := range s.Keys {
+        op := ">"
+        if key.Desc {
+            op = "<"
+        }
+        value := "CAST(? AS " + key.Type + ")"
+        comparison := "(" + key.Expr + ") " + op + " " + value
+
+        alternatives = append(alternatives, "("+strings.Join(append(append([]string{}, equalities...), comparison), " AND ")+")")
+        args = append(args, equalityArgs...)
+        args = append(args, key.Args...)
+        args = append(args, position.Values[i])
+
+        equalities = append(equalities, "("+key.Expr+") = "+value)
+        equalityArgs = append(equalityArgs, key.Args...)
+        equalityArgs = append(equalityArgs, position.Values[i])
+    }
+    alternatives = append(alternatives, "("+strings.Join(append(equalities, "posts.id < ?"), " AND ")+")")
+    args = append(args, equalityArgs...)
+    args = append(args, position.ID)
+
+    return query.Where("("+strings.Join(alternatives, " OR ")+")", args...), nil
+}
@@ -0,0 +1,179 @@
+package feed
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRun returns a database that builds statements without running them
+func dryRun(t *testing.T) *gorm.DB {
+    db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=invalid"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return db
+}
+
+func TestResolveSort(t *testing.T) {
+    tests := []struct {
+        name     string
+        sortBy   string
+        sortDir  string
+        filters  Filters
+        viewerID uint
+        want     string
+        wantKeys int
+        wantErr  string
+    }{
+        {"default", "", "", Filters{}, 0, "created_at", 1, ""},
+        {"ascending", "created_at", "asc", Filters{}, 0, "created_at:asc", 1, ""},
+        {"with tie-breaker", "metoo_count", "desc", Filters{}, 0, "metoo_count", 2, ""},
+        {"relevance without search", "relevance", "asc", Filters{}, 0, "created_at:asc", 1, ""},
+        {"personal feed", "hot", "", Filters{Personal: true}, 7, "personal:hot", 2, ""},
+        {"personal feed without viewer", "hot", "", Filters{Personal: true}, 0, "hot", 1, ""},
+        {"unknown mode", "random", "", Filters{}, 0, "", 0, "sort_by"},
+        {"unknown direction", "created_at", "up", Filters{}, 0, "", 0, "sort_dir"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := ResolveSort(tt.sortBy, tt.sortDir, tt.filters, tt.viewerID)
+            if tt.wantErr != "" {
+                var filterErr *FilterError
+                if !errors.As(err, &filterErr) || filterErr.Param != tt.wantErr {
+                    t.Fatalf("ResolveSort() error = %v, want a %s error", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got.Name != tt.want || len(got.Keys) != tt.wantKeys {
+                t.Errorf("ResolveSort() = %s with %d keys, want %s with %d", got.Name, len(got.Keys), tt.want, tt.wantKeys)
+            }
+        })
+    }
+}
+
+func TestDecodeCursor(t *testing.T) {
+    sort, err := ResolveSort("metoo_count", "desc", Filters{}, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    encode := func(position cursor) string {
+        encoded, err := encodeCursor(position)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return encoded
+    }
+    filters := sort.Filters
+    valid := cursor{Sort: "metoo_count", Filters: filters, Values: []string{"3", "2025-01-02 03:04:05.123456+00"}, ID: 42}
+
+    got, err := sort.decodeCursor(encode(valid))
+    if err != nil || !reflect.DeepEqual(got, valid) {
+        t.Errorf("decodeCursor() = %+v, %v, want %+v", got, err, valid)
+    }
+
+    tests := []struct {
+        name    string
+        encoded string
+        wantErr string
+    }{
+        {"offset with minutes", encode(cursor{Sort: "metoo_count", Filters: filters, Values: []string{"3", "2025-01-02 03:04:05+05:30"}, ID: 42}), ""},
+        {"not base64", "not a cursor!", "malformed cursor"},
+        {"not JSON", base64.RawURLEncoding.EncodeToString([]byte("{")), "malformed cursor"},
+        {"missing values", encode(cursor{Sort: "metoo_count", Filters: filters, Values: []string{"3"}, ID: 42}), "malformed cursor"},
+        {"other sort", encode(cursor{Sort: "comment_count", Filters: filters, Values: valid.Values, ID: 42}), "different sort order"},
+        {"edited count", encode(cursor{Sort: "metoo_count", Filters: filters, Values: []string{"3; DROP TABLE posts", valid.Values[1]}, ID: 42}), "malformed cursor"},
+        {"edited time", encode(cursor{Sort: "metoo_count", Filters: filters, Values: []string{"3", "yesterday"}, ID: 42}), "malformed cursor"},
+        {"other filters", encode(cursor{Sort: "metoo_count", Filters: Filters{Search: "go"}.Fingerprint(), Values: valid.Values, ID: 42}), "different filters"},
+        {"no filters", encode(cursor{Sort: "metoo_count", Values: valid.Values, ID: 42}), "different filters"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := sort.decodeCursor(tt.encoded)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("decodeCursor() error = %v, want %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got.Sort != "metoo_count" || got.ID != 42 {
+                t.Errorf("decodeCursor() = %+v, want the position after post 42", got)
+            }
+        })
+    }
+}
+
+func TestValidCursorValue(t *testing.T) {
+    tests := []struct {
+        sqlType string
+        value   string
+        want    bool
+    }{
+        {"bigint", "-12", true},
+        {"bigint", "1.5", false},
+        {"integer", "", false},
+        {"double precision", "12.75", true},
+        {"double precision", "1e+20", true},
+        {"double precision", "Infinity", true},
+        {"double precision", "twelve", false},
+        {"timestamptz", "2025-01-02 03:04:05+00", true},
+        {"timestamptz", "2025-01-02 03:04:05.5-08", true},
+        {"timestamptz", "2025-01-02 03:04:05+05:45:30", true},
+        {"timestamptz", "2025-01-02", false},
+        {"text", "anything", true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.sqlType+" "+tt.value, func(t *testing.T) {
+            if got := validCursorValue(tt.sqlType, tt.value); got != tt.want {
+                t.Errorf("validCursorValue(%q, %q) = %v, want %v", tt.sqlType, tt.value, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestAfter(t *testing.T) {
+    sort, err := ResolveSort("metoo_count", "asc", Filters{}, 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    encoded, err := encodeCursor(cursor{Sort: sort.Name, Filters: sort.Filters, Values: []string{"3", "2025-01-02 03:04:05+00"}, ID: 42})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    query, err := sort.After(dryRun(t).Table("posts"), encoded)
+    if err != nil {
+        t.Fatal(err)
+    }
+    statement := query.Find(&[]map[string]interface{}{}).Statement
+
+    // metoo_count ascending, then newest first, then posts.id descending
+    sql := statement.SQL.String()
+    for _, want := range []string{
+        "(" + metooCountExpr + ") > CAST($1 AS bigint)",
+        "(posts.created_at) < CAST($3 AS timestamptz)",
+        "posts.id < $6",
+    } {
+        if !strings.Contains(sql, want) {
+            t.Errorf("SQL doesn't contain %q:\n%s", want, sql)
+        }
+    }
+    wantArgs := []interface{}{"3", "3", "2025-01-02 03:04:05+00", "3", "2025-01-02 03:04:05+00", uint(42)}
+    if !reflect.DeepEqual(statement.Vars, wantArgs) {
+        t.Errorf("arguments = %v, want %v", statement.Vars, wantArgs)
+    }
+}
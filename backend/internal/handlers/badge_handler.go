@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/badges"
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+)
+
+// userBadgeData represents a badge awarded to a user
+type userBadgeData struct {
+    Badge       string `json:"badge"`
+    Name        string `json:"name"`
+    Description string `json:"description"`
+    Tag         string `json:"tag,omitempty"` // Tag the badge was earned in, for per-tag badges
+    AwardedAt   time.Time `json:"awarded_at"`
+}
+
+// GetBadges returns the badge catalog with the number of times each badge was awarded
+func GetBadges(c *fiber.Ctx) error {
+    var counts []struct {
+        Badge string
+        Count int64
+    }
+    if err := database.DB.Model(&models.UserBadge{}).
+        Select("badge, COUNT(*) AS count").
+        Group("badge").
+        Scan(&counts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count awarded badges",
+        })
+    }
+    countMap := make(map[string]int64, len(counts))
+    for _, count := range counts {
+        countMap[count.Badge] = count.Count
+    }
+
+    catalog := make([]fiber.Map, 0, len(badges.Catalog))
+    for _, rule := range badges.Catalog {
+        catalog = append(catalog, fiber.Map{
+            "badge":         rule.Badge,
+            "name":          rule.Name,
+            "description":   rule.Description,
+            "kind":          rule.Kind,
+            "threshold":     rule.Threshold,
+            "per_tag":       rule.PerTag(),
+            "awarded_count": countMap[rule.Badge],
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "badges": catalog,
+    })
+}
+
+// userBadges returns the badges awarded to a user, newest first. Badges since removed
+// from the catalog keep their identifier as name.
+func userBadges(userID uint) ([]userBadgeData, error) {
+    var awards []models.UserBadge
+    if err := database.DB.Where("user_id = ?", userID).
+        Order("awarded_at DESC, id DESC").
+        Find(&awards).Error; err != nil {
+        return nil, err
+    }
+
+    returnedBadges := make([]userBadgeData, 0, len(awards))
+    for _, award := range awards {
+        data := userBadgeData{
+            Badge:     award.Badge,
+            Name:      award.Badge,
+            Tag:       award.Tag,
+            AwardedAt: award.AwardedAt,
+        }
+        if rule, found := badges.Find(award.Badge); found {
+            data.Name = rule.Name
+            data.Description = rule.Description
+        }
+        returnedBadges = append(returnedBadges, data)
+    }
+    return returnedBadges, nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/cache"
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+)
+
+// postsVersion identifies the content of post responses by the versions of the cache
+// groups they are built from. Sync moves the groups on every change that shows in them,
+// so comparing versions validates responses without building or querying them.
+type postsVersion struct {
+    versions []string
+    viewerID uint
+}
+
+// loadPostsVersion loads the versions behind the responses of a single post, or of the
+// post listings when postID is 0, as seen by a viewer
+func loadPostsVersion(c cache.Cache, postID, viewerID uint) (postsVersion, error) {
+    groups := []string{cache.ListingsGroup}
+    if postID > 0 {
+        groups = []string{cache.PostGroup(postID), cache.UsersGroup}
+    }
+    if viewerID > 0 {
+        groups = append(groups, cache.ViewerGroup(viewerID))
+    }
+
+    version := postsVersion{viewerID: viewerID}
+    for _, group := range groups {
+        groupVersion, err := cache.Version(c, group)
+        if err != nil {
+            return postsVersion{}, err
+        }
+        version.versions = append(version.versions, groupVersion)
+    }
+    return version, nil
+}
+
+// lastModified returns when the latest of the versions was started
+func (v postsVersion) lastModified() time.Time {
+    var latest time.Time
+    for _, version := range v.versions {
+        if started, ok := cache.VersionTime(version); ok && started.After(latest) {
+            latest = started
+        }
+    }
+    return latest.UTC()
+}
+
+// etag returns the ETag of the version. Anonymous responses of a version are
+// byte-identical and get a strong ETag, those with per-user fields a weak one.
+func (v postsVersion) etag() string {
+    sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s", v.viewerID, strings.Join(v.versions, "|"))))
+    tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+    if v.viewerID > 0 {
+        return "W/" + tag
+    }
+    return tag
+}
+
+// notModified sets the validators of a response and reports whether the request's
+// If-None-Match or, without it, If-Modified-Since header shows the client already has
+// the current version. "If-None-Match: *" only matches a resource that exists.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time, exists bool) bool {
+    c.Set(fiber.HeaderETag, etag)
+    if !lastModified.IsZero() {
+        c.Set(fiber.HeaderLastModified, lastModified.Format(http.TimeFormat))
+    }
+
+    // If-None-Match uses the weak comparison, W/ prefixes don't matter
+    if match := c.Get(fiber.HeaderIfNoneMatch); match != "" {
+        etag = strings.TrimPrefix(etag, "W/")
+        for _, candidate := range strings.Split(match, ",") {
+            candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+            if candidate == etag || (candidate == "*" && exists) {
+                return true
+            }
+        }
+        return false
+    }
+    if since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince)); err == nil && !lastModified.IsZero() {
+        return !lastModified.Truncate(time.Second).After(since)
+    }
+    return false
+}
+
+// checkPostsCache sets the caching headers of a post response, for a single post or for
+// the listings when postID is 0. It returns the ETag of the current version, "" when
+// there is none, and whether the request can be answered with 304 Not Modified.
+// Responses with per-user fields (is_metoo, is_watchlisted, ...) may only be cached by
+// the viewer's browser. Without a cache there are no versions to validate against.
+func checkPostsCache(c *fiber.Ctx, postID, viewerID uint) (string, bool) {
+    c.Vary(fiber.HeaderAuthorization)
+    if viewerID > 0 {
+        c.Set(fiber.HeaderCacheControl, "private, no-cache")
+    } else {
+        c.Set(fiber.HeaderCacheControl, "public, no-cache")
+    }
+    if cache.Default == nil {
+        return "", false
+    }
+
+    version, err := loadPostsVersion(cache.Default, postID, viewerID)
+    if err != nil {
+        // Serve the response uncached rather than failing it
+        log.Printf("Failed to load content version: %v", err)
+        return "", false
+    }
+
+    // Listings always exist, a single post only while it has a row. Only
+    // "If-None-Match: *" needs to know.
+    exists := true
+    if postID > 0 && strings.TrimSpace(c.Get(fiber.HeaderIfNoneMatch)) == "*" {
+        var count int64
+        if err := database.DB.Model(&models.Post{}).Where("id = ?", postID).Count(&count).Error; err != nil {
+            log.Printf("Failed to check post %d: %v", postID, err)
+        }
+        exists = count > 0
+    }
+    etag := version.etag()
+    return etag, notModified(c, etag, version.lastModified(), exists)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/cache"
+)
+
+func TestNotModified(t *testing.T) {
+    const etag = `W/"abc"`
+    lastModified := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+    tests := []struct {
+        name    string
+        headers map[string]string
+        exists  bool
+        want    bool
+    }{
+        {"no validators", nil, true, false},
+        {"matching etag", map[string]string{"If-None-Match": `W/"abc"`}, true, true},
+        {"strong form of the etag", map[string]string{"If-None-Match": `"abc"`}, true, true},
+        {"one of several", map[string]string{"If-None-Match": `"x", W/"abc"`}, true, true},
+        {"other etag", map[string]string{"If-None-Match": `"x"`}, true, false},
+        {"star on an existing resource", map[string]string{"If-None-Match": "*"}, true, true},
+        {"star on a missing resource", map[string]string{"If-None-Match": "*"}, false, false},
+        {"not modified since", map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, true, true},
+        {"modified since", map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)}, true, false},
+        {"etag wins over date", map[string]string{
+            "If-None-Match":     `"x"`,
+            "If-Modified-Since": lastModified.Format(http.TimeFormat),
+        }, true, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            app := fiber.New()
+            app.Get("/", func(c *fiber.Ctx) error {
+                if notModified(c, etag, lastModified, tt.exists) {
+                    return c.SendStatus(fiber.StatusNotModified)
+                }
+                return c.SendString("body")
+            })
+
+            req := httptest.NewRequest(http.MethodGet, "/", nil)
+            for name, value := range tt.headers {
+                req.Header.Set(name, value)
+            }
+            resp, err := app.Test(req)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got := resp.StatusCode == fiber.StatusNotModified; got != tt.want {
+                t.Errorf("not modified = %v, want %v", got, tt.want)
+            }
+            if resp.Header.Get("ETag") != etag {
+                t.Errorf("ETag = %q, want %q", resp.Header.Get("ETag"), etag)
+            }
+        })
+    }
+}
+
+func TestCheckPostsCache(t *testing.T) {
+    defer func(previous cache.Cache) { cache.Default = previous }(cache.Default)
+    cache.Default = cache.NewLRU(10)
+
+    app := fiber.New()
+    app.Get("/", func(c *fiber.Ctx) error {
+        var viewerID uint
+        if c.Query("viewer") != "" {
+            viewerID = 7
+        }
+        if _, unchanged := checkPostsCache(c, 0, viewerID); unchanged {
+            return c.SendStatus(fiber.StatusNotModified)
+        }
+        return c.SendString("body")
+    })
+    get := func(target, ifNoneMatch string) *http.Response {
+        req := httptest.NewRequest(http.MethodGet, target, nil)
+        if ifNoneMatch != "" {
+            req.Header.Set("If-None-Match", ifNoneMatch)
+        }
+        resp, err := app.Test(req)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return resp
+    }
+
+    anonymous := get("/", "").Header.Get("ETag")
+    if anonymous == "" || strings.HasPrefix(anonymous, "W/") {
+        t.Errorf("anonymous ETag = %q, want a strong one", anonymous)
+    }
+    viewer := get("/?viewer=1", "").Header.Get("ETag")
+    if !strings.HasPrefix(viewer, "W/") || strings.TrimPrefix(viewer, "W/") == anonymous {
+        t.Errorf("viewer ETag = %q, want a weak one of its own", viewer)
+    }
+    if resp := get("/", anonymous); resp.StatusCode != fiber.StatusNotModified {
+        t.Errorf("status = %d for the current ETag, want 304", resp.StatusCode)
+    }
+
+    // Only the viewer's own changes move their version
+    cache.Invalidate(cache.Default, cache.ViewerGroup(7))
+    if resp := get("/", anonymous); resp.StatusCode != fiber.StatusNotModified {
+        t.Errorf("status = %d after another user's change, want 304", resp.StatusCode)
+    }
+    if resp := get("/?viewer=1", viewer); resp.StatusCode != fiber.StatusOK {
+        t.Errorf("status = %d after the viewer's change, want 200", resp.StatusCode)
+    }
+
+    cache.Invalidate(cache.Default, cache.ListingsGroup)
+    if resp := get("/", anonymous); resp.StatusCode != fiber.StatusOK {
+        t.Errorf("status = %d after the listings changed, want 200", resp.StatusCode)
+    }
+}
@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/chat"
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/feed"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/markdown"
+	"techquire-backend/internal/models"
+)
+
+// maxChatResults caps the posts listed by /techquire search
+const maxChatResults = 5
+
+const chatHelp = "Usage:\n" +
+    "`/techquire search <query>` finds posts, with the same syntax as the search box\n" +
+    "`/techquire ask <title> | <details>` asks a question\n" +
+    "`/techquire show <id>` shows a post\n" +
+    "`/techquire link` connects your chat account to your TechQuire account"
+
+// ChatCommand handles Slack and Mattermost slash commands. Slack requests are verified
+// with the signing secret in SLACK_SIGNING_SECRET, Mattermost requests with the command
+// token in MATTERMOST_COMMAND_TOKEN. Commands act as the TechQuire account linked to the
+// chat user; search and show also work before linking.
+func ChatCommand(c *fiber.Ctx) error {
+    // Verify the request and tell the platforms apart
+    var platform string
+    var err error
+    if signature := c.Get("X-Slack-Signature"); signature != "" {
+        platform = models.ChatSlack
+        err = chat.VerifySlack(os.Getenv("SLACK_SIGNING_SECRET"), c.Get("X-Slack-Request-Timestamp"), signature, c.Body(), time.Now())
+    } else {
+        platform = models.ChatMattermost
+        token := c.FormValue("token")
+        if authHeader := c.Get("Authorization"); strings.HasPrefix(authHeader, "Token ") {
+            token = authHeader[6:]
+        }
+        err = chat.VerifyMattermost(os.Getenv("MATTERMOST_COMMAND_TOKEN"), token)
+    }
+    if err == chat.ErrNotConfigured {
+        return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+            "error": "Chat commands are not configured",
+        })
+    }
+    if err != nil {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    teamID := c.FormValue("team_id")
+    chatUserID := c.FormValue("user_id")
+    if chatUserID == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Missing user_id",
+        })
+    }
+
+    // Find the linked account, 0 if there is none
+    var userID uint
+    var account models.ChatAccount
+    if err := database.DB.Where("platform = ? AND team_id = ? AND chat_user_id = ?", platform, teamID, chatUserID).
+        First(&account).Error; err == nil {
+        userID = account.UserID
+    } else if err != gorm.ErrRecordNotFound {
+        return c.JSON(chat.Ephemeral("Failed to look up your account, please try again"))
+    }
+
+    subcommand, args, _ := strings.Cut(strings.TrimSpace(c.FormValue("text")), " ")
+    args = strings.TrimSpace(args)
+
+    switch strings.ToLower(subcommand) {
+    case "search":
+        return c.JSON(chatSearch(platform, userID, args))
+    case "ask":
+        if userID == 0 {
+            return c.JSON(chatLinkPrompt(platform, teamID, chatUserID, c.FormValue("user_name")))
+        }
+        return c.JSON(chatAsk(platform, userID, args))
+    case "show":
+        return c.JSON(chatShow(platform, args))
+    case "link":
+        return c.JSON(chatLinkPrompt(platform, teamID, chatUserID, c.FormValue("user_name")))
+    default:
+        return c.JSON(chat.Ephemeral(chatHelp))
+    }
+}
+
+// chatLinkPrompt replies with a link to the page that connects the chat user to the
+// account of whoever opens it while logged in and confirms it
+func chatLinkPrompt(platform, teamID, chatUserID, userName string) chat.Response {
+    token := chat.LinkToken(chat.LinkClaims{
+        Platform:   platform,
+        TeamID:     teamID,
+        ChatUserID: chatUserID,
+        UserName:   userName,
+        ExpiresAt:  time.Now().Add(chat.LinkTokenTTL).Unix(),
+    })
+    link := mailer.Link("/chat/link?token=" + token)
+    return chat.Ephemeral("Connect your TechQuire account first: " + chat.Link(platform, link, "link account") +
+        " (valid for one hour)")
+}
+
+// chatSearch lists the posts best matching a query, like GetPosts with q and relevance
+// sorting
+func chatSearch(platform string, userID uint, q string) chat.Response {
+    if q == "" {
+        return chat.Ephemeral("Usage: `/techquire search <query>`")
+    }
+
+    filters, err := feed.ParseFilters(database.DB, map[string]string{"q": q})
+    if err != nil {
+        return chat.Ephemeral("Invalid query: " + err.Error())
+    }
+    if userID == 0 && filters.RequiresViewer() {
+        return chat.Ephemeral("This query needs a linked account, run `/techquire link` first")
+    }
+    if err := filters.ResolveSearch(); err != nil {
+        return chat.Ephemeral("Search failed, please try again")
+    }
+    sort, err := feed.ResolveSort("relevance", "desc", filters, userID)
+    if err != nil {
+        return chat.Ephemeral("Invalid query: " + err.Error())
+    }
+
+    query := filters.Apply(database.DB.Model(&models.Post{}), userID)
+    var totalPosts int64
+    if err := query.Session(&gorm.Session{}).Count(&totalPosts).Error; err != nil {
+        return chat.Ephemeral("Search failed, please try again")
+    }
+    var posts []models.Post
+    if err := sort.Apply(query).Limit(maxChatResults).Find(&posts).Error; err != nil {
+        return chat.Ephemeral("Search failed, please try again")
+    }
+    if len(posts) == 0 {
+        return chat.Ephemeral("No posts match " + chat.Escape(platform, q))
+    }
+
+    // Mark solved posts
+    postIDs := make([]uint, 0, len(posts))
+    for _, post := range posts {
+        postIDs = append(postIDs, post.ID)
+    }
+    var solvedIDs []uint
+    database.DB.Model(&models.Comment{}).Where("post_id IN ? AND is_solution = ?", postIDs, true).Pluck("post_id", &solvedIDs)
+    solved := make(map[uint]bool, len(solvedIDs))
+    for _, postID := range solvedIDs {
+        solved[postID] = true
+    }
+
+    var text strings.Builder
+    fmt.Fprintf(&text, "%d posts match %s", totalPosts, chat.Escape(platform, q))
+    if totalPosts > int64(len(posts)) {
+        fmt.Fprintf(&text, ", top %d:", len(posts))
+    } else {
+        text.WriteString(":")
+    }
+    for _, post := range posts {
+        fmt.Fprintf(&text, "\n• %s", chat.Link(platform, postLink(post.ID), post.Title))
+        if solved[post.ID] {
+            text.WriteString(" (solved)")
+        }
+        if len(post.Tags) > 0 {
+            text.WriteString(" " + chat.Escape(platform, "#"+strings.Join(post.Tags, " #")))
+        }
+    }
+    return chat.Ephemeral(text.String())
+}
+
+// chatAsk creates a post from "<title> | <details>", the details default to the title
+func chatAsk(platform string, userID uint, args string) chat.Response {
+    title, content, _ := strings.Cut(args, "|")
+    title, content = strings.TrimSpace(title), strings.TrimSpace(content)
+    if title == "" {
+        return chat.Ephemeral("Usage: `/techquire ask <title> | <details>`")
+    }
+    if content == "" {
+        content = title
+    }
+
+    post := models.Post{
+        Title:   title,
+        Content: content,
+        UserID:  userID,
+    }
+    user, _, message := createPost(&post)
+    if message != "" {
+        return chat.Ephemeral(message)
+    }
+
+    return chat.InChannel(fmt.Sprintf("%s asked: %s", chat.Escape(platform, user.Username), chat.Link(platform, postLink(post.ID), post.Title)))
+}
+
+// chatShow shows a post with its author, activity and whether it is solved
+func chatShow(platform string, args string) chat.Response {
+    postID, err := strconv.ParseUint(strings.TrimPrefix(args, "#"), 10, 32)
+    if err != nil {
+        return chat.Ephemeral("Usage: `/techquire show <id>`")
+    }
+
+    var post models.Post
+    if err := database.DB.First(&post, postID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return chat.Ephemeral(fmt.Sprintf("Post %d not found", postID))
+        }
+        return chat.Ephemeral("Failed to retrieve the post, please try again")
+    }
+
+    var author models.User
+    database.DB.Select("id", "username").First(&author, post.UserID)
+    var commentCount, metooCount int64
+    database.DB.Model(&models.Comment{}).Where("post_id = ?", post.ID).Count(&commentCount)
+    database.DB.Model(&models.MeToo{}).Where("post_id = ?", post.ID).Count(&metooCount)
+    var solutions int64
+    database.DB.Model(&models.Comment{}).Where("post_id = ? AND is_solution = ?", post.ID, true).Count(&solutions)
+
+    status := "open"
+    if solutions > 0 {
+        status = "solved"
+    }
+    text := fmt.Sprintf("%s by %s, %s\n%s\n%d comments, %d me too",
+        chat.Link(platform, postLink(post.ID), post.Title),
+        chat.Escape(platform, author.Username),
+        status,
+        chat.Escape(platform, markdown.Excerpt(post.Content, 280)),
+        commentCount, metooCount)
+    if len(post.Tags) > 0 {
+        text += "\n" + chat.Escape(platform, "#"+strings.Join(post.Tags, " #"))
+    }
+    return chat.InChannel(text)
+}
+
+// LinkChatAccount connects the chat user a link token was issued to with the
+// GetChatLink shows what a link token from /techquire link would connect, so the page
+// can ask the logged in user to confirm it. Links are sent to whoever ran the command,
+// anyone else who opens one is shown whose chat account it is.
+func GetChatLink(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    claims, err := chat.ParseLinkToken(c.Query("token"), time.Now())
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid or expired link, run /techquire link again",
+        })
+    }
+
+    var accounts []models.ChatAccount
+    if err := database.DB.Where("platform = ? AND team_id = ? AND chat_user_id = ?", claims.Platform, claims.TeamID, claims.ChatUserID).
+        Limit(1).Find(&accounts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve chat account",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "platform":        claims.Platform,
+        "team_id":         claims.TeamID,
+        "chat_user_name":  claims.UserName,
+        "expires_at":      time.Unix(claims.ExpiresAt, 0),
+        "linked":          len(accounts) > 0 && accounts[0].UserID == userID,
+        "linked_to_other": len(accounts) > 0 && accounts[0].UserID != userID,
+    })
+}
+
+// LinkChatAccount connects the chat user of a link token to the authenticated user once
+// they confirmed it. A chat user linked to another account stays linked to it, it has to
+// be unlinked there first.
+func LinkChatAccount(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var request struct {
+        Token   string `json:"token"`
+        Confirm bool   `json:"confirm"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+    if !request.Confirm {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Linking a chat account must be confirmed",
+        })
+    }
+    claims, err := chat.ParseLinkToken(request.Token, time.Now())
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid or expired link, run /techquire link again",
+        })
+    }
+
+    account := models.ChatAccount{
+        Platform:     claims.Platform,
+        TeamID:       claims.TeamID,
+        ChatUserID:   claims.ChatUserID,
+        ChatUserName: claims.UserName,
+        UserID:       userID,
+    }
+    // Linking again only refreshes the chat user name
+    result := database.DB.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "platform"}, {Name: "team_id"}, {Name: "chat_user_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"chat_user_name", "updated_at"}),
+        Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "chat_accounts.user_id = excluded.user_id"}}},
+    }).Create(&account)
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to link chat account",
+        })
+    }
+    if result.RowsAffected == 0 {
+        return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+            "error": "This chat account is linked to another TechQuire account, unlink it there first",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "platform":       account.Platform,
+        "chat_user_name": account.ChatUserName,
+        "message":        "Chat account linked successfully",
+    })
+}
+
+// GetChatAccounts lists the chat accounts linked to the authenticated user
+func GetChatAccounts(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    accounts := []models.ChatAccount{}
+    if err := database.DB.Where("user_id = ?", userID).Order("id ASC").Find(&accounts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve chat accounts",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "chat_accounts": accounts,
+    })
+}
+
+// DeleteChatAccount unlinks a chat account from the authenticated user
+func DeleteChatAccount(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get chat account ID from URL parameter
+    accountID, err := c.ParamsInt("account_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid chat account ID",
+        })
+    }
+
+    result := database.DB.Where("id = ? AND user_id = ?", accountID, userID).Delete(&models.ChatAccount{})
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to unlink chat account",
+        })
+    }
+    if result.RowsAffected == 0 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Chat account not found",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "Chat account unlinked successfully",
+    })
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/digest"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+)
+
+// GetDigestPreference returns how often the authenticated user receives digest emails
+func GetDigestPreference(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    preference := models.DigestPreference{UserID: userID, Frequency: models.DigestOff}
+    if err := database.DB.Where("user_id = ?", userID).Limit(1).Find(&preference).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve digest preference",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "frequency":    preference.Frequency,
+        "last_sent_at": preference.LastSentAt,
+    })
+}
+
+// UpdateDigestPreference sets how often the authenticated user receives digest emails:
+// "daily", "weekly" or "off"
+func UpdateDigestPreference(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var request struct {
+        Frequency string `json:"frequency"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+    switch request.Frequency {
+    case models.DigestOff, models.DigestDaily, models.DigestWeekly:
+    default:
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Frequency must be one of: daily, weekly, off",
+        })
+    }
+
+    // The first digest covers the period before it, not everything since the last one
+    // sent under an earlier preference. The unsubscribe links of earlier digests stop
+    // working, changing the preference is how users revoke them.
+    preference := models.DigestPreference{UserID: userID, Frequency: request.Frequency}
+    if err := database.DB.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "user_id"}},
+        DoUpdates: clause.Assignments(map[string]interface{}{
+            "frequency":           request.Frequency,
+            "last_sent_at":        nil,
+            "unsubscribe_version": gorm.Expr("digest_preferences.unsubscribe_version + 1"),
+            "updated_at":          gorm.Expr("NOW()"),
+        }),
+    }).Create(&preference).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update digest preference",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "frequency": request.Frequency,
+    })
+}
+
+// digestUnsubscribeLink loads the digest preference an unsubscribe link was signed for,
+// it returns false when the link is invalid, expired or revoked
+func digestUnsubscribeLink(c *fiber.Ctx) (models.DigestPreference, bool, error) {
+    var preference models.DigestPreference
+    userID, err := strconv.ParseUint(c.Query("user"), 10, 32)
+    if err != nil {
+        return preference, false, nil
+    }
+    expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+    if err != nil {
+        return preference, false, nil
+    }
+    result := database.DB.Where("user_id = ?", userID).Limit(1).Find(&preference)
+    if result.Error != nil {
+        return preference, false, result.Error
+    }
+    valid := result.RowsAffected > 0 &&
+        digest.ValidUnsubscribeToken(uint(userID), preference.UnsubscribeVersion, time.Unix(expires, 0), c.Query("token"), time.Now())
+    return preference, valid, nil
+}
+
+// sendUnsubscribePage sends the HTML page of an unsubscribe link
+func sendUnsubscribePage(c *fiber.Ctx, status int, page digest.UnsubscribePage) error {
+    body, err := digest.RenderUnsubscribePage(page)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to render page",
+        })
+    }
+    c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+    return c.Status(status).Send(body)
+}
+
+// GetUnsubscribeDigest shows the page of the unsubscribe link in a digest, it asks to
+// confirm with a POST to the same link. Opening the link alone doesn't unsubscribe.
+func GetUnsubscribeDigest(c *fiber.Ctx) error {
+    _, valid, err := digestUnsubscribeLink(c)
+    if err != nil {
+        return sendUnsubscribePage(c, fiber.StatusInternalServerError, digest.UnsubscribePage{Error: "Something went wrong, please try again later."})
+    }
+    if !valid {
+        return sendUnsubscribePage(c, fiber.StatusBadRequest, digest.UnsubscribePage{Error: "This unsubscribe link is invalid or has expired."})
+    }
+    return sendUnsubscribePage(c, fiber.StatusOK, digest.UnsubscribePage{
+        Action: mailer.APILink("/digests/unsubscribe") + "?" + string(c.Request().URI().QueryString()),
+    })
+}
+
+// UnsubscribeDigest turns off the digests of the user an unsubscribe link was signed for.
+// It needs no login and takes the POST of the confirmation page and of one-click
+// unsubscribing from mail clients (RFC 8058). The links sent so far stop working.
+func UnsubscribeDigest(c *fiber.Ctx) error {
+    preference, valid, err := digestUnsubscribeLink(c)
+    if err != nil {
+        return sendUnsubscribePage(c, fiber.StatusInternalServerError, digest.UnsubscribePage{Error: "Something went wrong, please try again later."})
+    }
+    if !valid {
+        return sendUnsubscribePage(c, fiber.StatusBadRequest, digest.UnsubscribePage{Error: "This unsubscribe link is invalid or has expired."})
+    }
+
+    if err := database.DB.Model(&preference).Updates(map[string]interface{}{
+        "frequency":           models.DigestOff,
+        "unsubscribe_version": gorm.Expr("unsubscribe_version + 1"),
+    }).Error; err != nil {
+        return sendUnsubscribePage(c, fiber.StatusInternalServerError, digest.UnsubscribePage{Error: "Failed to unsubscribe, please try again later."})
+    }
+
+    return sendUnsubscribePage(c, fiber.StatusOK, digest.UnsubscribePage{})
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/feed"
+)
+
+// postFields are the plain fields of a post response
+var postFields = []string{
+    "id", "title", "content", "pictures", "created_at", "updated_at",
+    "content_html", "excerpt", "highlights", "matched_comment",
+}
+
+// postIncludes are the related data of a post response and the fields holding them.
+// They cost extra queries, so clients that don't show them can leave them out.
+var postIncludes = map[string][]string{
+    "user":      {"user"},
+    "solution":  {"solution"},
+    "tags":      {"tags"},
+    "metoos":    {"metoo_count", "is_metoo"},
+    "watchlist": {"is_watchlisted"},
+    "comments":  {"comments", "comment_count"},
+}
+
+// postFieldset selects the parts of post responses with the fields= and include=
+// parameters. Without fields every field is returned, without include every related
+// part, so clients that send neither get the full posts as before. The id is always
+// returned.
+type postFieldset struct {
+    fields   map[string]bool // nil returns every field
+    includes map[string]bool // nil includes every related part
+}
+
+// parsePostFieldset reads and validates the fields and include parameters
+func parsePostFieldset(c *fiber.Ctx) (postFieldset, error) {
+    var fieldset postFieldset
+    args := c.Context().QueryArgs()
+
+    if args.Has("fields") {
+        valid := make(map[string]bool)
+        for _, field := range postFields {
+            valid[field] = true
+        }
+        for _, fields := range postIncludes {
+            for _, field := range fields {
+                valid[field] = true
+            }
+        }
+        fieldset.fields = map[string]bool{"id": true}
+        for _, field := range splitList(c.Query("fields")) {
+            if !valid[field] {
+                return fieldset, &feed.FilterError{Param: "fields", Message: "unknown field " + field + ", valid fields are " + strings.Join(sortedKeys(valid), ", ")}
+            }
+            fieldset.fields[field] = true
+        }
+    }
+
+    if args.Has("include") {
+        fieldset.includes = make(map[string]bool)
+        for _, include := range splitList(c.Query("include")) {
+            if _, ok := postIncludes[include]; !ok {
+                valid := make(map[string]bool)
+                for name := range postIncludes {
+                    valid[name] = true
+                }
+                return fieldset, &feed.FilterError{Param: "include", Message: "unknown include " + include + ", valid includes are " + strings.Join(sortedKeys(valid), ", ")}
+            }
+            fieldset.includes[include] = true
+        }
+    }
+    return fieldset, nil
+}
+
+// wants reports whether a field is part of the response, so its data has to be loaded
+func (f postFieldset) wants(field string) bool {
+    if f.fields != nil && !f.fields[field] {
+        return false
+    }
+    if f.includes != nil {
+        for include, fields := range postIncludes {
+            for _, included := range fields {
+                if included == field {
+                    return f.includes[include]
+                }
+            }
+        }
+    }
+    return true
+}
+
+// filter removes the unwanted fields from a post response
+func (f postFieldset) filter(data fiber.Map) fiber.Map {
+    for field := range data {
+        if !f.wants(field) {
+            delete(data, field)
+        }
+    }
+    return data
+}
+
+// splitList splits a comma separated parameter, ignoring blanks
+func splitList(value string) []string {
+    var items []string
+    for _, item := range strings.Split(value, ",") {
+        if item = strings.TrimSpace(item); item != "" {
+            items = append(items, item)
+        }
+    }
+    return items
+}
+
+// sortedKeys returns the keys of a set in order, for error messages
+func sortedKeys(set map[string]bool) []string {
+    keys := make([]string, 0, len(set))
+    for key := range set {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
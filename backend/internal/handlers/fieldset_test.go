@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParsePostFieldset(t *testing.T) {
+    // Every field of a post response, plain and included
+    all := append([]string(nil), postFields...)
+    for _, fields := range postIncludes {
+        all = append(all, fields...)
+    }
+    sort.Strings(all)
+    without := func(removed ...string) []string {
+        var fields []string
+        for _, field := range all {
+            kept := true
+            for _, r := range removed {
+                kept = kept && field != r
+            }
+            if kept {
+                fields = append(fields, field)
+            }
+        }
+        return fields
+    }
+
+    tests := []struct {
+        name       string
+        query      string
+        wantStatus int
+        wantFields []string // Fields the fieldset wants, sorted
+        wantError  string   // Part of the error message
+    }{
+        {"default set", "", fiber.StatusOK, all, ""},
+        {"empty include leaves out every related part", "include=", fiber.StatusOK, postFields, ""},
+        {"empty fields keeps the id", "fields=", fiber.StatusOK, []string{"id"}, ""},
+        {"fields", "fields=title,%20content,,", fiber.StatusOK, []string{"content", "id", "title"}, ""},
+        {"include brings all its fields", "include=metoos", fiber.StatusOK, without(
+            "user", "solution", "tags", "is_watchlisted", "comments", "comment_count",
+        ), ""},
+        {"field of an include", "fields=title,metoo_count", fiber.StatusOK, []string{"id", "metoo_count", "title"}, ""},
+        {"field of a left out include", "fields=title,metoo_count&include=user", fiber.StatusOK, []string{"id", "title"}, ""},
+        {"fields and their include", "fields=comment_count,user&include=comments,user", fiber.StatusOK, []string{"comment_count", "id", "user"}, ""},
+        {"unknown field", "fields=title,secret", fiber.StatusBadRequest, nil, "unknown field secret"},
+        {"include as field", "fields=metoos", fiber.StatusBadRequest, nil, "unknown field metoos"},
+        {"unknown include", "include=user,votes", fiber.StatusBadRequest, nil, "unknown include votes, valid includes are comments, metoos, solution, tags, user, watchlist"},
+        {"field as include", "include=comment_count", fiber.StatusBadRequest, nil, "unknown include comment_count"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            app := fiber.New()
+            app.Get("/posts", func(c *fiber.Ctx) error {
+                fieldset, err := parsePostFieldset(c)
+                if err != nil {
+                    return filterErrorResponse(c, err, "Failed to parse fields")
+                }
+                wanted := []string{}
+                for _, field := range all {
+                    if fieldset.wants(field) {
+                        wanted = append(wanted, field)
+                    }
+                }
+                return c.JSON(fiber.Map{"fields": wanted})
+            })
+
+            resp, err := app.Test(httptest.NewRequest("GET", "/posts?"+tt.query, nil))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if resp.StatusCode != tt.wantStatus {
+                t.Fatalf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+            }
+            var body struct {
+                Fields []string `json:"fields"`
+                Error  string   `json:"error"`
+            }
+            if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+                t.Fatal(err)
+            }
+            if tt.wantError != "" {
+                if !strings.Contains(body.Error, tt.wantError) {
+                    t.Errorf("error = %q, want it to contain %q", body.Error, tt.wantError)
+                }
+                return
+            }
+            want := append([]string(nil), tt.wantFields...)
+            sort.Strings(want)
+            if !reflect.DeepEqual(body.Fields, want) {
+                t.Errorf("fields = %v, want %v", body.Fields, want)
+            }
+        })
+    }
+}
+
+func TestPostFieldsetFilter(t *testing.T) {
+    fieldset := postFieldset{
+        fields:   map[string]bool{"id": true, "title": true, "user": true, "tags": true},
+        includes: map[string]bool{"tags": true},
+    }
+    got := fieldset.filter(fiber.Map{"id": 1, "title": "t", "content": "c", "user": "u", "tags": []string{"go"}})
+    want := fiber.Map{"id": 1, "title": "t", "tags": []string{"go"}}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("filter() = %v, want %v", got, want)
+    }
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"techquire-backend/internal/markdown"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxPreviewLength limits the size of Markdown accepted by the preview endpoint
+const maxPreviewLength = 50000
+
+// wantsHTML reports whether the client asked for rendered content with format=html
+func wantsHTML(c *fiber.Ctx) bool {
+    return c.Query("format") == "html"
+}
+
+// addRenderedContent adds the sanitized HTML and a plain-text excerpt of the content to a response map
+func addRenderedContent(data fiber.Map, content string) {
+    data["content_html"] = markdown.Render(content)
+    data["excerpt"] = markdown.Excerpt(content, markdown.DefaultExcerptLength)
+}
+
+// PreviewMarkdown renders Markdown the same way stored posts and comments are rendered
+func PreviewMarkdown(c *fiber.Ctx) error {
+    var previewRequest struct {
+        Content string `json:"content"`
+    }
+    if err := c.BodyParser(&previewRequest); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    if len(previewRequest.Content) > maxPreviewLength {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Content is too long to preview",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "content_html": markdown.Render(previewRequest.Content),
+        "excerpt":      markdown.Excerpt(previewRequest.Content, markdown.DefaultExcerptLength),
+    })
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/preferences"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var (
+    // mentionPattern matches @username when it is not part of a word or an email address
+    mentionPattern = regexp.MustCompile(`(?:^|[^\w@./-])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+    // Code is stripped before parsing so that e.g. Java annotations are not treated as mentions
+    fencedCodePattern = regexp.MustCompile("(?s)```.*?```")
+    inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+)
+
+// parseMentions extracts the unique usernames mentioned in the given text
+func parseMentions(text string) []string {
+    text = fencedCodePattern.ReplaceAllString(text, " ")
+    text = inlineCodePattern.ReplaceAllString(text, " ")
+
+    seen := make(map[string]bool)
+    usernames := []string{}
+    for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+        // Trailing punctuation belongs to the sentence, not the username
+        username := strings.TrimRight(match[1], ".-")
+        if len(username) < 3 || len(username) > 24 {
+            continue
+        }
+        if seen[username] {
+            continue
+        }
+        seen[username] = true
+        usernames = append(usernames, username)
+    }
+    return usernames
+}
+
+// resolveMentions looks up the users mentioned in the given text. Usernames are unique
+// case-sensitively, so they are matched exactly: "@Bob" doesn't mention "bob".
+func resolveMentions(db *gorm.DB, text string) ([]models.User, error) {
+    usernames := parseMentions(text)
+    if len(usernames) == 0 {
+        return []models.User{}, nil
+    }
+
+    var users []models.User
+    if err := db.Where("username IN ?", usernames).Find(&users).Error; err != nil {
+        return nil, err
+    }
+    return users, nil
+}
+
+// unknownMentions returns the usernames mentioned in the given text that didn't resolve to
+// a user, so the client can point them out
+func unknownMentions(text string, users []models.User) []string {
+    resolved := make(map[string]bool, len(users))
+    for _, user := range users {
+        resolved[user.Username] = true
+    }
+
+    unknown := []string{}
+    for _, username := range parseMentions(text) {
+        if !resolved[username] {
+            unknown = append(unknown, username)
+        }
+    }
+    return unknown
+}
+
+// syncMentions stores mention records for the users mentioned in a post (commentID nil) or a comment.
+// Mentions that were removed from the content are deleted, new ones are created unread for the
+// users whose preferences allow them in the app, and existing ones keep their read state. The
+// resolved users are returned for the response.
+func syncMentions(db *gorm.DB, authorID uint, postID uint, commentID *uint, text string) ([]models.User, error) {
+    users, err := resolveMentions(db, text)
+    if err != nil {
+        return nil, err
+    }
+
+    // Fetch the mentions currently recorded for this content
+    var existing []models.Mention
+    query := db.Where("post_id = ?", postID)
+    if commentID != nil {
+        query = query.Where("comment_id = ?", *commentID)
+    } else {
+        query = query.Where("comment_id IS NULL")
+    }
+    if err := query.Find(&existing).Error; err != nil {
+        return nil, err
+    }
+
+    mentioned := make(map[uint]bool)
+    for _, user := range users {
+        // Authors mentioning themselves are not recorded
+        if user.ID != authorID {
+            mentioned[user.ID] = true
+        }
+    }
+
+    // Delete mentions that are no longer in the content
+    recorded := make(map[uint]bool)
+    staleIDs := []uint{}
+    for _, mention := range existing {
+        if mentioned[mention.UserID] {
+            recorded[mention.UserID] = true
+        } else {
+            staleIDs = append(staleIDs, mention.ID)
+        }
+    }
+    if len(staleIDs) > 0 {
+        if err := db.Where("id IN ?", staleIDs).Delete(&models.Mention{}).Error; err != nil {
+            return nil, err
+        }
+    }
+
+    // Create the new ones
+    for _, user := range users {
+        if !mentioned[user.ID] || recorded[user.ID] {
+            continue
+        }
+        event := preferences.Event{Type: models.NotificationMention, PostID: postID}
+        notify, err := preferences.ShouldNotify(db, user.ID, event, preferences.ChannelInApp)
+        if err != nil {
+            return nil, err
+        }
+        if !notify {
+            continue
+        }
+        mention := models.Mention{
+            UserID:    user.ID,
+            AuthorID:  authorID,
+            PostID:    postID,
+            CommentID: commentID,
+        }
+        if err := db.Create(&mention).Error; err != nil {
+            return nil, err
+        }
+    }
+
+    return users, nil
+}
+
+// formatMentions formats resolved mentions for API responses
+func formatMentions(users []models.User) []fiber.Map {
+    formatted := make([]fiber.Map, 0, len(users))
+    for _, user := range users {
+        formatted = append(formatted, fiber.Map{
+            "id":                  user.ID,
+            "username":            user.Username,
+            "profile_picture_url": user.ProfilePictureURL,
+        })
+    }
+    return formatted
+}
+
+// GetMentions lists the mentions of the authenticated user, newest first
+func GetMentions(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Parse pagination and filter parameters
+    page := c.QueryInt("page", 1)
+    limit := c.QueryInt("limit", 20)
+    unreadOnly := c.QueryBool("unread", false)
+
+    // Validate pagination
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 20
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    offset := (page - 1) * limit
+
+    query := database.DB.Model(&models.Mention{}).Where("user_id = ?", userID)
+    if unreadOnly {
+        query = query.Where("is_read = ?", false)
+    }
+
+    // Count total mentions for pagination
+    var totalMentions int64
+    if err := query.Session(&gorm.Session{}).Count(&totalMentions).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count mentions",
+        })
+    }
+
+    // Count unread mentions for the badge in the UI
+    var unreadCount int64
+    database.DB.Model(&models.Mention{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&unreadCount)
+
+    var mentions []models.Mention
+    if err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&mentions).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve mentions",
+        })
+    }
+
+    returnedMentions := make([]fiber.Map, 0, len(mentions))
+    if len(mentions) > 0 {
+        // Collect author and post IDs for batch lookups
+        authorIDs := make([]uint, 0, len(mentions))
+        postIDs := make([]uint, 0, len(mentions))
+        for _, mention := range mentions {
+            authorIDs = append(authorIDs, mention.AuthorID)
+            postIDs = append(postIDs, mention.PostID)
+        }
+
+        authorMap := make(map[uint]models.User)
+        var authors []models.User
+        database.DB.Where("id IN ?", authorIDs).Find(&authors)
+        for _, author := range authors {
+            authorMap[author.ID] = author
+        }
+
+        postTitleMap := make(map[uint]string)
+        var posts []models.Post
+        database.DB.Select("id", "title").Where("id IN ?", postIDs).Find(&posts)
+        for _, post := range posts {
+            postTitleMap[post.ID] = post.Title
+        }
+
+        for _, mention := range mentions {
+            author := authorMap[mention.AuthorID]
+            returnedMentions = append(returnedMentions, fiber.Map{
+                "id":         mention.ID,
+                "post_id":    mention.PostID,
+                "post_title": postTitleMap[mention.PostID],
+                "comment_id": mention.CommentID,
+                "is_read":    mention.IsRead,
+                "created_at": mention.CreatedAt,
+                "author": fiber.Map{
+                    "id":                  author.ID,
+                    "username":            author.Username,
+                    "profile_picture_url": author.ProfilePictureURL,
+                },
+            })
+        }
+    }
+
+    // Calculate pagination metadata
+    totalPages := (int(totalMentions) + limit - 1) / limit
+    hasMore := page < totalPages
+
+    return c.JSON(fiber.Map{
+        "mentions":     returnedMentions,
+        "unread_count": unreadCount,
+        "pagination": fiber.Map{
+            "page":           page,
+            "limit":          limit,
+            "total_mentions": totalMentions,
+            "total_pages":    totalPages,
+            "has_more":       hasMore,
+        },
+    })
+}
+
+// MarkMentionRead marks a single mention of the authenticated user as read
+func MarkMentionRead(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get mention ID from URL parameter
+    mentionID, err := c.ParamsInt("mention_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid mention ID",
+        })
+    }
+
+    var mention models.Mention
+    if err := database.DB.Where("id = ? AND user_id = ?", mentionID, userID).First(&mention).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Mention not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to fetch mention",
+        })
+    }
+
+    mention.IsRead = true
+    if err := database.DB.Save(&mention).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update mention",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "id":      mention.ID,
+        "is_read": true,
+    })
+}
+
+// MarkAllMentionsRead marks every mention of the authenticated user as read
+func MarkAllMentionsRead(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    if err := database.DB.Model(&models.Mention{}).
+        Where("user_id = ? AND is_read = ?", userID, false).
+        Update("is_read", true).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update mentions",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "All mentions marked as read",
+    })
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+
+	"techquire-backend/internal/models"
+)
+
+func TestParseMentions(t *testing.T) {
+    tests := []struct {
+        name string
+        text string
+        want []string
+    }{
+        {"none", "no mentions here", []string{}},
+        {"single", "thanks @alice", []string{"alice"}},
+        {"start of text", "@alice look at this", []string{"alice"}},
+        {"several in order", "@bob and @alice, also @carol.", []string{"bob", "alice", "carol"}},
+        {"duplicates", "@alice @alice", []string{"alice"}},
+        {"case is kept", "@Bob and @bob", []string{"Bob", "bob"}},
+        {"trailing punctuation", "ask @alice.", []string{"alice"}},
+        {"dots inside", "@john.doe knows", []string{"john.doe"}},
+        {"email address", "mail alice@example.com", []string{}},
+        {"part of a word", "foo@alice", []string{}},
+        {"inline code", "use `@Override` here", []string{}},
+        {"fenced code", "```\n@Deprecated\nclass A {}\n```\n@alice", []string{"alice"}},
+        {"too short", "@ab", []string{}},
+        {"too long", "@abcdefghijklmnopqrstuvwxy", []string{}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := parseMentions(tt.text); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("parseMentions(%q) = %q, want %q", tt.text, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestUnknownMentions(t *testing.T) {
+    users := []models.User{{Username: "alice"}, {Username: "Bob"}}
+
+    tests := []struct {
+        name string
+        text string
+        want []string
+    }{
+        {"all resolved", "@alice @Bob", []string{}},
+        {"unknown user", "@alice @carol", []string{"carol"}},
+        {"case differs", "@bob", []string{"bob"}},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := unknownMentions(tt.text, users); !reflect.DeepEqual(got, tt.want) {
+                t.Errorf("unknownMentions(%q) = %q, want %q", tt.text, got, tt.want)
+            }
+        })
+    }
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+)
+
+// GetNotifications lists the notifications of the authenticated user, newest first
+func GetNotifications(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Parse pagination and filter parameters
+    page := c.QueryInt("page", 1)
+    limit := c.QueryInt("limit", 20)
+    unreadOnly := c.QueryBool("unread", false)
+
+    // Validate pagination
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 20
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    offset := (page - 1) * limit
+
+    query := database.DB.Model(&models.Notification{}).Where("user_id = ?", userID)
+    if unreadOnly {
+        query = query.Where("is_read = ?", false)
+    }
+    if notificationType := c.Query("type"); notificationType != "" {
+        query = query.Where("type = ?", notificationType)
+    }
+
+    // Count total notifications for pagination
+    var totalNotifications int64
+    if err := query.Session(&gorm.Session{}).Count(&totalNotifications).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count notifications",
+        })
+    }
+
+    // Count unread notifications for the badge in the UI
+    var unreadCount int64
+    database.DB.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&unreadCount)
+
+    var notifications []models.Notification
+    if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve notifications",
+        })
+    }
+
+    returnedNotifications := make([]fiber.Map, 0, len(notifications))
+    if len(notifications) > 0 {
+        // Collect actor and post IDs for batch lookups
+        actorIDs := make([]uint, 0, len(notifications))
+        postIDs := make([]uint, 0, len(notifications))
+        for _, notification := range notifications {
+            if notification.ActorID != nil {
+                actorIDs = append(actorIDs, *notification.ActorID)
+            }
+            if notification.PostID != nil {
+                postIDs = append(postIDs, *notification.PostID)
+            }
+        }
+
+        actorMap := make(map[uint]models.User)
+        if len(actorIDs) > 0 {
+            var actors []models.User
+            database.DB.Where("id IN ?", actorIDs).Find(&actors)
+            for _, actor := range actors {
+                actorMap[actor.ID] = actor
+            }
+        }
+
+        postTitleMap := make(map[uint]string)
+        if len(postIDs) > 0 {
+            var posts []models.Post
+            database.DB.Select("id", "title").Where("id IN ?", postIDs).Find(&posts)
+            for _, post := range posts {
+                postTitleMap[post.ID] = post.Title
+            }
+        }
+
+        for _, notification := range notifications {
+            returnedNotifications = append(returnedNotifications, formatNotification(notification, actorMap, postTitleMap))
+        }
+    }
+
+    // Calculate pagination metadata
+    totalPages := (int(totalNotifications) + limit - 1) / limit
+    hasMore := page < totalPages
+
+    return c.JSON(fiber.Map{
+        "notifications": returnedNotifications,
+        "unread_count":  unreadCount,
+        "pagination": fiber.Map{
+            "page":                page,
+            "limit":               limit,
+            "total_notifications": totalNotifications,
+            "total_pages":         totalPages,
+            "has_more":            hasMore,
+        },
+    })
+}
+
+// formatNotification formats a notification for API responses
+func formatNotification(notification models.Notification, actorMap map[uint]models.User, postTitleMap map[uint]string) fiber.Map {
+    data := fiber.Map{
+        "id":         notification.ID,
+        "type":       notification.Type,
+        "post_id":    notification.PostID,
+        "post_title": nil,
+        "comment_id": notification.CommentID,
+        "detail":     notification.Detail,
+        "is_read":    notification.IsRead,
+        "created_at": notification.CreatedAt,
+        "actor":      nil,
+    }
+    if notification.PostID != nil {
+        data["post_title"] = postTitleMap[*notification.PostID]
+    }
+    if notification.ActorID != nil {
+        if actor, found := actorMap[*notification.ActorID]; found {
+            data["actor"] = fiber.Map{
+                "id":                  actor.ID,
+                "username":            actor.Username,
+                "profile_picture_url": actor.ProfilePictureURL,
+            }
+        }
+    }
+    return data
+}
+
+// GetUnreadNotificationCount returns the number of unread notifications of the authenticated user
+func GetUnreadNotificationCount(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var unreadCount int64
+    if err := database.DB.Model(&models.Notification{}).
+        Where("user_id = ? AND is_read = ?", userID, false).
+        Count(&unreadCount).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count notifications",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "unread_count": unreadCount,
+    })
+}
+
+// MarkNotificationRead marks a single notification of the authenticated user as read
+func MarkNotificationRead(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get notification ID from URL parameter
+    notificationID, err := c.ParamsInt("notification_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid notification ID",
+        })
+    }
+
+    result := database.DB.Model(&models.Notification{}).
+        Where("id = ? AND user_id = ?", notificationID, userID).
+        Update("is_read", true)
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update notification",
+        })
+    }
+    if result.RowsAffected == 0 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Notification not found",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "id":      notificationID,
+        "is_read": true,
+    })
+}
+
+// MarkAllNotificationsRead marks every notification of the authenticated user as read
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    if err := database.DB.Model(&models.Notification{}).
+        Where("user_id = ? AND is_read = ?", userID, false).
+        Update("is_read", true).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update notifications",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "All notifications marked as read",
+    })
+}
+
+// DeleteNotification deletes a notification of the authenticated user
+func DeleteNotification(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get notification ID from URL parameter
+    notificationID, err := c.ParamsInt("notification_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid notification ID",
+        })
+    }
+
+    result := database.DB.Where("id = ? AND user_id = ?", notificationID, userID).Delete(&models.Notification{})
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete notification",
+        })
+    }
+    if result.RowsAffected == 0 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Notification not found",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "Notification deleted successfully",
+    })
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/preferences"
+)
+
+// GetNotificationPreferences returns the notification settings of the authenticated user:
+// the channels of every event type, quiet hours and muted posts and tags
+func GetNotificationPreferences(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    userPreferences, err := preferences.Load(database.DB, userID)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve notification preferences",
+        })
+    }
+
+    channels := fiber.Map{}
+    for _, eventType := range preferences.EventTypes {
+        eventChannels := userPreferences.Channels[eventType]
+        if eventChannels == nil {
+            eventChannels = []string{}
+        }
+        channels[eventType] = eventChannels
+    }
+
+    var quietHours interface{}
+    if userPreferences.QuietHours != nil {
+        quietHours = fiber.Map{
+            "start":     userPreferences.QuietHours.Start,
+            "end":       userPreferences.QuietHours.End,
+            "time_zone": userPreferences.QuietHours.TimeZone,
+        }
+    }
+
+    // Muted posts with their titles, muted tags by name
+    mutedPosts := []fiber.Map{}
+    if len(userPreferences.MutedPosts) > 0 {
+        postIDs := make([]uint, 0, len(userPreferences.MutedPosts))
+        for postID := range userPreferences.MutedPosts {
+            postIDs = append(postIDs, postID)
+        }
+        var posts []models.Post
+        database.DB.Select("id", "title").Where("id IN ?", postIDs).Order("id DESC").Find(&posts)
+        for _, post := range posts {
+            mutedPosts = append(mutedPosts, fiber.Map{
+                "id":    post.ID,
+                "title": post.Title,
+            })
+        }
+    }
+    mutedTags := sortedKeys(userPreferences.MutedTags)
+
+    return c.JSON(fiber.Map{
+        "channels":           channels,
+        "quiet_hours":        quietHours,
+        "muted_posts":        mutedPosts,
+        "muted_tags":         mutedTags,
+        "event_types":        preferences.EventTypes,
+        "available_channels": preferences.Channels,
+    })
+}
+
+// UpdateNotificationPreferences sets the channels of event types for the authenticated
+// user, e.g. {"channels": {"reaction": [], "comment": ["in_app", "email"]}}. An empty
+// list turns an event type off, event types left out keep their channels.
+func UpdateNotificationPreferences(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var request struct {
+        Channels map[string][]string `json:"channels"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    updated := make([]models.NotificationPreference, 0, len(request.Channels))
+    for eventType, channels := range request.Channels {
+        if !preferences.ValidEventType(eventType) {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "error": "Unknown event type: " + eventType,
+            })
+        }
+        // "none" is accepted as an explicit way of turning an event type off
+        enabled := pq.StringArray{}
+        seen := make(map[string]bool)
+        for _, channel := range channels {
+            if channel == "none" || seen[channel] {
+                continue
+            }
+            if !preferences.ValidChannel(channel) {
+                return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                    "error": "Unknown channel: " + channel,
+                })
+            }
+            seen[channel] = true
+            enabled = append(enabled, channel)
+        }
+        updated = append(updated, models.NotificationPreference{
+            UserID:    userID,
+            EventType: eventType,
+            Channels:  enabled,
+        })
+    }
+
+    if len(updated) > 0 {
+        if err := database.DB.Clauses(clause.OnConflict{
+            Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}},
+            DoUpdates: clause.AssignmentColumns([]string{"channels", "updated_at"}),
+        }).Create(&updated).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "error": "Failed to update notification preferences",
+            })
+        }
+    }
+
+    return GetNotificationPreferences(c)
+}
+
+// SetQuietHours sets the daily period in which the authenticated user receives no emails,
+// e.g. {"start": "22:00", "end": "07:00", "time_zone": "Europe/Berlin"}
+func SetQuietHours(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var request struct {
+        Start    string `json:"start"`
+        End      string `json:"end"`
+        TimeZone string `json:"time_zone"`
+    }
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    start, err := preferences.ParseClock(request.Start)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Start must be a time of day as HH:MM",
+        })
+    }
+    end, err := preferences.ParseClock(request.End)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "End must be a time of day as HH:MM",
+        })
+    }
+    if start == end {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Start and end must differ",
+        })
+    }
+    if request.TimeZone == "" {
+        request.TimeZone = "UTC"
+    }
+    if _, err := time.LoadLocation(request.TimeZone); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Unknown time zone: " + request.TimeZone,
+        })
+    }
+
+    quietHours := models.QuietHours{
+        UserID:   userID,
+        Start:    request.Start,
+        End:      request.End,
+        TimeZone: request.TimeZone,
+    }
+    if err := database.DB.Clauses(clause.OnConflict{
+        Columns:   []clause.Column{{Name: "user_id"}},
+        DoUpdates: clause.AssignmentColumns([]string{"start_time", "end_time", "time_zone", "updated_at"}),
+    }).Create(&quietHours).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to set quiet hours",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "start":     quietHours.Start,
+        "end":       quietHours.End,
+        "time_zone": quietHours.TimeZone,
+    })
+}
+
+// DeleteQuietHours removes the quiet hours of the authenticated user
+func DeleteQuietHours(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    if err := database.DB.Where("user_id = ?", userID).Delete(&models.QuietHours{}).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to remove quiet hours",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "Quiet hours removed",
+    })
+}
+
+// ToggleMutePost handles muting or unmuting the notifications about a post
+func ToggleMutePost(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get post ID from URL parameter
+    postID, err := c.ParamsInt("post_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid post ID",
+        })
+    }
+
+    // Check if post exists
+    var post models.Post
+    if err := database.DB.Select("id").First(&post, postID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Post not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to fetch post",
+        })
+    }
+
+    // Unmute if muted, mute otherwise
+    result := database.DB.Where("user_id = ? AND post_id = ?", userID, post.ID).Delete(&models.NotificationMute{})
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to unmute post",
+        })
+    }
+    if result.RowsAffected > 0 {
+        return c.JSON(fiber.Map{
+            "id":       post.ID,
+            "is_muted": false,
+        })
+    }
+
+    mute := models.NotificationMute{UserID: userID, PostID: &post.ID}
+    if err := database.DB.Create(&mute).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to mute post",
+        })
+    }
+    return c.JSON(fiber.Map{
+        "id":       post.ID,
+        "is_muted": true,
+    })
+}
+
+// ToggleMuteTag handles muting or unmuting the notifications about posts with a tag
+func ToggleMuteTag(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if tag exists
+    tag, err := findTag(tagParam(c))
+    if err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Tag not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag",
+        })
+    }
+
+    // Unmute if muted, mute otherwise
+    result := database.DB.Where("user_id = ? AND tag_id = ?", userID, tag.ID).Delete(&models.NotificationMute{})
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to unmute tag",
+        })
+    }
+    if result.RowsAffected > 0 {
+        return c.JSON(fiber.Map{
+            "tag":      tag.Name,
+            "is_muted": false,
+        })
+    }
+
+    mute := models.NotificationMute{UserID: userID, TagID: &tag.ID}
+    if err := database.DB.Create(&mute).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to mute tag",
+        })
+    }
+    return c.JSON(fiber.Map{
+        "tag":      tag.Name,
+        "is_muted": true,
+    })
+}
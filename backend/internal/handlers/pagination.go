@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/feed"
+	"techquire-backend/internal/models"
+)
+
+// postPage holds the pagination parameters of the post listings. A cursor from a
+// previous response's next_cursor continues the listing after its last post, otherwise
+// page and limit select the posts by offset. The total count costs an extra query, it is
+// included by default in page mode and with include_total=true in cursor mode.
+type postPage struct {
+    Page         int
+    Limit        int
+    Cursor       string
+    IncludeTotal bool
+}
+
+// parsePostPage reads and validates the pagination parameters
+func parsePostPage(c *fiber.Ctx) postPage {
+    p := postPage{
+        Page:   c.QueryInt("page", 1),
+        Limit:  c.QueryInt("limit", 10),
+        Cursor: c.Query("cursor"),
+    }
+    p.IncludeTotal = c.QueryBool("include_total", p.Cursor == "")
+
+    if p.Limit > 50 {
+        p.Limit = 50
+    }
+    if p.Limit < 1 {
+        p.Limit = 10
+    }
+    if p.Page < 1 {
+        p.Page = 1
+    }
+    return p
+}
+
+// count counts the posts matching a query when the total is requested
+func (p postPage) count(query *gorm.DB) (int64, error) {
+    if !p.IncludeTotal {
+        return 0, nil
+    }
+    var total int64
+    err := database.DB.Table("(?) as filtered_posts", query.Session(&gorm.Session{}).Limit(-1).Offset(-1)).Count(&total).Error
+    return total, err
+}
+
+// find orders a query, fetches one page of posts and returns the cursor of the next page,
+// if there is one
+func (p postPage) find(query *gorm.DB, sort feed.Sort) ([]models.Post, string, error) {
+    if p.Cursor != "" {
+        var err error
+        if query, err = sort.After(query, p.Cursor); err != nil {
+            return nil, "", err
+        }
+    } else {
+        query = query.Offset((p.Page - 1) * p.Limit)
+    }
+
+    // One extra post tells whether there is a next page
+    var posts []models.Post
+    if err := sort.Apply(query).Limit(p.Limit + 1).Find(&posts).Error; err != nil {
+        return nil, "", err
+    }
+    if len(posts) <= p.Limit {
+        return posts, "", nil
+    }
+
+    posts = posts[:p.Limit]
+    nextCursor, err := sort.Cursor(database.DB, posts[len(posts)-1].ID)
+    if err != nil {
+        return nil, "", err
+    }
+    return posts, nextCursor, nil
+}
+
+// response builds the pagination metadata of a listing
+func (p postPage) response(total int64, nextCursor string) fiber.Map {
+    pagination := fiber.Map{
+        "limit":       p.Limit,
+        "has_more":    nextCursor != "",
+        "next_cursor": nextCursor,
+    }
+    if p.Cursor == "" {
+        pagination["page"] = p.Page
+    }
+    if p.IncludeTotal {
+        pagination["total_posts"] = total
+        pagination["total_pages"] = (int(total) + p.Limit - 1) / p.Limit
+    }
+    return pagination
+}
+
+// filterErrorResponse responds 400 to invalid filter, sort or cursor parameters, pointing
+// at the bad token of a search query, and 500 with the given message to other errors
+func filterErrorResponse(c *fiber.Ctx, err error, message string) error {
+    if filterErr, ok := err.(*feed.FilterError); ok {
+        response := fiber.Map{
+            "error": filterErr.Error(),
+        }
+        if filterErr.Token != "" {
+            response["token"] = filterErr.Token
+            response["position"] = filterErr.Position
+        }
+        return c.Status(fiber.StatusBadRequest).JSON(response)
+    }
+    return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+        "error": message,
+    })
+}
@@ -5,9 +5,14 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"techquire-backend/internal/cache"
 	"techquire-backend/internal/database"
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/feed"
 	"techquire-backend/internal/models"
+	"techquire-backend/internal/reputation"
+	"techquire-backend/internal/search"
+	"techquire-backend/internal/tagging"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -47,9 +52,14 @@ func CreatePost(c *fiber.Ctx) error {
         content = contentFields[0]
     }
     
-    // Extract tags - handle as array
+    // Extract tags - handle as array, with synonyms mapped to their canonical tag
     if tagsFields := form.Value["tags"]; len(tagsFields) > 0 {
-        tags = tagsFields
+        tags, err = tagging.Canonicalize(database.DB, tagsFields)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "error": "Failed to resolve tags",
+            })
+        }
     }
 
     // Validate the post data
@@ -119,25 +129,10 @@ func CreatePost(c *fiber.Ctx) error {
         UserID:   userID,
     }
 
-    // Create the post in the database
-    if err := database.DB.Create(&post).Error; err != nil {
+    user, mentions, message := createPost(&post)
+    if message != "" {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to create post",
-        })
-    }
-
-    var user models.User
-    if err := database.DB.First(&user, post.UserID).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to retrieve user associated with post",
-        })
-    }
-
-    // Increment user's post count
-    user.NumberOfPosts++
-    if err := database.DB.Save(&user).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to update user post count",
+            "error": message,
         })
     }
 
@@ -153,6 +148,8 @@ func CreatePost(c *fiber.Ctx) error {
         "is_metoo":       false,
         "metoo_count":    0,
         "is_watchlisted": false,
+        "mentions":       formatMentions(mentions),
+        "unknown_mentions": unknownMentions(post.Title+"\n"+post.Content, mentions),
         "user": fiber.Map{
             "id":       user.ID,
             "username": user.Username,
@@ -163,6 +160,42 @@ func CreatePost(c *fiber.Ctx) error {
     return c.JSON(postData)
 }
 
+// createPost stores a new post and updates what depends on it: the usage counts of its
+// tags, the author's post count and mentions. It returns the author and the mentioned
+// users, or a message for the client when a step fails.
+func createPost(post *models.Post) (models.User, []models.User, string) {
+    var user models.User
+
+    // Create the post in the database
+    if err := database.DB.Create(post).Error; err != nil {
+        return user, nil, "Failed to create post"
+    }
+
+    // Update usage counts of the post's tags
+    if err := tagging.UpdateUsage(database.DB, nil, post.Tags); err != nil {
+        return user, nil, "Failed to update tag usage"
+    }
+
+    if err := database.DB.First(&user, post.UserID).Error; err != nil {
+        return user, nil, "Failed to retrieve user associated with post"
+    }
+
+    // Increment user's post count
+    user.NumberOfPosts++
+    if err := database.DB.Save(&user).Error; err != nil {
+        return user, nil, "Failed to update user post count"
+    }
+
+    // Record mentions of other users
+    mentions, err := syncMentions(database.DB, post.UserID, post.ID, nil, post.Title+"\n"+post.Content)
+    if err != nil {
+        return user, nil, "Failed to save mentions"
+    }
+
+    events.Publish(events.Event{Type: events.PostCreated, PostID: post.ID, UserID: post.UserID})
+    return user, mentions, ""
+}
+
 // DeletePost handles the deletion of a post
 func DeletePost(c *fiber.Ctx) error {
     // Get post ID from URL parameter
@@ -250,7 +283,7 @@ func DeletePost(c *fiber.Ctx) error {
         })
     }
     
-    // 4. Delete reactions for these comments
+    // 4. Delete reactions for these comments and take back the reputation they earned
     if len(commentIDs) > 0 {
         if err := tx.Where("comment_id IN ?", commentIDs).Delete(&models.Reaction{}).Error; err != nil {
             tx.Rollback()
@@ -258,9 +291,41 @@ func DeletePost(c *fiber.Ctx) error {
                 "error": "Failed to delete comment reactions: " + err.Error(),
             })
         }
+        if err := reputation.ReverseComments(tx, commentIDs); err != nil {
+            tx.Rollback()
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "error": "Failed to update reputation: " + err.Error(),
+            })
+        }
     }
     
-    // 5. Delete all comments
+    // 5. Delete mentions in the post and its comments, and alerts, notifications and mutes of the post
+    if err := tx.Where("post_id = ?", postID).Delete(&models.Mention{}).Error; err != nil {
+        tx.Rollback()
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete mentions: " + err.Error(),
+        })
+    }
+    if err := tx.Where("post_id = ?", postID).Delete(&models.SearchAlert{}).Error; err != nil {
+        tx.Rollback()
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete search alerts: " + err.Error(),
+        })
+    }
+    if err := tx.Where("post_id = ?", postID).Delete(&models.Notification{}).Error; err != nil {
+        tx.Rollback()
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete notifications: " + err.Error(),
+        })
+    }
+    if err := tx.Where("post_id = ?", postID).Delete(&models.NotificationMute{}).Error; err != nil {
+        tx.Rollback()
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete notification mutes: " + err.Error(),
+        })
+    }
+
+    // 6. Delete all comments
     if err := tx.Where("post_id = ?", postID).Delete(&models.Comment{}).Error; err != nil {
         tx.Rollback()
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -268,7 +333,7 @@ func DeletePost(c *fiber.Ctx) error {
         })
     }
 
-    // 6. Delete all pictures from the filesystem
+    // 7. Delete all pictures from the filesystem
     for _, picture := range post.Pictures {
         filePath := fmt.Sprintf("./static/uploads/attached_pictures/%s", filepath.Base(picture))
         if err := os.Remove(filePath); err != nil {
@@ -276,7 +341,15 @@ func DeletePost(c *fiber.Ctx) error {
         }
     }
     
-    // 7. Finally, delete the post
+    // 8. Update usage counts of the post's tags
+    if err := tagging.UpdateUsage(tx, post.Tags, nil); err != nil {
+        tx.Rollback()
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update tag usage: " + err.Error(),
+        })
+    }
+
+    // 9. Finally, delete the post
     if err := tx.Delete(&post).Error; err != nil {
         tx.Rollback()
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -290,6 +363,7 @@ func DeletePost(c *fiber.Ctx) error {
             "error": "Failed to commit transaction: " + err.Error(),
         })
     }
+    events.Publish(events.Event{Type: events.PostDeleted, PostID: post.ID, UserID: userID})
 
     // Decrement user's post count
     user.NumberOfPosts--
@@ -375,9 +449,15 @@ func EditPost(c *fiber.Ctx) error {
         post.Content = contentFields[0]
     }
     
-    // Extract tags - handle as array
+    // Extract tags - handle as array, with synonyms mapped to their canonical tag
+    oldTags := append([]string{}, post.Tags...)
     if tagsFields := form.Value["tags"]; len(tagsFields) > 0 {
-        post.Tags = tagsFields
+        post.Tags, err = tagging.Canonicalize(database.DB, tagsFields)
+        if err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "error": "Failed to resolve tags",
+            })
+        }
     }
 
     // Process new uploaded picture files
@@ -439,6 +519,23 @@ func EditPost(c *fiber.Ctx) error {
         })
     }
 
+    // Update usage counts of added and removed tags
+    if err := tagging.UpdateUsage(database.DB, oldTags, post.Tags); err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update tag usage",
+        })
+    }
+
+    // Update mentions to match the edited content
+    mentions, err := syncMentions(database.DB, post.UserID, post.ID, nil, post.Title+"\n"+post.Content)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to save mentions",
+        })
+    }
+
+    events.Publish(events.Event{Type: events.PostUpdated, PostID: post.ID, UserID: userID})
+
     // Fetch user data for response
     var user models.User
     if err := database.DB.First(&user, post.UserID).Error; err != nil {
@@ -546,6 +643,8 @@ func EditPost(c *fiber.Ctx) error {
         "is_metoo":       isMetoo,
         "metoo_count":    metooCount,
         "is_watchlisted": isWatchlisted,
+        "mentions":       formatMentions(mentions),
+        "unknown_mentions": unknownMentions(post.Title+"\n"+post.Content, mentions),
         "user": fiber.Map{
             "id":                 user.ID,
             "username":           user.Username,
@@ -625,6 +724,8 @@ func DeletePostPicture(c *fiber.Ctx) error {
         })
     }
 
+    events.Publish(events.Event{Type: events.PostUpdated, PostID: post.ID, UserID: userID})
+
     // Delete the picture file from the filesystem
     filePath := fmt.Sprintf("./static/uploads/attached_pictures/%s", filepath.Base(pictureURL))
     if err := os.Remove(filePath); err != nil {
@@ -640,12 +741,38 @@ func DeletePostPicture(c *fiber.Ctx) error {
 // GetPost handles retrieving a single post by its ID
 func GetPost(c *fiber.Ctx) error {
     postID := c.Params("post_id")
+    renderHTML := wantsHTML(c)
+    fieldset, err := parsePostFieldset(c)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to parse fields")
+    }
+
+    // Answer conditional requests before loading the post
+    var viewerID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        viewerID = uint(userIDFloat)
+    }
+    var cacheKey string
+    if id, err := c.ParamsInt("post_id"); err == nil && id > 0 {
+        etag, unchanged := checkPostsCache(c, uint(id), viewerID)
+        if unchanged {
+            return c.SendStatus(fiber.StatusNotModified)
+        }
+        cacheKey = responseCacheKey(c, cache.PostGroup(uint(id)), viewerID, etag)
+        if sendCachedResponse(c, cacheKey) {
+            return nil
+        }
+    }
 
     var post models.Post
-    // Preload the Comments relationship with ordering
-    if err := database.DB.Preload("Comments", func(db *gorm.DB) *gorm.DB {
-        return db.Order("created_at DESC")
-    }).First(&post, postID).Error; err != nil {
+    query := database.DB
+    if fieldset.wants("comments") || fieldset.wants("comment_count") {
+        // Preload the Comments relationship with ordering
+        query = query.Preload("Comments", func(db *gorm.DB) *gorm.DB {
+            return db.Order("created_at DESC")
+        })
+    }
+    if err := query.First(&post, postID).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
             return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
                 "error": "Post not found",
@@ -658,10 +785,12 @@ func GetPost(c *fiber.Ctx) error {
 
     // Fetch user separately
     var user models.User
-    if err := database.DB.First(&user, post.UserID).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to retrieve user associated with post",
-        })
+    if fieldset.wants("user") {
+        if err := database.DB.First(&user, post.UserID).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "error": "Failed to retrieve user associated with post",
+            })
+        }
     }
 
     // Initialize flags for metoo and watchlist
@@ -670,7 +799,9 @@ func GetPost(c *fiber.Ctx) error {
     var metooCount int64 = 0
     
     // Count total metoos for this post
-    database.DB.Model(&models.MeToo{}).Where("post_id = ?", post.ID).Count(&metooCount)
+    if fieldset.wants("metoo_count") {
+        database.DB.Model(&models.MeToo{}).Where("post_id = ?", post.ID).Count(&metooCount)
+    }
     
     // Check if current user is authenticated
     var userID uint
@@ -678,59 +809,68 @@ func GetPost(c *fiber.Ctx) error {
         userID = uint(userIDFloat)
         
         // Check if current user has a metoo
-        var meTooEntry models.MeToo
-        isMetoo = database.DB.Where("post_id = ? AND user_id = ?", post.ID, userID).First(&meTooEntry).Error == nil
+        if fieldset.wants("is_metoo") {
+            var meTooEntry models.MeToo
+            isMetoo = database.DB.Where("post_id = ? AND user_id = ?", post.ID, userID).First(&meTooEntry).Error == nil
+        }
         
         // Check if current user has watchlisted this post
-        var watchlistEntry models.UserWatchlist
-        isWatchlisted = database.DB.Where("post_id = ? AND user_id = ?", post.ID, userID).
-            First(&watchlistEntry).Error == nil
+        if fieldset.wants("is_watchlisted") {
+            var watchlistEntry models.UserWatchlist
+            isWatchlisted = database.DB.Where("post_id = ? AND user_id = ?", post.ID, userID).
+                First(&watchlistEntry).Error == nil
+        }
     }
 
     // Format comments to needs
     formattedComments := make([]fiber.Map, 0, len(post.Comments))
-    for _, comment := range post.Comments {
-        var commentAuthor models.User
-        if err := database.DB.First(&commentAuthor, comment.UserID).Error; err != nil {
-            log.Printf("Error fetching author for comment %d: %v", comment.ID, err)
-            continue
-        }
-
-        // Check if the current user has liked or disliked this comment
-        var isLiked, isDisliked bool
+    if fieldset.wants("comments") {
+        for _, comment := range post.Comments {
+            var commentAuthor models.User
+            if err := database.DB.First(&commentAuthor, comment.UserID).Error; err != nil {
+                log.Printf("Error fetching author for comment %d: %v", comment.ID, err)
+                continue
+            }
 
-        // Only check reactions if user is authenticated
-        if userID > 0 {
-            var reaction models.Reaction
-            result := database.DB.Where("comment_id = ? AND user_id = ?", comment.ID, userID).First(&reaction)
-            if result.Error == nil {
-                // User has a reaction
-                isLiked = reaction.Type == "like"
-                isDisliked = reaction.Type == "dislike"
+            // Check if the current user has liked or disliked this comment
+            var isLiked, isDisliked bool
+
+            // Only check reactions if user is authenticated
+            if userID > 0 {
+                var reaction models.Reaction
+                result := database.DB.Where("comment_id = ? AND user_id = ?", comment.ID, userID).First(&reaction)
+                if result.Error == nil {
+                    // User has a reaction
+                    isLiked = reaction.Type == "like"
+                    isDisliked = reaction.Type == "dislike"
+                }
             }
-        }
         
-        // Format the comment with proper JSON structure
-        formattedComment := fiber.Map{
-            "id":         comment.ID,
-            "content":    comment.Content,
-            "post_id":    comment.PostID,
-            "pictures": comment.Pictures,
-            "is_solution": comment.IsSolution,
-            "created_at": comment.CreatedAt,
-            "updated_at": comment.UpdatedAt,
-            "like_count":      comment.Likes,
-            "dislike_count":   comment.Dislikes,
-            "is_liked":       isLiked,
-            "is_disliked":    isDisliked,
-            "user": fiber.Map{
-                "id":                 commentAuthor.ID,
-                "username":           commentAuthor.Username,
-                "profile_picture_url": commentAuthor.ProfilePictureURL,
-            },
-        }
+            // Format the comment with proper JSON structure
+            formattedComment := fiber.Map{
+                "id":         comment.ID,
+                "content":    comment.Content,
+                "post_id":    comment.PostID,
+                "pictures": comment.Pictures,
+                "is_solution": comment.IsSolution,
+                "created_at": comment.CreatedAt,
+                "updated_at": comment.UpdatedAt,
+                "like_count":      comment.Likes,
+                "dislike_count":   comment.Dislikes,
+                "is_liked":       isLiked,
+                "is_disliked":    isDisliked,
+                "user": fiber.Map{
+                    "id":                 commentAuthor.ID,
+                    "username":           commentAuthor.Username,
+                    "profile_picture_url": commentAuthor.ProfilePictureURL,
+                },
+            }
+            if renderHTML {
+                addRenderedContent(formattedComment, comment.Content)
+            }
         
-        formattedComments = append(formattedComments, formattedComment)
+            formattedComments = append(formattedComments, formattedComment)
+        }
     }
 
     // Initialize the response without solution
@@ -754,206 +894,111 @@ func GetPost(c *fiber.Ctx) error {
             "profile_picture_url": user.ProfilePictureURL,
         },
     }
+    if renderHTML {
+        addRenderedContent(response, post.Content)
+    }
 
-    // Fetch solution if it exists
-    var solution models.Comment
-    if err := database.DB.Where("post_id = ? AND is_solution = ?", post.ID, true).First(&solution).Error; err == nil {
-        // Only add solution data if a solution was found (no error)
-        var solver models.User
-        if err := database.DB.First(&solver, solution.UserID).Error; err == nil {
-            // Add solution data to post
-            response["solution"] = fiber.Map{
-                "id":         solution.ID,
-                "content":    solution.Content,
-                "pictures":   solution.Pictures,
-                "created_at": solution.CreatedAt,
-                "user": fiber.Map{
-                    "id":       solver.ID,
-                    "username": solver.Username,
-                },
+    // Fetch solution if it exists and was requested
+    if fieldset.wants("solution") {
+        var solution models.Comment
+        if err := database.DB.Where("post_id = ? AND is_solution = ?", post.ID, true).First(&solution).Error; err == nil {
+            // Only add solution data if a solution was found (no error)
+            var solver models.User
+            if err := database.DB.First(&solver, solution.UserID).Error; err == nil {
+                // Add solution data to post
+                solutionData := fiber.Map{
+                    "id":         solution.ID,
+                    "content":    solution.Content,
+                    "pictures":   solution.Pictures,
+                    "created_at": solution.CreatedAt,
+                    "user": fiber.Map{
+                        "id":       solver.ID,
+                        "username": solver.Username,
+                    },
+                }
+                if renderHTML {
+                    addRenderedContent(solutionData, solution.Content)
+                }
+                response["solution"] = solutionData
             }
         }
     }
 
-    return c.JSON(response)
+    return cacheAndSend(c, cacheKey, postCacheTTL, fieldset.filter(response))
 }
 
 // GetPosts handles retrieving a list of posts for the main feed
 // with support for filtering, sorting and pagination
 func GetPosts(c *fiber.Ctx) error {
     // Parse pagination and sorting parameters
-    page := c.QueryInt("page", 1)
-    limit := c.QueryInt("limit", 10)
+    page := parsePostPage(c)
     sortBy := c.Query("sort_by", "created_at")
     sortDir := c.Query("sort_dir", "desc")
+    renderHTML := wantsHTML(c)
+    fieldset, err := parsePostFieldset(c)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to parse fields")
+    }
     
     // Parse filter parameters
-    searchQuery := c.Query("search")
-    tagsQuery := c.Query("tags")
-    isMetoo := c.QueryBool("is_metoo", false)
-    isWatchlisted := c.QueryBool("is_watchlisted", false)
-    hasSolutionQuery := c.Query("has_solution")
-    userIDParam := c.Query("user_id")
-    
-    // Validate pagination parameters
-    if limit > 50 {
-        limit = 50
-    }
-    if limit < 1 {
-        limit = 10
-    }
-    if page < 1 {
-        page = 1
+    filters, err := feed.ParseFilters(database.DB, c.Queries())
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to parse filters")
     }
     
-    // Calculate offset for pagination
-    offset := (page - 1) * limit
-    
     // Get current user ID from JWT token
     var userID uint
     if userIDFloat, ok := c.Locals("user_id").(float64); ok {
         userID = uint(userIDFloat)
     }
     
-    // Initialize base query with distinct to avoid duplicates
-    query := database.DB.Model(&models.Post{}).
-        Select("DISTINCT posts.*").
-        Preload("Comments")
-    
-    // Filter by user_id
-    if userIDParam != "" {
-        query = query.Where("posts.user_id = ?", userIDParam)
+    if err := feed.CheckSort(sortBy, sortDir); err != nil {
+        return filterErrorResponse(c, err, "Failed to sort posts")
     }
     
-    // Search in title and content
-    if searchQuery != "" {
-        query = query.Where("posts.title ILIKE ? OR posts.content ILIKE ?", 
-            "%"+searchQuery+"%", "%"+searchQuery+"%")
+    // Answer conditional requests before building the response
+    etag, unchanged := checkPostsCache(c, 0, userID)
+    if unchanged {
+        return c.SendStatus(fiber.StatusNotModified)
+    }
+    cacheKey := responseCacheKey(c, cache.ListingsGroup, userID, etag)
+    if sendCachedResponse(c, cacheKey) {
+        return nil
     }
     
-    // Filter by tags
-    if tagsQuery != "" {
-        tagsList := strings.Split(tagsQuery, ",")
-        for _, tag := range tagsList {
-            cleanTag := strings.TrimSpace(tag)
-            if cleanTag != "" {
-                query = query.Where("? = ANY(posts.tags)", cleanTag)
-            }
-        }
+    // Search the index only for responses that are built
+    if err := filters.ResolveSearch(); err != nil {
+        return filterErrorResponse(c, err, "Failed to search posts")
+    }
+    sort, err := feed.ResolveSort(sortBy, sortDir, filters, userID)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to sort posts")
     }
     
-    // Apply user-specific filters if authenticated
-    if userID > 0 {
-        // Filter by "metoo" status
-        if isMetoo {
-            query = query.Where("EXISTS (SELECT 1 FROM me_toos WHERE me_toos.post_id = posts.id AND me_toos.user_id = ?)", userID)
-        }
-        
-        // Filter by watchlisted status
-        if isWatchlisted {
-            query = query.Where("EXISTS (SELECT 1 FROM user_watchlist WHERE user_watchlist.post_id = posts.id AND user_watchlist.user_id = ?)", userID)
-        }
-    } else if isMetoo || isWatchlisted {
-        // Return empty result if user is not authenticated but tries to use these filters
+    // Return empty result if user is not authenticated but tries to use user-specific filters
+    if userID == 0 && filters.RequiresViewer() {
         return c.JSON(fiber.Map{
-            "posts": []fiber.Map{},
-            "pagination": fiber.Map{
-                "page": page,
-                "limit": limit,
-                "total_posts": 0,
-                "total_pages": 0,
-                "has_more": false,
-            },
+            "posts":      []fiber.Map{},
+            "pagination": page.response(0, ""),
         })
     }
     
-    // Filter by solution status
-    if hasSolutionQuery != "" {
-        hasSolution := hasSolutionQuery == "true"
-        if hasSolution {
-            query = query.Where("EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true)")
-        } else {
-            query = query.Where("NOT EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true)")
-        }
-    }
+    // Initialize base query
+    query := database.DB.Model(&models.Post{})
+    query = filters.Apply(query, userID)
     
     // Count total matching posts for pagination
-    var totalPosts int64
-    if err := database.DB.Table("(?) as filtered_posts", query.Session(&gorm.Session{}).Limit(-1).Offset(-1)).Count(&totalPosts).Error; err != nil {
+    totalPosts, err := page.count(query)
+    if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "error": "Failed to count posts: " + err.Error(),
         })
     }
     
-    // Apply sorting
-    if sortBy == "metoo_count" {
-        query = database.DB.Table("posts").
-            Select("posts.*, COALESCE((SELECT COUNT(*) FROM me_toos WHERE me_toos.post_id = posts.id), 0) as metoo_count").
-            Preload("Comments")
-        
-        // Apply other filters that were applied to the original query
-        if userIDParam != "" {
-            query = query.Where("posts.user_id = ?", userIDParam)
-        }
-        
-        if searchQuery != "" {
-            query = query.Where("posts.title LIKE ? OR posts.content LIKE ?", 
-                "%"+searchQuery+"%", "%"+searchQuery+"%")
-        }
-        
-        // Filter by tags
-        if tagsQuery != "" {
-            tagsList := strings.Split(tagsQuery, ",")
-            for _, tag := range tagsList {
-                cleanTag := strings.TrimSpace(tag)
-                if cleanTag != "" {
-                    query = query.Where("? = ANY(posts.tags)", cleanTag)
-                }
-            }
-        }
-        
-        if userID > 0 {
-            if isMetoo {
-                query = query.Where("EXISTS (SELECT 1 FROM me_toos WHERE me_toos.post_id = posts.id AND me_toos.user_id = ?)", userID)
-            }
-            
-            if isWatchlisted {
-                query = query.Where("EXISTS (SELECT 1 FROM user_watchlist WHERE user_watchlist.post_id = posts.id AND user_watchlist.user_id = ?)", userID)
-            }
-        }
-        
-        if hasSolutionQuery != "" {
-            hasSolution := hasSolutionQuery == "true"
-            if hasSolution {
-                query = query.Where("EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true)")
-            } else {
-                query = query.Where("NOT EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true)")
-            }
-        }
-        
-        // Apply the sorting
-        if sortDir == "asc" {
-            query = query.Order("metoo_count ASC, posts.created_at DESC")
-        } else {
-            query = query.Order("metoo_count DESC, posts.created_at DESC")
-        }
-    } else {
-        // Standard sorting for other fields
-        if sortDir == "asc" {
-            query = query.Order("posts." + sortBy + " ASC")
-        } else {
-            query = query.Order("posts." + sortBy + " DESC")
-        }
-        // Add secondary sort by ID for consistent pagination
-        query = query.Order("posts.id DESC")
-    }
-    
     // Execute the paginated query
-    var posts []models.Post
-    if err := query.Limit(limit).Offset(offset).Find(&posts).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to retrieve posts",
-        })
+    posts, nextCursor, err := page.find(query, sort)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to retrieve posts")
     }
     
     // Extract the data needed for response
@@ -963,10 +1008,13 @@ func GetPosts(c *fiber.Ctx) error {
     if len(posts) > 0 {
         // Maps for efficient lookups
         userMap := make(map[uint]models.User)
+        commentCountMap := make(map[uint]int64)
         metooCountMap := make(map[uint]int64)
         userMetooMap := make(map[uint]bool)
         userWatchlistMap := make(map[uint]bool)
         solutionMap := make(map[uint]fiber.Map)
+        highlightMap := make(map[uint]search.Highlight)
+        commentMatchMap := make(map[uint]search.CommentMatch)
         
         // Collect user and post IDs for batch operations
         userIDs := make([]uint, 0, len(posts))
@@ -980,42 +1028,62 @@ func GetPosts(c *fiber.Ctx) error {
         }
         
         // Fetch all users in one query
-        var users []models.User
-        database.DB.Where("id IN ?", userIDs).Find(&users)
-        for _, user := range users {
-            userMap[user.ID] = user
+        if fieldset.wants("user") {
+            var users []models.User
+            database.DB.Where("id IN ?", userIDs).Find(&users)
+            for _, user := range users {
+                userMap[user.ID] = user
+            }
         }
         
-        // Get metoo counts for all posts in one query
-        type MetooCountResult struct {
+        // Get comment and metoo counts for all posts in one query each
+        type CountResult struct {
             PostID uint
             Count  int64
         }
-        var metooResults []MetooCountResult
-        database.DB.Model(&models.MeToo{}).
-            Select("post_id, count(*) as count").
-            Where("post_id IN ?", postIDs).
-            Group("post_id").
-            Scan(&metooResults)
-            
-        for _, result := range metooResults {
-            metooCountMap[result.PostID] = result.Count
+        if fieldset.wants("comment_count") {
+            var commentResults []CountResult
+            database.DB.Model(&models.Comment{}).
+                Select("post_id, count(*) as count").
+                Where("post_id IN ?", postIDs).
+                Group("post_id").
+                Scan(&commentResults)
+                
+            for _, result := range commentResults {
+                commentCountMap[result.PostID] = result.Count
+            }
+        }
+        if fieldset.wants("metoo_count") {
+            var metooResults []CountResult
+            database.DB.Model(&models.MeToo{}).
+                Select("post_id, count(*) as count").
+                Where("post_id IN ?", postIDs).
+                Group("post_id").
+                Scan(&metooResults)
+                
+            for _, result := range metooResults {
+                metooCountMap[result.PostID] = result.Count
+            }
         }
         
         // If user is authenticated, get their metoos and watchlist items
         if userID > 0 {
             // Check user's metoos
-            var userMetoos []models.MeToo
-            database.DB.Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&userMetoos)
-            for _, metoo := range userMetoos {
-                userMetooMap[metoo.PostID] = true
+            if fieldset.wants("is_metoo") {
+                var userMetoos []models.MeToo
+                database.DB.Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&userMetoos)
+                for _, metoo := range userMetoos {
+                    userMetooMap[metoo.PostID] = true
+                }
             }
             
             // Check user's watchlist
-            var userWatchlist []models.UserWatchlist
-            database.DB.Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&userWatchlist)
-            for _, watchlist := range userWatchlist {
-                userWatchlistMap[watchlist.PostID] = true
+            if fieldset.wants("is_watchlisted") {
+                var userWatchlist []models.UserWatchlist
+                database.DB.Where("user_id = ? AND post_id IN ?", userID, postIDs).Find(&userWatchlist)
+                for _, watchlist := range userWatchlist {
+                    userWatchlistMap[watchlist.PostID] = true
+                }
             }
         }
         
@@ -1032,11 +1100,13 @@ func GetPosts(c *fiber.Ctx) error {
         }
         
         var solutions []SolutionResult
-        database.DB.Model(&models.Comment{}).
-            Select("comments.post_id, comments.id as solution_id, comments.content, comments.pictures, comments.created_at, users.id as user_id, users.username, users.profile_picture_url").
-            Joins("JOIN users ON users.id = comments.user_id").
-            Where("comments.post_id IN ? AND comments.is_solution = ?", postIDs, true).
-            Scan(&solutions)
+        if fieldset.wants("solution") {
+            database.DB.Model(&models.Comment{}).
+                Select("comments.post_id, comments.id as solution_id, comments.content, comments.pictures, comments.created_at, users.id as user_id, users.username, users.profile_picture_url").
+                Joins("JOIN users ON users.id = comments.user_id").
+                Where("comments.post_id IN ? AND comments.is_solution = ?", postIDs, true).
+                Scan(&solutions)
+        }
             
         for _, sol := range solutions {
             solutionMap[sol.PostID] = fiber.Map{
@@ -1050,12 +1120,35 @@ func GetPosts(c *fiber.Ctx) error {
                     "profile_picture_url": sol.ProfilePictureURL,
                 },
             }
+            if renderHTML {
+                addRenderedContent(solutionMap[sol.PostID], sol.Content)
+            }
+        }
+        
+        // Get highlighted snippets of the search matches
+        if filters.Search != "" && fieldset.wants("highlights") {
+            highlights, err := search.Default.Highlights(filters.SearchRequest(), postIDs)
+            if err != nil {
+                log.Printf("Failed to highlight search results: %v", err)
+            } else {
+                highlightMap = highlights
+            }
+        }
+        
+        // Get the best matching comment of each post when searching comments
+        if filters.SearchesComments() && fieldset.wants("matched_comment") {
+            commentMatches, err := search.Default.CommentMatches(filters.SearchRequest(), postIDs)
+            if err != nil {
+                log.Printf("Failed to find matching comments: %v", err)
+            } else {
+                commentMatchMap = commentMatches
+            }
         }
         
         // Format each post with the collected data
         for _, post := range posts {
             user, found := userMap[post.UserID]
-            if !found {
+            if !found && fieldset.wants("user") {
                 // Skip posts where user is not found
                 log.Printf("User not found for post ID %d", post.ID)                                            
                 continue
@@ -1071,7 +1164,7 @@ func GetPosts(c *fiber.Ctx) error {
                 "tags":           post.Tags,
                 "created_at":     post.CreatedAt,
                 "updated_at":     post.UpdatedAt,
-                "comment_count":  len(post.Comments),
+                "comment_count":  commentCountMap[post.ID],
                 "is_metoo":       userMetooMap[post.ID],
                 "metoo_count":    metooCountMap[post.ID],
                 "is_watchlisted": userWatchlistMap[post.ID],
@@ -1081,29 +1174,34 @@ func GetPosts(c *fiber.Ctx) error {
                     "profile_picture_url": user.ProfilePictureURL,
                 },
             }
+            if renderHTML {
+                addRenderedContent(postData, post.Content)
+            }
             
             // Add solution if it exists
             if solution, exists := solutionMap[post.ID]; exists {
                 postData["solution"] = solution
             }
             
-            returnedPosts = append(returnedPosts, postData)
+            // Add search highlights if searching
+            if highlight, exists := highlightMap[post.ID]; exists {
+                postData["highlights"] = highlight
+            }
+            if filters.SearchesComments() {
+                if commentMatch, exists := commentMatchMap[post.ID]; exists {
+                    postData["matched_comment"] = commentMatch
+                } else {
+                    postData["matched_comment"] = nil
+                }
+            }
+            
+            returnedPosts = append(returnedPosts, fieldset.filter(postData))
         }
     }
     
-    // Calculate pagination metadata
-    totalPages := (int(totalPosts) + limit - 1) / limit
-    hasMore := page < totalPages
-    
-    return c.JSON(fiber.Map{
-        "posts": returnedPosts,
-        "pagination": fiber.Map{
-            "page":        page,
-            "limit":       limit,
-            "total_posts": totalPosts,
-            "total_pages": totalPages,
-            "has_more":    hasMore,
-        },
+    return cacheAndSend(c, cacheKey, listingCacheTTL, fiber.Map{
+        "posts":      returnedPosts,
+        "pagination": page.response(totalPosts, nextCursor),
     })
 }
 
@@ -1117,20 +1215,17 @@ func GetUserPosts(c *fiber.Ctx) error {
         })
     }
     
-    // Parse pagination parameters
-    page := c.QueryInt("page", 1)
-    limit := c.QueryInt("limit", 10)
-    
-    // Validate pagination
-    if limit > 50 {
-        limit = 50
+    // Parse pagination, sorting and field parameters
+    page := parsePostPage(c)
+    sort, err := feed.ResolveSort(c.Query("sort_by", "created_at"), c.Query("sort_dir", "desc"), feed.Filters{}, 0)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to sort posts")
     }
-    if page < 1 {
-        page = 1
+    fieldset, err := parsePostFieldset(c)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to parse fields")
     }
     
-    offset := (page - 1) * limit
-    
     // Get current user ID from JWT for personalization
     var currentUserID uint
     if userIDFloat, ok := c.Locals("user_id").(float64); ok {
@@ -1150,42 +1245,60 @@ func GetUserPosts(c *fiber.Ctx) error {
         })
     }
     
-    // Count total posts for pagination
-    var totalPosts int64
-    database.DB.Model(&models.Post{}).Where("user_id = ?", userIDParam).Count(&totalPosts)
+    // Answer conditional requests before building the response
+    etag, unchanged := checkPostsCache(c, 0, currentUserID)
+    if unchanged {
+        return c.SendStatus(fiber.StatusNotModified)
+    }
+    cacheKey := responseCacheKey(c, cache.ListingsGroup, currentUserID, etag)
+    if sendCachedResponse(c, cacheKey) {
+        return nil
+    }
     
-    // Fetch posts with comments
-    var posts []models.Post
-    if err := database.DB.Where("user_id = ?", userIDParam).
-        Preload("Comments").
-        Order("created_at DESC").
-        Limit(limit).
-        Offset(offset).
-        Find(&posts).Error; err != nil {
+    query := database.DB.Model(&models.Post{}).Where("posts.user_id = ?", profileUser.ID)
+    if fieldset.wants("comment_count") {
+        query = query.Preload("Comments")
+    }
+    
+    // Count total posts for pagination
+    totalPosts, err := page.count(query)
+    if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to retrieve posts",
+            "error": "Failed to count posts",
         })
     }
     
+    // Fetch posts with comments
+    posts, nextCursor, err := page.find(query, sort)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to retrieve posts")
+    }
+    
     // Process results
     returnedPosts := make([]fiber.Map, 0, len(posts))
     for _, post := range posts {
         // Get metoo count
         var metooCount int64
-        database.DB.Model(&models.MeToo{}).Where("post_id = ?", post.ID).Count(&metooCount)
+        if fieldset.wants("metoo_count") {
+            database.DB.Model(&models.MeToo{}).Where("post_id = ?", post.ID).Count(&metooCount)
+        }
         
         // Check if current user has metoo'd or watchlisted this post
         var isMetoo, isWatchlisted bool
         if currentUserID > 0 {
             // Check metoo
-            var meTooEntry models.MeToo
-            isMetoo = database.DB.Where("post_id = ? AND user_id = ?", post.ID, currentUserID).
-                First(&meTooEntry).Error == nil
+            if fieldset.wants("is_metoo") {
+                var meTooEntry models.MeToo
+                isMetoo = database.DB.Where("post_id = ? AND user_id = ?", post.ID, currentUserID).
+                    First(&meTooEntry).Error == nil
+            }
             
             // Check watchlist
-            var watchlistEntry models.UserWatchlist
-            isWatchlisted = database.DB.Where("post_id = ? AND user_id = ?", post.ID, currentUserID).
-                First(&watchlistEntry).Error == nil
+            if fieldset.wants("is_watchlisted") {
+                var watchlistEntry models.UserWatchlist
+                isWatchlisted = database.DB.Where("post_id = ? AND user_id = ?", post.ID, currentUserID).
+                    First(&watchlistEntry).Error == nil
+            }
         }
         
         // Build post data
@@ -1210,42 +1323,34 @@ func GetUserPosts(c *fiber.Ctx) error {
         }
         
         // Check if there's a solution
-        var solution models.Comment
-        if err := database.DB.Where("post_id = ? AND is_solution = ?", post.ID, true).
-            First(&solution).Error; err == nil {
-            // Solution exists, get the solver
-            var solver models.User
-            if err := database.DB.First(&solver, solution.UserID).Error; err == nil {
-                postData["solution"] = fiber.Map{
-                    "id":         solution.ID,
-                    "content":    solution.Content,
-                    "pictures":   solution.Pictures,
-                    "created_at": solution.CreatedAt,
-                    "user": fiber.Map{
-                        "id":                 solver.ID,
-                        "username":           solver.Username,
-                        "profile_picture_url": solver.ProfilePictureURL,
-                    },
+        if fieldset.wants("solution") {
+            var solution models.Comment
+            if err := database.DB.Where("post_id = ? AND is_solution = ?", post.ID, true).
+                First(&solution).Error; err == nil {
+                // Solution exists, get the solver
+                var solver models.User
+                if err := database.DB.First(&solver, solution.UserID).Error; err == nil {
+                    postData["solution"] = fiber.Map{
+                        "id":         solution.ID,
+                        "content":    solution.Content,
+                        "pictures":   solution.Pictures,
+                        "created_at": solution.CreatedAt,
+                        "user": fiber.Map{
+                            "id":                 solver.ID,
+                            "username":           solver.Username,
+                            "profile_picture_url": solver.ProfilePictureURL,
+                        },
+                    }
                 }
             }
         }
         
-        returnedPosts = append(returnedPosts, postData)
+        returnedPosts = append(returnedPosts, fieldset.filter(postData))
     }
     
-    // Calculate pagination metadata
-    totalPages := (int(totalPosts) + limit - 1) / limit
-    hasMore := page < totalPages
-    
-    return c.JSON(fiber.Map{
-        "posts": returnedPosts,
-        "pagination": fiber.Map{
-            "page":        page,
-            "limit":       limit,
-            "total_posts": totalPosts,
-            "total_pages": totalPages,
-            "has_more":    hasMore,
-        },
+    return cacheAndSend(c, cacheKey, listingCacheTTL, fiber.Map{
+        "posts":      returnedPosts,
+        "pagination": page.response(totalPosts, nextCursor),
     })
 }
 
@@ -1291,6 +1396,7 @@ func ToggleMetoo(c *fiber.Ctx) error {
                 "error": "Failed to remove metoo",
             })
         }
+        events.Publish(events.Event{Type: events.MetooRemoved, PostID: uint(postID), UserID: userID})
         return c.JSON(fiber.Map{
             "id":      postID,
             "is_metoo":   false,
@@ -1306,6 +1412,7 @@ func ToggleMetoo(c *fiber.Ctx) error {
                 "error": "Failed to add metoo",
             })
         }
+        events.Publish(events.Event{Type: events.MetooAdded, PostID: uint(postID), UserID: userID})
         return c.JSON(fiber.Map{
             "id":      postID,
             "is_metoo":   true,
@@ -1362,6 +1469,7 @@ func ToggleWatchlist(c *fiber.Ctx) error {
                 "error": "Failed to remove from watchlist",
             })
         }
+        events.Publish(events.Event{Type: events.WatchlistChanged, PostID: uint(postID), UserID: userID})
         return c.JSON(fiber.Map{
             "id":             postID,
             "is_watchlisted": false,
@@ -1377,6 +1485,7 @@ func ToggleWatchlist(c *fiber.Ctx) error {
                 "error": "Failed to add to watchlist",
             })
         }
+        events.Publish(events.Event{Type: events.WatchlistChanged, PostID: uint(postID), UserID: userID})
         return c.JSON(fiber.Map{
             "id":             postID,
             "is_watchlisted": true,
@@ -1519,6 +1628,16 @@ func CreateComment(c* fiber.Ctx) error {
         })
     }
 
+    // Record mentions of other users
+    mentions, err := syncMentions(database.DB, comment.UserID, comment.PostID, &comment.ID, comment.Content)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to save mentions",
+        })
+    }
+
+    events.Publish(events.Event{Type: events.CommentCreated, PostID: comment.PostID, CommentID: comment.ID, UserID: comment.UserID})
+
     commentData := fiber.Map{
         "id":               comment.ID,
         "post_id":          comment.PostID,
@@ -1531,6 +1650,8 @@ func CreateComment(c* fiber.Ctx) error {
         "is_disliked":      false,
         "created_at":       comment.CreatedAt,
         "updated_at":       comment.UpdatedAt,
+        "mentions":         formatMentions(mentions),
+        "unknown_mentions": unknownMentions(comment.Content, mentions),
         "user": fiber.Map{
             "id":       user.ID,
             "username": user.Username,
@@ -1611,6 +1732,8 @@ func DeleteCommentPicture(c *fiber.Ctx) error {
         })
     }
 
+    events.Publish(events.Event{Type: events.CommentUpdated, PostID: comment.PostID, CommentID: comment.ID, UserID: userID})
+
     // Delete the picture file from the filesystem
     filePath := fmt.Sprintf("./static/uploads/attached_pictures/%s", pictureURL)
     if err := os.Remove(filePath); err != nil {
@@ -1738,6 +1861,16 @@ func EditComment(c *fiber.Ctx) error {
         })
     }
 
+    // Update mentions to match the edited content
+    mentions, err := syncMentions(database.DB, comment.UserID, comment.PostID, &comment.ID, comment.Content)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to save mentions",
+        })
+    }
+
+    events.Publish(events.Event{Type: events.CommentUpdated, PostID: comment.PostID, CommentID: comment.ID, UserID: userID})
+
     // Fetch user data for response
     var user models.User
     if err := database.DB.First(&user, comment.UserID).Error; err != nil {
@@ -1769,6 +1902,8 @@ func EditComment(c *fiber.Ctx) error {
         "dislike_count":    comment.Dislikes,
         "is_liked":         isLiked,
         "is_disliked":      isDisliked,
+        "mentions":         formatMentions(mentions),
+        "unknown_mentions": unknownMentions(comment.Content, mentions),
         "user": fiber.Map{
             "id":                 user.ID,
             "username":           user.Username,
@@ -1827,6 +1962,26 @@ func DeleteComment(c *fiber.Ctx) error {
         })
     }
 
+    // Delete the comment with its mentions and the notifications about it, and take back
+    // the reputation it earned its author
+    err = database.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.Mention{}).Error; err != nil {
+            return err
+        }
+        if err := tx.Where("comment_id = ?", comment.ID).Delete(&models.Notification{}).Error; err != nil {
+            return err
+        }
+        if err := reputation.ReverseComments(tx, []uint{comment.ID}); err != nil {
+            return err
+        }
+        return tx.Delete(&comment).Error
+    })
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete comment",
+        })
+    }
+
     // Delete the comment's pictures from the filesystem
     for _, picture := range comment.Pictures {
         filePath := fmt.Sprintf("./static/uploads/attached_pictures/%s", filepath.Base(picture))
@@ -1834,13 +1989,7 @@ func DeleteComment(c *fiber.Ctx) error {
             log.Printf("Error deleting file %s: %v", filePath, err)
         }
     }
-
-    // Delete the comment
-    if err := database.DB.Delete(&comment).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to delete comment",
-        })
-    }
+    events.Publish(events.Event{Type: events.CommentDeleted, PostID: comment.PostID, CommentID: comment.ID, UserID: userID})
 
     return c.JSON(fiber.Map{
         "message": "Comment deleted successfully",
@@ -1907,25 +2056,20 @@ func React(c *fiber.Ctx) error {
         })
     }
 
-    // Fetch comment author to update their reputation
-    var commentAuthor models.User
-    if err := tx.First(&commentAuthor, comment.UserID).Error; err != nil {
-        tx.Rollback()
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to fetch comment author",
-        })
-    }
-
     // Check if the user has already reacted to the comment
     var existingReaction models.Reaction
     result := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).First(&existingReaction)
 
     // Variables to track the final state
     var isLiked, isDisliked bool
+
+    // Reactions before and after, for the reputation ledger of the comment author
+    var previousReaction, currentReaction string
     
     if result.Error == nil {
         // Reaction exists, store the old type
         oldReactionType := existingReaction.Type
+        previousReaction = oldReactionType
         
         // If the same reaction is clicked again, remove it (toggle off)
         if existingReaction.Type == reactionRequest.Reaction {
@@ -1941,14 +2085,10 @@ func React(c *fiber.Ctx) error {
                 if comment.Likes > 0 {
                     comment.Likes--
                 }
-                // Decrease author reputation
-                commentAuthor.Reputation--
             } else if oldReactionType == "dislike" {
                 if comment.Dislikes > 0 {
                     comment.Dislikes--
                 }
-                // Increase author reputation (removing a dislike)
-                commentAuthor.Reputation++
             }
             
             // User has no reaction after removal
@@ -1958,6 +2098,7 @@ func React(c *fiber.Ctx) error {
         } else {
             // User is changing their reaction type
             existingReaction.Type = reactionRequest.Reaction
+            currentReaction = reactionRequest.Reaction
             
             if err := tx.Save(&existingReaction).Error; err != nil {
                 tx.Rollback()
@@ -1972,9 +2113,6 @@ func React(c *fiber.Ctx) error {
                     comment.Likes--
                 }
                 comment.Dislikes++
-
-                // Update reputation: -1 for removing like, -1 for adding dislike
-                commentAuthor.Reputation -= 2
                 
                 // Set new reaction state
                 isLiked = false
@@ -1985,9 +2123,6 @@ func React(c *fiber.Ctx) error {
                     comment.Dislikes--
                 }
                 comment.Likes++
-
-                // Update reputation: +1 for removing dislike, +1 for adding like
-                commentAuthor.Reputation += 2
                 
                 // Set new reaction state
                 isLiked = true
@@ -2001,6 +2136,7 @@ func React(c *fiber.Ctx) error {
             UserID:    userID,
             Type:      reactionRequest.Reaction,
         }
+        currentReaction = reactionRequest.Reaction
         
         if err := tx.Create(&newReaction).Error; err != nil {
             tx.Rollback()
@@ -2012,12 +2148,10 @@ func React(c *fiber.Ctx) error {
         // Increment the appropriate count
         if reactionRequest.Reaction == "like" {
             comment.Likes++
-            commentAuthor.Reputation++
             isLiked = true
             isDisliked = false
         } else if reactionRequest.Reaction == "dislike" {
             comment.Dislikes++
-            commentAuthor.Reputation--
             isLiked = false
             isDisliked = true
         }
@@ -2037,8 +2171,13 @@ func React(c *fiber.Ctx) error {
         })
     }
 
-    // Save the updated author with new reputation
-    if err := tx.Save(&commentAuthor).Error; err != nil {
+    // Record the reputation changes of the author
+    ledger := reputation.ReactionChange(previousReaction, currentReaction)
+    reputationEvents := make([]models.ReputationEvent, 0, len(ledger))
+    for _, reason := range ledger {
+        reputationEvents = append(reputationEvents, reputation.Event(comment.UserID, reason, comment.PostID, comment.ID, userID))
+    }
+    if err := reputation.Record(tx, reputationEvents...); err != nil {
         tx.Rollback()
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "error": "Failed to update author reputation",
@@ -2052,6 +2191,13 @@ func React(c *fiber.Ctx) error {
         })
     }
     
+    reaction := events.Event{Type: events.ReactionRemoved, PostID: comment.PostID, CommentID: comment.ID, UserID: userID}
+    if isLiked || isDisliked {
+        reaction.Type = events.ReactionAdded
+        reaction.Detail = reactionRequest.Reaction
+    }
+    events.Publish(reaction)
+    
     // Return the updated comment data
     return c.JSON(fiber.Map{
         "id":               commentID,
@@ -2137,24 +2283,15 @@ func ToggleMarkCommentAsSolution(c *fiber.Ctx) error {
     if err := database.DB.Where("post_id = ? AND is_solution = ?", comment.PostID, true).First(&existingSolution).Error; err == nil {
         // If the comment is already marked as a solution, unmark it
         if existingSolution.ID == comment.ID {
-            existingSolution.IsSolution = false
-            if err := database.DB.Save(&existingSolution).Error; err != nil {
+            // Decrease the solution count and take back the reputation points with it
+            changed, err := setSolution(comment, commentAuthor.ID, user.ID, false)
+            if err != nil {
                 return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
                     "error": "Failed to unmark comment as solution",
                 })
             }
-            // Decrease the solution count
-            if commentAuthor.NumberOfSolutions > 0 {
-                commentAuthor.NumberOfSolutions--
-            }
-            // Decrease reputation points
-            if commentAuthor.Reputation > 0 {
-                commentAuthor.Reputation -= 10
-            }
-            if err := database.DB.Save(&commentAuthor).Error; err != nil {
-                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                    "error": "Failed to update user solution count",
-                })
+            if changed {
+                events.Publish(events.Event{Type: events.CommentUpdated, PostID: comment.PostID, CommentID: comment.ID, UserID: user.ID})
             }
             return c.JSON(fiber.Map{
                 "id":         comment.ID,
@@ -2162,21 +2299,18 @@ func ToggleMarkCommentAsSolution(c *fiber.Ctx) error {
             })
         }
     }
-    // Mark the comment as a solution
-    comment.IsSolution = true
-    if err := database.DB.Save(&comment).Error; err != nil {
+    // Mark the comment as a solution, increase the solution count and award the
+    // reputation points
+    changed, err := setSolution(comment, commentAuthor.ID, user.ID, true)
+    if err != nil {
         return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
             "error": "Failed to mark comment as solution",
         })
     }
-    // Increase the solution count
-    commentAuthor.NumberOfSolutions++
-    // Increase reputation points
-    commentAuthor.Reputation += 10
-    if err := database.DB.Save(&commentAuthor).Error; err != nil {
-        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-            "error": "Failed to update user solution count",
-        })
+    // A concurrent request may have marked it already and notified about it
+    if changed {
+        events.Publish(events.Event{Type: events.CommentUpdated, PostID: comment.PostID, CommentID: comment.ID, UserID: user.ID})
+        events.Publish(events.Event{Type: events.SolutionMarked, PostID: comment.PostID, CommentID: comment.ID, UserID: user.ID})
     }
 
     return c.JSON(fiber.Map{
@@ -2194,4 +2328,35 @@ func ToggleMarkCommentAsSolution(c *fiber.Ctx) error {
             },
         },
     });
-}
\ No newline at end of file
+}
+
+// setSolution marks or unmarks a comment as a solution together with its author's
+// solution count and reputation. It reports whether it changed anything, nothing changes
+// when a concurrent request already did.
+func setSolution(comment models.Comment, authorID, actorID uint, isSolution bool) (bool, error) {
+    count := gorm.Expr("number_of_solutions + 1")
+    reason := models.ReputationSolution
+    if !isSolution {
+        count = gorm.Expr("GREATEST(number_of_solutions - 1, 0)")
+        reason = models.ReputationSolutionRemoved
+    }
+
+    changed := false
+    err := database.DB.Transaction(func(tx *gorm.DB) error {
+        result := tx.Model(&models.Comment{}).
+            Where("id = ? AND is_solution = ?", comment.ID, !isSolution).
+            Update("is_solution", isSolution)
+        if result.Error != nil || result.RowsAffected == 0 {
+            return result.Error
+        }
+        if err := tx.Model(&models.User{}).Where("id = ?", authorID).Update("number_of_solutions", count).Error; err != nil {
+            return err
+        }
+        if err := reputation.Record(tx, reputation.Event(authorID, reason, comment.PostID, comment.ID, actorID)); err != nil {
+            return err
+        }
+        changed = true
+        return nil
+    })
+    return changed && err == nil, err
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/realtime"
+)
+
+// maxStreamPosts caps the post channels one stream may follow
+const maxStreamPosts = 20
+
+// streamHeartbeat is how often an idle stream sends a comment, keeping proxies from
+// closing it and noticing clients that went away
+const streamHeartbeat = 25 * time.Second
+
+// StreamEvents streams real-time updates as Server-Sent Events: the notifications of the
+// authenticated user, and new comments, reactions and solution changes on the posts
+// listed in the posts parameter
+func StreamEvents(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    hub := realtime.Default
+    if hub == nil {
+        return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+            "error": "Real-time updates are disabled",
+        })
+    }
+
+    // Parse the posts to follow
+    channels := []string{realtime.UserChannel(userID)}
+    postIDs := splitList(c.Query("posts"))
+    if len(postIDs) > maxStreamPosts {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": fmt.Sprintf("At most %d posts can be followed", maxStreamPosts),
+        })
+    }
+    for _, value := range postIDs {
+        postID, err := strconv.ParseUint(value, 10, 32)
+        if err != nil || postID == 0 {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "error": "Invalid post ID: " + value,
+            })
+        }
+        channels = append(channels, realtime.PostChannel(uint(postID)))
+    }
+
+    c.Set(fiber.HeaderContentType, "text/event-stream")
+    c.Set(fiber.HeaderCacheControl, "no-cache")
+    c.Set(fiber.HeaderConnection, "keep-alive")
+    c.Set("X-Accel-Buffering", "no")
+
+    subscription := hub.Subscribe(channels...)
+    c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+        defer hub.Unsubscribe(subscription)
+
+        heartbeat := time.NewTicker(streamHeartbeat)
+        defer heartbeat.Stop()
+
+        // Tell the client how long to wait before reconnecting
+        fmt.Fprint(w, "retry: 5000\n\n")
+        if err := w.Flush(); err != nil {
+            return
+        }
+
+        for {
+            select {
+            case message := <-subscription.C:
+                data, err := json.Marshal(message)
+                if err != nil {
+                    continue
+                }
+                fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Event, data)
+            case <-heartbeat.C:
+                fmt.Fprint(w, ": heartbeat\n\n")
+            }
+            // A failed flush means the client went away
+            if err := w.Flush(); err != nil {
+                return
+            }
+        }
+    })
+    return nil
+}
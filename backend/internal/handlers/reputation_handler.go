@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/reputation"
+)
+
+// GetReputationHistory lists the reputation ledger of a user, newest first, with the
+// reputation it adds up to. Who disliked the user is never shown.
+func GetReputationHistory(c *fiber.Ctx) error {
+    // Get user ID from URL parameter
+    userID, err := c.ParamsInt("user_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid user ID",
+        })
+    }
+
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "User not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to get user",
+        })
+    }
+
+    // Parse pagination parameters
+    page := c.QueryInt("page", 1)
+    limit := c.QueryInt("limit", 20)
+
+    // Validate pagination
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 20
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    offset := (page - 1) * limit
+
+    query := database.DB.Model(&models.ReputationEvent{}).Where("user_id = ?", user.ID)
+
+    // Count total events for pagination
+    var totalEvents int64
+    if err := query.Session(&gorm.Session{}).Count(&totalEvents).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count reputation events",
+        })
+    }
+
+    var reputationEvents []models.ReputationEvent
+    if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&reputationEvents).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve reputation events",
+        })
+    }
+
+    returnedEvents := make([]fiber.Map, 0, len(reputationEvents))
+    if len(reputationEvents) > 0 {
+        // Collect actor and post IDs for batch lookups
+        actorIDs := make([]uint, 0, len(reputationEvents))
+        postIDs := make([]uint, 0, len(reputationEvents))
+        for _, event := range reputationEvents {
+            if event.ActorID != nil && reputation.ShowsActor(event.Reason) {
+                actorIDs = append(actorIDs, *event.ActorID)
+            }
+            if event.PostID != nil {
+                postIDs = append(postIDs, *event.PostID)
+            }
+        }
+
+        actorMap := make(map[uint]models.User)
+        if len(actorIDs) > 0 {
+            var actors []models.User
+            database.DB.Where("id IN ?", actorIDs).Find(&actors)
+            for _, actor := range actors {
+                actorMap[actor.ID] = actor
+            }
+        }
+
+        // Posts deleted since keep a nil title
+        postTitleMap := make(map[uint]string)
+        if len(postIDs) > 0 {
+            var posts []models.Post
+            database.DB.Select("id", "title").Where("id IN ?", postIDs).Find(&posts)
+            for _, post := range posts {
+                postTitleMap[post.ID] = post.Title
+            }
+        }
+
+        for _, event := range reputationEvents {
+            data := fiber.Map{
+                "id":         event.ID,
+                "delta":      event.Delta,
+                "reason":     event.Reason,
+                "post_id":    event.PostID,
+                "post_title": nil,
+                "comment_id": event.CommentID,
+                "created_at": event.CreatedAt,
+                "actor":      nil,
+            }
+            if event.PostID != nil {
+                if title, found := postTitleMap[*event.PostID]; found {
+                    data["post_title"] = title
+                }
+            }
+            if event.ActorID != nil && reputation.ShowsActor(event.Reason) {
+                if actor, found := actorMap[*event.ActorID]; found {
+                    data["actor"] = fiber.Map{
+                        "id":                  actor.ID,
+                        "username":            actor.Username,
+                        "profile_picture_url": actor.ProfilePictureURL,
+                    }
+                }
+            }
+            returnedEvents = append(returnedEvents, data)
+        }
+    }
+
+    // Calculate pagination metadata
+    totalPages := (int(totalEvents) + limit - 1) / limit
+    hasMore := page < totalPages
+
+    return c.JSON(fiber.Map{
+        "user_id":    user.ID,
+        "reputation": user.Reputation,
+        "events":     returnedEvents,
+        "pagination": fiber.Map{
+            "page":         page,
+            "limit":        limit,
+            "total_events": totalEvents,
+            "total_pages":  totalPages,
+            "has_more":     hasMore,
+        },
+    })
+}
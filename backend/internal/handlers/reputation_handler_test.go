@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+)
+
+// mockDatabase replaces database.DB for a test with one whose statements are checked
+// against the expectations of the returned mock
+func mockDatabase(t *testing.T) sqlmock.Sqlmock {
+    conn, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+        Logger: logger.Default.LogMode(logger.Silent),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    previous := database.DB
+    database.DB = db
+    t.Cleanup(func() {
+        database.DB = previous
+        conn.Close()
+        if err := mock.ExpectationsWereMet(); err != nil {
+            t.Error(err)
+        }
+    })
+    return mock
+}
+
+func TestSetSolution(t *testing.T) {
+    comment := models.Comment{ID: 3, PostID: 2}
+
+    t.Run("marks", func(t *testing.T) {
+        mock := mockDatabase(t)
+        mock.ExpectBegin()
+        mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "is_solution"=$1`)).
+            WillReturnResult(sqlmock.NewResult(0, 1))
+        mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "number_of_solutions"=number_of_solutions + 1`)).
+            WillReturnResult(sqlmock.NewResult(0, 1))
+        mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reputation_events"`)).
+            WithArgs(4, 10, models.ReputationSolution, 2, 3, 9, sqlmock.AnyArg()).
+            WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+        mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET reputation`)).
+            WillReturnResult(sqlmock.NewResult(0, 1))
+        mock.ExpectCommit()
+
+        changed, err := setSolution(comment, 4, 9, true)
+        if err != nil || !changed {
+            t.Errorf("setSolution() = %v, %v, want a change", changed, err)
+        }
+    })
+
+    t.Run("already marked", func(t *testing.T) {
+        // A concurrent request got there first, neither the count nor the ledger change
+        mock := mockDatabase(t)
+        mock.ExpectBegin()
+        mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "is_solution"=$1`)).
+            WillReturnResult(sqlmock.NewResult(0, 0))
+        mock.ExpectCommit()
+
+        changed, err := setSolution(comment, 4, 9, true)
+        if err != nil || changed {
+            t.Errorf("setSolution() = %v, %v, want no change", changed, err)
+        }
+    })
+
+    t.Run("failed ledger", func(t *testing.T) {
+        mock := mockDatabase(t)
+        mock.ExpectBegin()
+        mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "is_solution"=$1`)).
+            WillReturnResult(sqlmock.NewResult(0, 1))
+        mock.ExpectExec(regexp.QuoteMeta(`UPDATE "users" SET "number_of_solutions"=GREATEST(number_of_solutions - 1, 0)`)).
+            WillReturnResult(sqlmock.NewResult(0, 1))
+        mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reputation_events"`)).
+            WillReturnError(sqlmock.ErrCancelled)
+        mock.ExpectRollback()
+
+        changed, err := setSolution(comment, 4, 9, false)
+        if err == nil || changed {
+            t.Errorf("setSolution() = %v, %v, want the error and no change", changed, err)
+        }
+    })
+}
+
+func TestGetReputationHistoryHidesDislikers(t *testing.T) {
+    mock := mockDatabase(t)
+    now := time.Now()
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE "users"."id" = $1`)).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "username", "reputation"}).AddRow(4, "author", 0))
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "reputation_events"`)).
+        WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reputation_events"`)).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "delta", "reason", "post_id", "comment_id", "actor_id", "created_at"}).
+            AddRow(3, 4, -1, models.ReputationDislike, 2, 3, 8, now).
+            AddRow(2, 4, -1, models.ReputationLikeRemoved, 2, 3, 8, now).
+            AddRow(1, 4, 1, models.ReputationLike, 2, 3, 9, now))
+    // Only the liker is looked up
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "users" WHERE id IN ($1)`)).
+        WithArgs(9).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "username"}).AddRow(9, "liker"))
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id","title" FROM "posts"`)).
+        WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(2, "Post"))
+
+    app := fiber.New()
+    app.Get("/users/:user_id/reputation", GetReputationHistory)
+    resp, err := app.Test(httptest.NewRequest("GET", "/users/4/reputation", nil))
+    if err != nil {
+        t.Fatal(err)
+    }
+    var body struct {
+        Events []struct {
+            Reason string                 `json:"reason"`
+            Actor  map[string]interface{} `json:"actor"`
+        } `json:"events"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+        t.Fatal(err)
+    }
+    if len(body.Events) != 3 {
+        t.Fatalf("%d events, want 3", len(body.Events))
+    }
+    for _, event := range body.Events {
+        if shown := event.Actor != nil; shown != (event.Reason == models.ReputationLike) {
+            t.Errorf("actor of %s = %v", event.Reason, event.Actor)
+        }
+    }
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/cache"
+)
+
+// Lifetimes of cached responses. Responses are cached under the ETag of the content they
+// were built from, so they are never served for newer content. Events invalidate them
+// early and the lifetime bounds how long outdated entries take up space.
+const (
+    listingCacheTTL = time.Minute
+    postCacheTTL    = 5 * time.Minute
+)
+
+// responseCacheKey returns the cache key of a response in a group for the content version
+// with the given ETag, or "" when it isn't cached. Only anonymous responses are cached,
+// the others have per-user fields, and only when the version is known.
+func responseCacheKey(c *fiber.Ctx, group string, viewerID uint, etag string) string {
+    if cache.Default == nil || viewerID > 0 || etag == "" {
+        return ""
+    }
+    return cache.Key(cache.Default, group, etag+" "+c.Path()+"?"+string(c.Request().URI().QueryString()))
+}
+
+// sendCachedResponse sends the response cached under a key and reports whether there
+// was one
+func sendCachedResponse(c *fiber.Ctx, key string) bool {
+    if key == "" {
+        return false
+    }
+    body, found, err := cache.Default.Get(key)
+    if err != nil {
+        log.Printf("Failed to read cache: %v", err)
+        return false
+    }
+    if !found {
+        return false
+    }
+    c.Set("X-Cache", "HIT")
+    c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+    c.Send(body)
+    return true
+}
+
+// cacheAndSend sends a JSON response, caching it under the key taken before it was built,
+// so a change in between leaves it under an outdated version
+func cacheAndSend(c *fiber.Ctx, key string, ttl time.Duration, response interface{}) error {
+    if key == "" {
+        return c.JSON(response)
+    }
+    body, err := json.Marshal(response)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to encode response",
+        })
+    }
+    if err := cache.Default.Set(key, body, ttl); err != nil {
+        log.Printf("Failed to write cache: %v", err)
+    }
+    c.Set("X-Cache", "MISS")
+    c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+    return c.Send(body)
+}
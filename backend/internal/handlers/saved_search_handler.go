@@ -0,0 +1,455 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/feed"
+	"techquire-backend/internal/models"
+)
+
+// Maximum length of a saved search name
+const maxSavedSearchNameLength = 100
+
+// savedSearchRequest is the body of CreateSavedSearch and UpdateSavedSearch.
+// Params holds GetPosts query parameters, e.g. {"q": "tag:go is:unsolved"}.
+type savedSearchRequest struct {
+    Name          *string           `json:"name"`
+    Params        map[string]string `json:"params"`
+    AlertsEnabled *bool             `json:"alerts_enabled"`
+    EmailAlerts   *bool             `json:"email_alerts"`
+}
+
+// formatSavedSearch builds the response data of a saved search
+func formatSavedSearch(search models.SavedSearch) fiber.Map {
+    return fiber.Map{
+        "id":             search.ID,
+        "name":           search.Name,
+        "params":         feed.DecodeParams(search.Params),
+        "alerts_enabled": search.AlertsEnabled,
+        "email_alerts":   search.EmailAlerts,
+        "created_at":     search.CreatedAt,
+        "updated_at":     search.UpdatedAt,
+    }
+}
+
+// validateSearchParams encodes the filter parameters of a saved search and checks that
+// GetPosts accepts them. An error message for the client is returned if not.
+func validateSearchParams(params map[string]string) (string, string) {
+    encoded := feed.EncodeParams(params)
+    if encoded == "" {
+        return "", "At least one search parameter is required"
+    }
+    if _, err := feed.ParseFilters(database.DB, feed.DecodeParams(encoded)); err != nil {
+        if filterErr, ok := err.(*feed.FilterError); ok {
+            return "", filterErr.Error()
+        }
+        return "", "Failed to parse filters"
+    }
+    return encoded, ""
+}
+
+// latestPostID returns the ID of the newest post. Alerts start after it when enabled.
+func latestPostID() uint {
+    var postID uint
+    database.DB.Model(&models.Post{}).Select("COALESCE(MAX(id), 0)").Scan(&postID)
+    return postID
+}
+
+// GetSavedSearches lists the saved searches of the authenticated user
+func GetSavedSearches(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var searches []models.SavedSearch
+    if err := database.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&searches).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve saved searches",
+        })
+    }
+
+    returnedSearches := make([]fiber.Map, 0, len(searches))
+    for _, search := range searches {
+        returnedSearches = append(returnedSearches, formatSavedSearch(search))
+    }
+
+    return c.JSON(fiber.Map{
+        "searches": returnedSearches,
+    })
+}
+
+// CreateSavedSearch saves a search of the authenticated user
+func CreateSavedSearch(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    var request savedSearchRequest
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    // Validate name and parameters
+    if request.Name == nil || strings.TrimSpace(*request.Name) == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Name is required",
+        })
+    }
+    name := strings.TrimSpace(*request.Name)
+    if len(name) > maxSavedSearchNameLength {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Name is too long",
+        })
+    }
+    params, message := validateSearchParams(request.Params)
+    if message != "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": message,
+        })
+    }
+
+    search := models.SavedSearch{
+        UserID: userID,
+        Name:   name,
+        Params: params,
+    }
+    if request.AlertsEnabled != nil {
+        search.AlertsEnabled = *request.AlertsEnabled
+    }
+    if request.EmailAlerts != nil {
+        search.EmailAlerts = *request.EmailAlerts
+    }
+    if search.AlertsEnabled {
+        search.LastPostID = latestPostID()
+    }
+
+    if err := database.DB.Create(&search).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to save search",
+        })
+    }
+
+    return c.Status(fiber.StatusCreated).JSON(formatSavedSearch(search))
+}
+
+// UpdateSavedSearch renames a saved search, changes its parameters or turns its alerts on or off
+func UpdateSavedSearch(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get search ID from URL parameter
+    searchID, err := c.ParamsInt("search_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid search ID",
+        })
+    }
+
+    var search models.SavedSearch
+    if err := database.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&search).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Saved search not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve saved search",
+        })
+    }
+
+    var request savedSearchRequest
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    if request.Name != nil {
+        name := strings.TrimSpace(*request.Name)
+        if name == "" || len(name) > maxSavedSearchNameLength {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "error": "Name must be between 1 and 100 characters",
+            })
+        }
+        search.Name = name
+    }
+    if request.Params != nil {
+        params, message := validateSearchParams(request.Params)
+        if message != "" {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "error": message,
+            })
+        }
+        search.Params = params
+    }
+    if request.AlertsEnabled != nil {
+        // Alerts only cover posts created after they are turned on
+        if *request.AlertsEnabled && !search.AlertsEnabled {
+            search.LastPostID = latestPostID()
+        }
+        search.AlertsEnabled = *request.AlertsEnabled
+    }
+    if request.EmailAlerts != nil {
+        search.EmailAlerts = *request.EmailAlerts
+    }
+
+    if err := database.DB.Save(&search).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update saved search",
+        })
+    }
+
+    return c.JSON(formatSavedSearch(search))
+}
+
+// DeleteSavedSearch deletes a saved search of the authenticated user and its alerts
+func DeleteSavedSearch(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get search ID from URL parameter
+    searchID, err := c.ParamsInt("search_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid search ID",
+        })
+    }
+
+    var search models.SavedSearch
+    if err := database.DB.Where("id = ? AND user_id = ?", searchID, userID).First(&search).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Saved search not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve saved search",
+        })
+    }
+
+    err = database.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("saved_search_id = ?", search.ID).Delete(&models.SearchAlert{}).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&search).Error
+    })
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete saved search",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "Saved search deleted successfully",
+    })
+}
+
+// GetSearchAlerts lists the alerts of the authenticated user, newest first.
+// Supports pagination, unread=true and search_id to only list one saved search's alerts.
+func GetSearchAlerts(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Parse pagination and filter parameters
+    page := c.QueryInt("page", 1)
+    limit := c.QueryInt("limit", 20)
+    unreadOnly := c.QueryBool("unread", false)
+    searchID := c.QueryInt("search_id", 0)
+
+    // Validate pagination
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 20
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    offset := (page - 1) * limit
+
+    query := database.DB.Model(&models.SearchAlert{}).Where("user_id = ?", userID)
+    if unreadOnly {
+        query = query.Where("is_read = ?", false)
+    }
+    if searchID > 0 {
+        query = query.Where("saved_search_id = ?", searchID)
+    }
+
+    // Count total alerts for pagination
+    var totalAlerts int64
+    if err := query.Session(&gorm.Session{}).Count(&totalAlerts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count alerts",
+        })
+    }
+
+    // Count unread alerts for the badge in the UI
+    var unreadCount int64
+    database.DB.Model(&models.SearchAlert{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&unreadCount)
+
+    var alerts []models.SearchAlert
+    if err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&alerts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve alerts",
+        })
+    }
+
+    returnedAlerts := make([]fiber.Map, 0, len(alerts))
+    if len(alerts) > 0 {
+        // Collect saved search and post IDs for batch lookups
+        searchIDs := make([]uint, 0, len(alerts))
+        postIDs := make([]uint, 0, len(alerts))
+        for _, alert := range alerts {
+            searchIDs = append(searchIDs, alert.SavedSearchID)
+            postIDs = append(postIDs, alert.PostID)
+        }
+
+        searchNameMap := make(map[uint]string)
+        var searches []models.SavedSearch
+        database.DB.Select("id", "name").Where("id IN ?", searchIDs).Find(&searches)
+        for _, search := range searches {
+            searchNameMap[search.ID] = search.Name
+        }
+
+        postTitleMap := make(map[uint]string)
+        var posts []models.Post
+        database.DB.Select("id", "title").Where("id IN ?", postIDs).Find(&posts)
+        for _, post := range posts {
+            postTitleMap[post.ID] = post.Title
+        }
+
+        for _, alert := range alerts {
+            returnedAlerts = append(returnedAlerts, fiber.Map{
+                "id":                alert.ID,
+                "saved_search_id":   alert.SavedSearchID,
+                "saved_search_name": searchNameMap[alert.SavedSearchID],
+                "post_id":           alert.PostID,
+                "post_title":        postTitleMap[alert.PostID],
+                "is_read":           alert.IsRead,
+                "created_at":        alert.CreatedAt,
+            })
+        }
+    }
+
+    // Calculate pagination metadata
+    totalPages := (int(totalAlerts) + limit - 1) / limit
+    hasMore := page < totalPages
+
+    return c.JSON(fiber.Map{
+        "alerts":       returnedAlerts,
+        "unread_count": unreadCount,
+        "pagination": fiber.Map{
+            "page":         page,
+            "limit":        limit,
+            "total_alerts": totalAlerts,
+            "total_pages":  totalPages,
+            "has_more":     hasMore,
+        },
+    })
+}
+
+// MarkSearchAlertRead marks a single alert of the authenticated user as read
+func MarkSearchAlertRead(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Get alert ID from URL parameter
+    alertID, err := c.ParamsInt("alert_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid alert ID",
+        })
+    }
+
+    result := database.DB.Model(&models.SearchAlert{}).
+        Where("id = ? AND user_id = ?", alertID, userID).
+        Update("is_read", true)
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update alert",
+        })
+    }
+    if result.RowsAffected == 0 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Alert not found",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "id":      alertID,
+        "is_read": true,
+    })
+}
+
+// MarkAllSearchAlertsRead marks every alert of the authenticated user as read
+func MarkAllSearchAlertsRead(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    if err := database.DB.Model(&models.SearchAlert{}).
+        Where("user_id = ? AND is_read = ?", userID, false).
+        Update("is_read", true).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update alerts",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "All alerts marked as read",
+    })
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log"
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/search"
+)
+
+// reindexing is set while a reindex started through the API runs
+var reindexing atomic.Bool
+
+// ReindexSearch rebuilds the search index of the running server in the background (admins
+// only). The index keeps serving searches meanwhile.
+func ReindexSearch(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to reindex search",
+        })
+    }
+
+    if !reindexing.CompareAndSwap(false, true) {
+        return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+            "error": "A reindex is already running",
+        })
+    }
+    go func() {
+        defer reindexing.Store(false)
+        if err := search.Reindex(database.DB, search.Default); err != nil {
+            log.Printf("[SEARCH] Failed to reindex: %v", err)
+        }
+    }()
+
+    return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+        "message": "Reindex started",
+    })
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/feed"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/markdown"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/syndication"
+	"techquire-backend/internal/tagging"
+)
+
+// Number of entries in a feed, readers poll often so feeds stay short
+const (
+    defaultFeedLength = 20
+    maxFeedLength     = 50
+)
+
+// feedFormat validates the format of a feed URL ("rss" or "atom")
+func feedFormat(format string) (string, bool) {
+    format = strings.ToLower(format)
+    _, ok := syndication.ContentTypes[format]
+    return format, ok
+}
+
+// feedLength reads the number of entries requested with the limit parameter
+func feedLength(c *fiber.Ctx) int {
+    limit := c.QueryInt("limit", defaultFeedLength)
+    if limit < 1 || limit > maxFeedLength {
+        limit = defaultFeedLength
+    }
+    return limit
+}
+
+// postLink returns the frontend URL of a post
+func postLink(postID uint) string {
+    return mailer.Link(fmt.Sprintf("/post/%d", postID))
+}
+
+// feedID returns the canonical URL of a feed, its permanent Atom ID. The query keeps
+// only the non-empty filter parameters in a fixed order, so links that differ in
+// parameter order or length still name the same feed.
+func feedID(c *fiber.Ctx) string {
+    query := url.Values{}
+    for key, value := range c.Queries() {
+        if key != "limit" && value != "" {
+            query.Set(key, value)
+        }
+    }
+    id := c.BaseURL() + c.Path()
+    if len(query) > 0 {
+        id += "?" + query.Encode()
+    }
+    return id
+}
+
+// usernames maps user IDs to usernames
+func usernames(userIDs []uint) map[uint]string {
+    names := make(map[uint]string)
+    if len(userIDs) == 0 {
+        return names
+    }
+    var users []models.User
+    database.DB.Select("id, username").Where("id IN ?", userIDs).Find(&users)
+    for _, user := range users {
+        names[user.ID] = user.Username
+    }
+    return names
+}
+
+// sendFeed renders a feed, answering with 304 Not Modified when the reader already has
+// the current version
+func sendFeed(c *fiber.Ctx, f syndication.Feed, format string) error {
+    // Fixed once, so the document and Last-Modified agree for a feed without entries
+    f.Updated = f.LastUpdated()
+    // Atom needs an author for entries without one
+    f.Author = "TechQuire"
+    body, err := f.Render(format)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to render feed",
+        })
+    }
+
+    // Feeds are the same for everyone, so the ETag is a strong hash of the body
+    sum := sha256.Sum256(body)
+    c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+    if notModified(c, `"`+hex.EncodeToString(sum[:16])+`"`, f.LastUpdated(), true) {
+        return c.SendStatus(fiber.StatusNotModified)
+    }
+
+    c.Set(fiber.HeaderContentType, syndication.ContentTypes[format])
+    return c.Send(body)
+}
+
+// sendPostsFeed sends the newest posts matching the GetPosts filter parameters. Feed
+// readers don't authenticate, so filters on the viewer's own activity are rejected.
+func sendPostsFeed(c *fiber.Ctx, format string, params map[string]string, f syndication.Feed) error {
+    filters, err := feed.ParseFilters(database.DB, params)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to parse filters")
+    }
+    if filters.RequiresViewer() {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Feeds are public and can't be filtered by your own activity",
+        })
+    }
+    if err := filters.ResolveSearch(); err != nil {
+        return filterErrorResponse(c, err, "Failed to search posts")
+    }
+    sort, err := feed.ResolveSort(c.Query("sort_by", "created_at"), c.Query("sort_dir", "desc"), filters, 0)
+    if err != nil {
+        return filterErrorResponse(c, err, "Failed to sort posts")
+    }
+
+    var posts []models.Post
+    query := filters.Apply(database.DB.Model(&models.Post{}), 0)
+    if err := sort.Apply(query).Limit(feedLength(c)).Find(&posts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve posts",
+        })
+    }
+
+    userIDs := make([]uint, 0, len(posts))
+    for _, post := range posts {
+        userIDs = append(userIDs, post.UserID)
+    }
+    authors := usernames(userIDs)
+
+    f.ID = feedID(c)
+    f.SelfLink = c.BaseURL() + c.OriginalURL()
+    f.Entries = make([]syndication.Entry, 0, len(posts))
+    for _, post := range posts {
+        link := postLink(post.ID)
+        f.Entries = append(f.Entries, syndication.Entry{
+            ID:          link,
+            Title:       post.Title,
+            Link:        link,
+            Author:      authors[post.UserID],
+            ContentHTML: markdown.Render(post.Content),
+            Categories:  post.Tags,
+            Published:   post.CreatedAt,
+            Updated:     post.UpdatedAt,
+        })
+    }
+    return sendFeed(c, f, format)
+}
+
+// GetPostsFeed returns the post feed as RSS or Atom, filtered like GetPosts
+func GetPostsFeed(c *fiber.Ctx) error {
+    format, ok := feedFormat(c.Params("format"))
+    if !ok {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Unknown feed format",
+        })
+    }
+    return sendPostsFeed(c, format, c.Queries(), syndication.Feed{
+        Title:       "TechQuire",
+        Description: "The newest questions on TechQuire",
+        Link:        mailer.Link("/feed"),
+    })
+}
+
+// GetTagFeed returns the posts with a tag as RSS or Atom. The tag name and format share
+// the last path segment ("node.js.rss"), so it is split at the last dot.
+func GetTagFeed(c *fiber.Ctx) error {
+    file, err := url.PathUnescape(c.Params("tag_feed"))
+    if err != nil {
+        file = c.Params("tag_feed")
+    }
+    dot := strings.LastIndex(file, ".")
+    if dot < 1 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Unknown feed format",
+        })
+    }
+    format, ok := feedFormat(file[dot+1:])
+    if !ok {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Unknown feed format",
+        })
+    }
+    tag := tagging.Normalize(file[:dot])
+
+    params := c.Queries()
+    params["tags"] = tag
+    return sendPostsFeed(c, format, params, syndication.Feed{
+        Title:       "TechQuire: " + tag,
+        Description: "The newest questions tagged " + tag + " on TechQuire",
+        Link:        mailer.Link("/feed?tags=" + url.QueryEscape(tag)),
+    })
+}
+
+// GetUserFeed returns the posts of a user as RSS or Atom
+func GetUserFeed(c *fiber.Ctx) error {
+    format, ok := feedFormat(c.Params("format"))
+    if !ok {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Unknown feed format",
+        })
+    }
+
+    var user models.User
+    userID, err := c.ParamsInt("user_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid user ID",
+        })
+    }
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "User not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+
+    params := c.Queries()
+    params["user_id"] = fmt.Sprint(user.ID)
+    return sendPostsFeed(c, format, params, syndication.Feed{
+        Title:       "TechQuire: " + user.Username,
+        Description: "The newest questions by " + user.Username + " on TechQuire",
+        Link:        mailer.Link("/profile/" + url.PathEscape(user.Username)),
+    })
+}
+
+// GetPostCommentsFeed returns the newest comments of a post as RSS or Atom
+func GetPostCommentsFeed(c *fiber.Ctx) error {
+    format, ok := feedFormat(c.Params("format"))
+    if !ok {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Unknown feed format",
+        })
+    }
+
+    var post models.Post
+    postID, err := c.ParamsInt("post_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid post ID",
+        })
+    }
+    if err := database.DB.First(&post, postID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Post not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve post",
+        })
+    }
+
+    var comments []models.Comment
+    if err := database.DB.Where("post_id = ?", post.ID).
+        Order("created_at DESC, id DESC").
+        Limit(feedLength(c)).
+        Find(&comments).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve comments",
+        })
+    }
+
+    userIDs := make([]uint, 0, len(comments))
+    for _, comment := range comments {
+        userIDs = append(userIDs, comment.UserID)
+    }
+    authors := usernames(userIDs)
+
+    link := postLink(post.ID)
+    f := syndication.Feed{
+        Title:       "Comments on " + post.Title,
+        Description: "The newest comments on \"" + post.Title + "\" on TechQuire",
+        ID:          feedID(c),
+        Link:        link,
+        SelfLink:    c.BaseURL() + c.OriginalURL(),
+        Updated:     post.UpdatedAt,
+        Entries:     make([]syndication.Entry, 0, len(comments)),
+    }
+    for _, comment := range comments {
+        title := "Comment by " + authors[comment.UserID]
+        if comment.IsSolution {
+            title = "Solution by " + authors[comment.UserID]
+        }
+        f.Entries = append(f.Entries, syndication.Entry{
+            ID:          fmt.Sprintf("%s#comment-%d", link, comment.ID),
+            Title:       title,
+            Link:        fmt.Sprintf("%s#comment-%d", link, comment.ID),
+            Author:      authors[comment.UserID],
+            ContentHTML: markdown.Render(comment.Content),
+            Published:   comment.CreatedAt,
+            Updated:     comment.UpdatedAt,
+        })
+    }
+    return sendFeed(c, f, format)
+}
@@ -0,0 +1,686 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/tagging"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// maxTagDescriptionLength limits the short tag summary
+const maxTagDescriptionLength = 500
+
+// tagParam returns the normalized tag name from the URL parameter
+func tagParam(c *fiber.Ctx) string {
+    name, err := url.PathUnescape(c.Params("name"))
+    if err != nil {
+        name = c.Params("name")
+    }
+    return tagging.Normalize(name)
+}
+
+// findTag looks up a tag by its name or by one of its synonyms
+func findTag(name string) (models.Tag, error) {
+    var tag models.Tag
+    err := database.DB.Where("name = ?", name).First(&tag).Error
+    if err == gorm.ErrRecordNotFound {
+        err = database.DB.Joins("JOIN tag_synonyms ON tag_synonyms.tag_id = tags.id").
+            Where("tag_synonyms.name = ?", name).
+            First(&tag).Error
+    }
+    return tag, err
+}
+
+// formatTag formats a tag for API responses
+func formatTag(tag models.Tag) fiber.Map {
+    return fiber.Map{
+        "id":          tag.ID,
+        "name":        tag.Name,
+        "description": tag.Description,
+        "usage_count": tag.UsageCount,
+        "created_at":  tag.CreatedAt,
+        "updated_at":  tag.UpdatedAt,
+    }
+}
+
+// GetTags lists tags with support for searching, sorting and pagination
+func GetTags(c *fiber.Ctx) error {
+    // Parse pagination, sorting and search parameters
+    page := c.QueryInt("page", 1)
+    limit := c.QueryInt("limit", 20)
+    sortBy := c.Query("sort_by", "popular")
+    search := tagging.Normalize(c.Query("search"))
+
+    // Validate pagination
+    if limit > 100 {
+        limit = 100
+    }
+    if limit < 1 {
+        limit = 20
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    offset := (page - 1) * limit
+
+    query := database.DB.Model(&models.Tag{})
+    // Escape LIKE wildcards so they are matched literally
+    escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(search)
+    if search != "" {
+        query = query.Where("name LIKE ?", "%"+escaped+"%")
+    }
+
+    // Count total matching tags for pagination
+    var totalTags int64
+    if err := query.Session(&gorm.Session{}).Count(&totalTags).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count tags",
+        })
+    }
+
+    // Apply sorting, tags starting with the search term come first
+    if search != "" {
+        query = query.Select("tags.*, CASE WHEN name LIKE ? THEN 0 ELSE 1 END AS prefix_rank", escaped+"%").
+            Order("prefix_rank ASC")
+    }
+    switch sortBy {
+    case "name":
+        query = query.Order("name ASC")
+    case "new":
+        query = query.Order("created_at DESC").Order("name ASC")
+    default:
+        query = query.Order("usage_count DESC").Order("name ASC")
+    }
+
+    var tags []models.Tag
+    if err := query.Limit(limit).Offset(offset).Find(&tags).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tags",
+        })
+    }
+
+    returnedTags := make([]fiber.Map, 0, len(tags))
+    for _, tag := range tags {
+        returnedTags = append(returnedTags, formatTag(tag))
+    }
+
+    // Calculate pagination metadata
+    totalPages := (int(totalTags) + limit - 1) / limit
+    hasMore := page < totalPages
+
+    return c.JSON(fiber.Map{
+        "tags": returnedTags,
+        "pagination": fiber.Map{
+            "page":        page,
+            "limit":       limit,
+            "total_tags":  totalTags,
+            "total_pages": totalPages,
+            "has_more":    hasMore,
+        },
+    })
+}
+
+// GetTag returns a tag with its wiki, synonyms and top posts.
+// Looking up a synonym returns its canonical tag.
+func GetTag(c *fiber.Ctx) error {
+    tag, err := findTag(tagParam(c))
+    if err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Tag not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag",
+        })
+    }
+
+    limit := c.QueryInt("limit", 10)
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 10
+    }
+
+    // Top posts are the ones most people ran into, then the most discussed
+    type TopPostResult struct {
+        ID                uint
+        Title             string
+        CreatedAt         time.Time
+        MetooCount        int64
+        CommentCount      int64
+        HasSolution       bool
+        UserID            uint
+        Username          string
+        ProfilePictureURL *string `gorm:"column:profile_picture_url"`
+    }
+
+    var topPosts []TopPostResult
+    if err := database.DB.Table("posts").
+        Select(`posts.id, posts.title, posts.created_at,
+            (SELECT COUNT(*) FROM me_toos WHERE me_toos.post_id = posts.id) AS metoo_count,
+            (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id) AS comment_count,
+            EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.is_solution = true) AS has_solution,
+            users.id AS user_id, users.username, users.profile_picture_url`).
+        Joins("JOIN users ON users.id = posts.user_id").
+        Where("? = ANY(posts.tags)", tag.Name).
+        Order("metoo_count DESC, comment_count DESC, posts.created_at DESC").
+        Limit(limit).
+        Scan(&topPosts).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve top posts",
+        })
+    }
+
+    returnedPosts := make([]fiber.Map, 0, len(topPosts))
+    for _, post := range topPosts {
+        returnedPosts = append(returnedPosts, fiber.Map{
+            "id":            post.ID,
+            "title":         post.Title,
+            "created_at":    post.CreatedAt,
+            "metoo_count":   post.MetooCount,
+            "comment_count": post.CommentCount,
+            "has_solution":  post.HasSolution,
+            "user": fiber.Map{
+                "id":                  post.UserID,
+                "username":            post.Username,
+                "profile_picture_url": post.ProfilePictureURL,
+            },
+        })
+    }
+
+    var synonyms []string
+    database.DB.Model(&models.TagSynonym{}).Where("tag_id = ?", tag.ID).Order("name ASC").Pluck("name", &synonyms)
+
+    response := formatTag(tag)
+    response["wiki"] = tag.Wiki
+    response["synonyms"] = synonyms
+    response["top_posts"] = returnedPosts
+
+    // Add the follow state if the user is authenticated
+    response["is_followed"] = false
+    response["is_ignored"] = false
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok && userIDFloat > 0 {
+        var preference models.TagPreference
+        if err := database.DB.Where("user_id = ? AND tag_id = ?", uint(userIDFloat), tag.ID).First(&preference).Error; err == nil {
+            response["is_followed"] = preference.Type == models.TagPreferenceFollow
+            response["is_ignored"] = preference.Type == models.TagPreferenceIgnore
+        }
+    }
+
+    return c.JSON(response)
+}
+
+// UpdateTag updates the description and wiki of a tag (moderators and admins only)
+func UpdateTag(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is allowed to edit tags
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" && user.Role != "moderator" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to edit tags",
+        })
+    }
+
+    // Only update fields that are provided
+    var tagUpdate struct {
+        Description *string `json:"description"`
+        Wiki        *string `json:"wiki"`
+    }
+    if err := c.BodyParser(&tagUpdate); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    var tag models.Tag
+    if err := database.DB.Where("name = ?", tagParam(c)).First(&tag).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Tag not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag",
+        })
+    }
+
+    if tagUpdate.Description != nil {
+        description := strings.TrimSpace(*tagUpdate.Description)
+        if len(description) > maxTagDescriptionLength {
+            return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+                "error": "Description must be at most 500 characters",
+            })
+        }
+        tag.Description = description
+    }
+    if tagUpdate.Wiki != nil {
+        tag.Wiki = *tagUpdate.Wiki
+    }
+
+    if err := database.DB.Save(&tag).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update tag",
+        })
+    }
+
+    response := formatTag(tag)
+    response["wiki"] = tag.Wiki
+    return c.JSON(response)
+}
+
+// tagErrorStatus maps tagging errors to HTTP status codes
+func tagErrorStatus(err error) int {
+    switch err {
+    case tagging.ErrTagNotFound:
+        return fiber.StatusNotFound
+    case tagging.ErrSameTag, tagging.ErrSynonymIsTag:
+        return fiber.StatusBadRequest
+    case tagging.ErrSynonymExists:
+        return fiber.StatusConflict
+    default:
+        return fiber.StatusInternalServerError
+    }
+}
+
+// GetTagSynonyms lists the synonyms of a tag
+func GetTagSynonyms(c *fiber.Ctx) error {
+    var tag models.Tag
+    if err := database.DB.Where("name = ?", tagParam(c)).First(&tag).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Tag not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag",
+        })
+    }
+
+    var synonyms []models.TagSynonym
+    if err := database.DB.Where("tag_id = ?", tag.ID).Order("name ASC").Find(&synonyms).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve synonyms",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "tag":      tag.Name,
+        "synonyms": synonyms,
+    })
+}
+
+// AddTagSynonym maps a synonym to a tag (moderators and admins only)
+func AddTagSynonym(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is allowed to manage synonyms
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" && user.Role != "moderator" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage tag synonyms",
+        })
+    }
+
+    var synonymRequest struct {
+        Synonym string `json:"synonym"`
+    }
+    if err := c.BodyParser(&synonymRequest); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+    if tagging.Normalize(synonymRequest.Synonym) == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Synonym is required",
+        })
+    }
+
+    synonym, err := tagging.AddSynonym(database.DB, synonymRequest.Synonym, tagParam(c))
+    if err != nil {
+        return c.Status(tagErrorStatus(err)).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    events.Publish(events.Event{Type: events.SynonymsChanged, UserID: userID, Detail: tagParam(c)})
+
+    return c.JSON(synonym)
+}
+
+// DeleteTagSynonym removes a synonym mapping (moderators and admins only)
+func DeleteTagSynonym(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is allowed to manage synonyms
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" && user.Role != "moderator" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage tag synonyms",
+        })
+    }
+
+    synonymName, err := url.PathUnescape(c.Params("synonym"))
+    if err != nil {
+        synonymName = c.Params("synonym")
+    }
+
+    result := database.DB.
+        Where("name = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)", tagging.Normalize(synonymName), tagParam(c)).
+        Delete(&models.TagSynonym{})
+    if result.Error != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete synonym",
+        })
+    }
+    if result.RowsAffected == 0 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Synonym not found",
+        })
+    }
+
+    events.Publish(events.Event{Type: events.SynonymsChanged, UserID: userID, Detail: tagParam(c)})
+
+    return c.JSON(fiber.Map{
+        "message": "Synonym deleted successfully",
+    })
+}
+
+// MergeTags rewrites all posts from a source tag to a target tag and turns
+// the source into a synonym of the target (admins only)
+func MergeTags(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to merge tags",
+        })
+    }
+
+    var mergeRequest struct {
+        Source string `json:"source"`
+        Target string `json:"target"`
+    }
+    if err := c.BodyParser(&mergeRequest); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+    if tagging.Normalize(mergeRequest.Source) == "" || tagging.Normalize(mergeRequest.Target) == "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Source and target tags are required",
+        })
+    }
+
+    rewritten, err := tagging.Merge(database.DB, mergeRequest.Source, mergeRequest.Target)
+    if err != nil {
+        return c.Status(tagErrorStatus(err)).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    // The search index, cached responses and webhooks follow the rewritten posts
+    for _, postID := range rewritten {
+        events.Publish(events.Event{Type: events.PostUpdated, PostID: postID, UserID: userID})
+    }
+    events.Publish(events.Event{Type: events.SynonymsChanged, UserID: userID, Detail: tagging.Normalize(mergeRequest.Target)})
+
+    return c.JSON(fiber.Map{
+        "source":          tagging.Normalize(mergeRequest.Source),
+        "target":          tagging.Normalize(mergeRequest.Target),
+        "rewritten_posts": len(rewritten),
+    })
+}
+
+// GetTagPreferences lists the tags the authenticated user follows and ignores
+func GetTagPreferences(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    type PreferenceResult struct {
+        models.Tag
+        Type string
+    }
+    var preferences []PreferenceResult
+    if err := database.DB.Table("tags").
+        Select("tags.*, tag_preferences.type").
+        Joins("JOIN tag_preferences ON tag_preferences.tag_id = tags.id").
+        Where("tag_preferences.user_id = ?", userID).
+        Order("tags.name ASC").
+        Scan(&preferences).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag preferences",
+        })
+    }
+
+    followed := []fiber.Map{}
+    ignored := []fiber.Map{}
+    for _, preference := range preferences {
+        if preference.Type == models.TagPreferenceFollow {
+            followed = append(followed, formatTag(preference.Tag))
+        } else {
+            ignored = append(ignored, formatTag(preference.Tag))
+        }
+    }
+
+    return c.JSON(fiber.Map{
+        "followed": followed,
+        "ignored":  ignored,
+    })
+}
+
+// ToggleFollowTag handles following or unfollowing a tag
+func ToggleFollowTag(c *fiber.Ctx) error {
+    return toggleTagPreference(c, models.TagPreferenceFollow)
+}
+
+// ToggleIgnoreTag handles ignoring or unignoring a tag
+func ToggleIgnoreTag(c *fiber.Ctx) error {
+    return toggleTagPreference(c, models.TagPreferenceIgnore)
+}
+
+// toggleTagPreference sets the user's preference for a tag to the given type, or removes it
+// if it already is of that type. Following an ignored tag (and vice versa) switches the type.
+func toggleTagPreference(c *fiber.Ctx, preferenceType string) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if tag exists
+    tag, err := findTag(tagParam(c))
+    if err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Tag not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag",
+        })
+    }
+
+    // Check if a preference already exists
+    var preference models.TagPreference
+    result := database.DB.Where("user_id = ? AND tag_id = ?", userID, tag.ID).First(&preference)
+
+    if result.Error == nil {
+        if preference.Type == preferenceType {
+            // Same preference, so remove it
+            if err := database.DB.Delete(&preference).Error; err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "error": "Failed to remove tag preference",
+                })
+            }
+            preference.Type = ""
+        } else {
+            // Switch between follow and ignore
+            preference.Type = preferenceType
+            if err := database.DB.Save(&preference).Error; err != nil {
+                return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                    "error": "Failed to update tag preference",
+                })
+            }
+        }
+    } else if result.Error == gorm.ErrRecordNotFound {
+        // Preference doesn't exist, so create it
+        preference = models.TagPreference{
+            UserID: userID,
+            TagID:  tag.ID,
+            Type:   preferenceType,
+        }
+        if err := database.DB.Create(&preference).Error; err != nil {
+            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+                "error": "Failed to add tag preference",
+            })
+        }
+    } else {
+        // Some other database error
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Database error",
+        })
+    }
+
+    events.Publish(events.Event{Type: events.TagPreferenceChanged, UserID: userID, Detail: tag.Name})
+
+    return c.JSON(fiber.Map{
+        "tag":         tag.Name,
+        "is_followed": preference.Type == models.TagPreferenceFollow,
+        "is_ignored":  preference.Type == models.TagPreferenceIgnore,
+    })
+}
+
+// GetTagExperts ranks the users who answer posts with a tag best, from accepted
+// solutions and comment likes within a time window (week, month or all)
+func GetTagExperts(c *fiber.Ctx) error {
+    tag, err := findTag(tagParam(c))
+    if err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Tag not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve tag",
+        })
+    }
+
+    window := c.Query("window", "all")
+    since, err := tagging.WindowStart(window)
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": err.Error(),
+        })
+    }
+
+    limit := c.QueryInt("limit", 10)
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 10
+    }
+
+    experts, err := tagging.Experts(database.DB, tag.Name, since, limit)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to rank experts",
+        })
+    }
+
+    returnedExperts := make([]fiber.Map, 0, len(experts))
+    for i, expert := range experts {
+        returnedExperts = append(returnedExperts, fiber.Map{
+            "rank":      i + 1,
+            "score":     expert.Score,
+            "solutions": expert.Solutions,
+            "likes":     expert.Likes,
+            "user": fiber.Map{
+                "id":                  expert.UserID,
+                "username":            expert.Username,
+                "profile_picture_url": expert.ProfilePictureURL,
+            },
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "tag":     tag.Name,
+        "window":  window,
+        "experts": returnedExperts,
+    })
+}
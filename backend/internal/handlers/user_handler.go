@@ -5,8 +5,11 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"techquire-backend/internal/database"
+	"techquire-backend/internal/events"
 	"techquire-backend/internal/models"
+	"techquire-backend/internal/tagging"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -23,8 +26,13 @@ type publicUserData struct {
     Reputation        int    `json:"reputation"`
     NumberOfPosts     int    `json:"number_of_posts"`
     NumberOfSolutions int    `json:"number_of_solutions"`
+    TopTags           []tagging.TagScore `json:"top_tags"` // Tags the user is most helpful in
+    Badges            []userBadgeData    `json:"badges"`
 }
 
+// topTagsLimit is the number of top tags shown on a user profile
+const topTagsLimit = 5
+
 // GetUser returns the public user data for a given username
 func GetUser(c *fiber.Ctx) error {
     username := c.Params("username")
@@ -51,9 +59,63 @@ func GetUser(c *fiber.Ctx) error {
         NumberOfSolutions: user.NumberOfSolutions,
     }
 
+    // Add the tags the user scores highest in
+    topTags, err := tagging.TopTags(database.DB, user.ID, topTagsLimit)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to get top tags",
+        })
+    }
+    if topTags == nil {
+        topTags = []tagging.TagScore{}
+    }
+    publicUser.TopTags = topTags
+
+    // Add the badges the user was awarded
+    awardedBadges, err := userBadges(user.ID)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to get badges",
+        })
+    }
+    publicUser.Badges = awardedBadges
+
     return c.JSON(publicUser)
 }
 
+// AutocompleteUsers returns users whose username starts with the given prefix,
+// used to suggest @mentions while typing
+func AutocompleteUsers(c *fiber.Ctx) error {
+    prefix := strings.TrimPrefix(strings.TrimSpace(c.Query("prefix")), "@")
+    limit := c.QueryInt("limit", 10)
+
+    if limit > 20 {
+        limit = 20
+    }
+    if limit < 1 {
+        limit = 10
+    }
+
+    if prefix == "" {
+        return c.JSON([]fiber.Map{})
+    }
+
+    // Escape LIKE wildcards so they are matched literally
+    escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(strings.ToLower(prefix))
+
+    var users []models.User
+    if err := database.DB.Where("LOWER(username) LIKE ?", escaped+"%").
+        Order("reputation DESC, username ASC").
+        Limit(limit).
+        Find(&users).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to search users",
+        })
+    }
+
+    return c.JSON(formatMentions(users))
+}
+
 // UpdateUsername updates the username of the authenticated user
 func UpdateUsername(c *fiber.Ctx) error {
     // Get user ID from the JWT token
@@ -102,6 +164,8 @@ func UpdateUsername(c *fiber.Ctx) error {
         })
     }
 
+    events.Publish(events.Event{Type: events.UserUpdated, UserID: userID, SubjectID: userID})
+
     return c.JSON(fiber.Map{
         "username": newUsername.Username,
     })
@@ -252,6 +316,10 @@ func UpdateUserRole(c *fiber.Ctx) error {
         })
     }
 
+    if userToUpdate.Role != userRole.Role {
+        events.Publish(events.Event{Type: events.RoleChanged, UserID: userID, SubjectID: userToUpdate.ID, Detail: userRole.Role})
+    }
+
     return c.JSON(fiber.Map{
         "message": "Role updated successfully",
     })
@@ -350,6 +418,7 @@ func UpdateProfilePicture(c *fiber.Ctx) error {
     }
 
     log.Printf("Profile picture updated successfully for user %d: %s", userID, profilePictureURL)
+    events.Publish(events.Event{Type: events.UserUpdated, UserID: userID, SubjectID: userID})
     return c.JSON(fiber.Map{
         "profile_picture_url": profilePictureURL,
     })
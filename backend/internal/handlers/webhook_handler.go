@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/database"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/webhooks"
+)
+
+// webhookRequest is the body of CreateWebhook and UpdateWebhook. Fields left out of an
+// update keep their value.
+type webhookRequest struct {
+    URL      *string  `json:"url"`
+    Events   []string `json:"events"`
+    IsActive *bool    `json:"is_active"`
+}
+
+// validateWebhook checks the URL and events of a webhook and returns an error message
+// for the client, or "" when they are valid
+func validateWebhook(webhookURL string, events []string) string {
+    parsed, err := url.Parse(webhookURL)
+    if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+        return "URL must be an absolute http or https URL"
+    }
+    if len(events) == 0 {
+        return "At least one event is required, one of: " + strings.Join(webhooks.EventNames(), ", ")
+    }
+    for _, event := range events {
+        if !webhooks.ValidEvent(event) {
+            return "Unknown event " + event + ", expected one of: " + strings.Join(webhooks.EventNames(), ", ")
+        }
+    }
+    return ""
+}
+
+// formatWebhook formats a webhook for API responses, without its secret
+func formatWebhook(webhook models.Webhook) fiber.Map {
+    return fiber.Map{
+        "id":            webhook.ID,
+        "url":           webhook.URL,
+        "events":        webhook.Events,
+        "is_active":     webhook.IsActive,
+        "failure_count": webhook.FailureCount,
+        "disabled_at":   webhook.DisabledAt,
+        "created_by_id": webhook.CreatedByID,
+        "created_at":    webhook.CreatedAt,
+        "updated_at":    webhook.UpdatedAt,
+    }
+}
+
+// GetWebhooks lists the webhooks (admins only)
+func GetWebhooks(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage webhooks",
+        })
+    }
+
+    var hooks []models.Webhook
+    if err := database.DB.Order("id ASC").Find(&hooks).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve webhooks",
+        })
+    }
+
+    returnedWebhooks := make([]fiber.Map, 0, len(hooks))
+    for _, webhook := range hooks {
+        returnedWebhooks = append(returnedWebhooks, formatWebhook(webhook))
+    }
+    return c.JSON(fiber.Map{
+        "webhooks": returnedWebhooks,
+        "events":   webhooks.EventNames(),
+    })
+}
+
+// CreateWebhook creates a webhook (admins only). The signing secret is generated and only
+// returned in this response.
+func CreateWebhook(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage webhooks",
+        })
+    }
+
+    var request webhookRequest
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+    if request.URL == nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "URL is required",
+        })
+    }
+    if message := validateWebhook(*request.URL, request.Events); message != "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": message,
+        })
+    }
+
+    secret, err := webhooks.NewSecret()
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to generate webhook secret",
+        })
+    }
+    webhook := models.Webhook{
+        URL:         *request.URL,
+        Secret:      secret,
+        Events:      pq.StringArray(request.Events),
+        IsActive:    true,
+        CreatedByID: userID,
+    }
+    if err := database.DB.Create(&webhook).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to create webhook",
+        })
+    }
+
+    response := formatWebhook(webhook)
+    response["secret"] = secret
+    return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// UpdateWebhook updates the URL, events or state of a webhook (admins only). Enabling a
+// webhook that was disabled for failing resets its failure count.
+func UpdateWebhook(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage webhooks",
+        })
+    }
+
+    // Get webhook ID from URL parameter
+    webhookID, err := c.ParamsInt("webhook_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid webhook ID",
+        })
+    }
+
+    var webhook models.Webhook
+    if err := database.DB.First(&webhook, webhookID).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Webhook not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve webhook",
+        })
+    }
+
+    var request webhookRequest
+    if err := c.BodyParser(&request); err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid request body",
+        })
+    }
+
+    if request.URL != nil {
+        webhook.URL = *request.URL
+    }
+    if request.Events != nil {
+        webhook.Events = pq.StringArray(request.Events)
+    }
+    if message := validateWebhook(webhook.URL, webhook.Events); message != "" {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": message,
+        })
+    }
+    if request.IsActive != nil {
+        if *request.IsActive && !webhook.IsActive {
+            webhook.FailureCount = 0
+            webhook.DisabledAt = nil
+        }
+        webhook.IsActive = *request.IsActive
+    }
+
+    if err := database.DB.Save(&webhook).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to update webhook",
+        })
+    }
+
+    return c.JSON(formatWebhook(webhook))
+}
+
+// DeleteWebhook deletes a webhook and its delivery log (admins only)
+func DeleteWebhook(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage webhooks",
+        })
+    }
+
+    // Get webhook ID from URL parameter
+    webhookID, err := c.ParamsInt("webhook_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid webhook ID",
+        })
+    }
+
+    var deleted int64
+    if err := database.DB.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Where("webhook_id = ?", webhookID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+            return err
+        }
+        result := tx.Delete(&models.Webhook{}, webhookID)
+        deleted = result.RowsAffected
+        return result.Error
+    }); err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to delete webhook",
+        })
+    }
+    if deleted == 0 {
+        return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+            "error": "Webhook not found",
+        })
+    }
+
+    return c.JSON(fiber.Map{
+        "message": "Webhook deleted successfully",
+    })
+}
+
+// GetWebhookDeliveries lists the deliveries of a webhook, newest first (admins only)
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage webhooks",
+        })
+    }
+
+    // Get webhook ID from URL parameter
+    webhookID, err := c.ParamsInt("webhook_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid webhook ID",
+        })
+    }
+
+    // Parse pagination and filter parameters
+    page := c.QueryInt("page", 1)
+    limit := c.QueryInt("limit", 20)
+
+    // Validate pagination
+    if limit > 50 {
+        limit = 50
+    }
+    if limit < 1 {
+        limit = 20
+    }
+    if page < 1 {
+        page = 1
+    }
+
+    offset := (page - 1) * limit
+
+    query := database.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+    if status := c.Query("status"); status != "" {
+        query = query.Where("status = ?", status)
+    }
+
+    // Count total deliveries for pagination
+    var totalDeliveries int64
+    if err := query.Session(&gorm.Session{}).Count(&totalDeliveries).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to count deliveries",
+        })
+    }
+
+    deliveries := []models.WebhookDelivery{}
+    if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve deliveries",
+        })
+    }
+
+    // Calculate pagination metadata
+    totalPages := (int(totalDeliveries) + limit - 1) / limit
+    hasMore := page < totalPages
+
+    return c.JSON(fiber.Map{
+        "deliveries": deliveries,
+        "pagination": fiber.Map{
+            "page":             page,
+            "limit":            limit,
+            "total_deliveries": totalDeliveries,
+            "total_pages":      totalPages,
+            "has_more":         hasMore,
+        },
+    })
+}
+
+// RedeliverWebhookDelivery queues a new delivery of the payload of an earlier one, e.g.
+// after fixing the endpoint (admins only)
+func RedeliverWebhookDelivery(c *fiber.Ctx) error {
+    // Get user ID from the JWT token
+    var userID uint
+    if userIDFloat, ok := c.Locals("user_id").(float64); ok {
+        userID = uint(userIDFloat)
+    } else {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Authentication required",
+        })
+    }
+
+    // Check if the user is an admin
+    var user models.User
+    if err := database.DB.First(&user, userID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve user",
+        })
+    }
+    if user.Role != "admin" {
+        return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+            "error": "You are not authorized to manage webhooks",
+        })
+    }
+
+    // Get webhook and delivery IDs from URL parameters
+    webhookID, err := c.ParamsInt("webhook_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid webhook ID",
+        })
+    }
+    deliveryID, err := c.ParamsInt("delivery_id")
+    if err != nil {
+        return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+            "error": "Invalid delivery ID",
+        })
+    }
+
+    var delivery models.WebhookDelivery
+    if err := database.DB.Where("id = ? AND webhook_id = ?", deliveryID, webhookID).First(&delivery).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+                "error": "Delivery not found",
+            })
+        }
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve delivery",
+        })
+    }
+
+    var webhook models.Webhook
+    if err := database.DB.First(&webhook, webhookID).Error; err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to retrieve webhook",
+        })
+    }
+    if !webhook.IsActive {
+        return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+            "error": "Webhook is disabled, enable it before redelivering",
+        })
+    }
+
+    redelivery, err := webhooks.Redeliver(database.DB, delivery)
+    if err != nil {
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Failed to queue redelivery",
+        })
+    }
+
+    return c.Status(fiber.StatusAccepted).JSON(redelivery)
+}
@@ -0,0 +1,185 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text body and optionally an HTML alternative
+type Message struct {
+    To      string
+    Subject string
+    Body    string
+    HTML    string            // HTML version of Body, optional
+    Headers map[string]string // Extra headers, e.g. List-Unsubscribe
+}
+
+// Mailer sends emails
+type Mailer interface {
+    Send(msg Message) error
+}
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string // Sender address
+}
+
+// Send sends a message, authenticating with PLAIN auth when a username is configured
+func (m *SMTPMailer) Send(msg Message) error {
+    var auth smtp.Auth
+    if m.Username != "" {
+        auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+    }
+
+    body := Format(m.From, msg)
+    return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{msg.To}, body)
+}
+
+// headerBreaks turns line breaks into spaces. Header values include user content, e.g.
+// post titles in subjects, and a line break would start a header or the body.
+var headerBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// headerValue makes text safe as a header value: on one line, and encoded as UTF-8
+// encoded-words (RFC 2047) when it isn't plain ASCII
+func headerValue(text string) string {
+    return mime.QEncoding.Encode("utf-8", headerBreaks.Replace(text))
+}
+
+// Format renders a message in the Internet Message Format. Messages with an HTML version
+// are sent as multipart/alternative, so clients without HTML show the plain text.
+func Format(from string, msg Message) []byte {
+    lines := []string{
+        "From: TechQuire <" + headerBreaks.Replace(from) + ">",
+        "To: " + headerBreaks.Replace(msg.To),
+        "Subject: " + headerValue(msg.Subject),
+        "Date: " + time.Now().Format(time.RFC1123Z),
+        "MIME-Version: 1.0",
+    }
+    // Sorted, so the output doesn't depend on map order
+    names := make([]string, 0, len(msg.Headers))
+    for name := range msg.Headers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        lines = append(lines, headerBreaks.Replace(name)+": "+headerValue(msg.Headers[name]))
+    }
+
+    if msg.HTML == "" {
+        lines = append(lines, "Content-Type: text/plain; charset=UTF-8", "", msg.Body)
+        return []byte(strings.Join(lines, "\r\n"))
+    }
+
+    boundary := newBoundary()
+    lines = append(lines,
+        "Content-Type: multipart/alternative; boundary=\""+boundary+"\"",
+        "",
+        "--"+boundary,
+        "Content-Type: text/plain; charset=UTF-8",
+        "",
+        msg.Body,
+        "--"+boundary,
+        "Content-Type: text/html; charset=UTF-8",
+        "",
+        msg.HTML,
+        "--"+boundary+"--",
+        "",
+    )
+    return []byte(strings.Join(lines, "\r\n"))
+}
+
+// newBoundary returns a random multipart boundary
+func newBoundary() string {
+    buf := make([]byte, 16)
+    rand.Read(buf)
+    return "techquire-" + hex.EncodeToString(buf)
+}
+
+// LogMailer logs emails instead of sending them, for development
+type LogMailer struct{}
+
+// Send logs the message, the plain text version only
+func (LogMailer) Send(msg Message) error {
+    log.Printf("[MAIL] To: %s, Subject: %s\n%s", msg.To, msg.Subject, msg.Body)
+    return nil
+}
+
+// FileMailer writes emails to .eml files in a directory instead of sending them, for
+// testing and for inspecting the rendered emails
+type FileMailer struct {
+    Dir string
+}
+
+// Send writes the message to a new file named after the time and recipient
+func (m FileMailer) Send(msg Message) error {
+    if err := os.MkdirAll(m.Dir, 0755); err != nil {
+        return err
+    }
+    recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+    name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), recipient)
+    return os.WriteFile(filepath.Join(m.Dir, name), Format("noreply@localhost", msg), 0644)
+}
+
+// FromEnv returns a FileMailer when MAIL_DIR is set, an SMTP mailer when SMTP_HOST is set
+// and a LogMailer otherwise
+func FromEnv() Mailer {
+    if dir := os.Getenv("MAIL_DIR"); dir != "" {
+        log.Printf("[MAIL] Emails will be written to %s", dir)
+        return FileMailer{Dir: dir}
+    }
+
+    host := os.Getenv("SMTP_HOST")
+    if host == "" {
+        log.Println("[MAIL] SMTP_HOST is not set, emails will be logged")
+        return LogMailer{}
+    }
+
+    port := os.Getenv("SMTP_PORT")
+    if port == "" {
+        port = "587"
+    }
+    from := os.Getenv("SMTP_FROM")
+    if from == "" {
+        from = "noreply@" + host
+    }
+    return &SMTPMailer{
+        Host:     host,
+        Port:     port,
+        Username: os.Getenv("SMTP_USERNAME"),
+        Password: os.Getenv("SMTP_PASSWORD"),
+        From:     from,
+    }
+}
+
+// Link returns the frontend URL of a path, for links in emails. The frontend is
+// configured with FRONTEND_URL.
+func Link(path string) string {
+    base := os.Getenv("FRONTEND_URL")
+    if base == "" {
+        base = "http://localhost:5173"
+    }
+    return strings.TrimRight(base, "/") + path
+}
+
+// APILink returns the URL of a backend path, for links in emails that act without the
+// frontend, e.g. unsubscribing. The backend is configured with API_URL.
+func APILink(path string) string {
+    base := os.Getenv("API_URL")
+    if base == "" {
+        base = "http://localhost:8080"
+    }
+    return strings.TrimRight(base, "/") + path
+}
@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"bytes"
+	"mime"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestFormatHeaders(t *testing.T) {
+    tests := []struct {
+        name        string
+        msg         Message
+        wantSubject string
+        wantRaw     string // Subject line as written, "" to skip
+    }{
+        {"plain subject", Message{To: "a@example.com", Subject: "New comment on Go modules"}, "New comment on Go modules", "Subject: New comment on Go modules"},
+        {"non-ASCII subject", Message{To: "a@example.com", Subject: "Neuer Kommentar: Größe"}, "Neuer Kommentar: Größe", "Subject: =?utf-8?q?"},
+        {"injected header", Message{To: "a@example.com", Subject: "Hi\r\nBcc: victim@example.com"}, "Hi Bcc: victim@example.com", ""},
+        {"injected body", Message{To: "a@example.com", Subject: "Hi\n\nclick here"}, "Hi  click here", ""},
+        {"injected recipient", Message{To: "a@example.com\r\nCc: b@example.com", Subject: "Hi"}, "Hi", ""},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            raw := Format("noreply@example.com", tt.msg)
+            parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+            if err != nil {
+                t.Fatalf("ReadMessage() error = %v\n%s", err, raw)
+            }
+            for _, name := range []string{"Bcc", "Cc"} {
+                if parsed.Header.Get(name) != "" {
+                    t.Errorf("%s header was injected:\n%s", name, raw)
+                }
+            }
+            subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if subject != tt.wantSubject {
+                t.Errorf("Subject = %q, want %q", subject, tt.wantSubject)
+            }
+            if tt.wantRaw != "" && !strings.Contains(string(raw), tt.wantRaw) {
+                t.Errorf("message doesn't contain %q:\n%s", tt.wantRaw, raw)
+            }
+            body := new(bytes.Buffer)
+            body.ReadFrom(parsed.Body)
+            if strings.Contains(body.String(), "click here") {
+                t.Errorf("body was injected:\n%s", raw)
+            }
+        })
+    }
+}
+
+func TestFormatExtraHeaders(t *testing.T) {
+    raw := Format("noreply@example.com", Message{
+        To:      "a@example.com",
+        Subject: "Digest",
+        Body:    "text",
+        HTML:    "<p>text</p>",
+        Headers: map[string]string{
+            "List-Unsubscribe": "<https://api.example.com/digests/unsubscribe?user=1>",
+            "X-Post-Title":     "Tïtle\r\nBcc: victim@example.com",
+        },
+    })
+    parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if got := parsed.Header.Get("List-Unsubscribe"); got != "<https://api.example.com/digests/unsubscribe?user=1>" {
+        t.Errorf("List-Unsubscribe = %q", got)
+    }
+    if parsed.Header.Get("Bcc") != "" {
+        t.Errorf("Bcc header was injected:\n%s", raw)
+    }
+    title, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("X-Post-Title"))
+    if title != "Tïtle Bcc: victim@example.com" {
+        t.Errorf("X-Post-Title = %q", title)
+    }
+    if !strings.HasPrefix(parsed.Header.Get("Content-Type"), "multipart/alternative") {
+        t.Errorf("Content-Type = %q", parsed.Header.Get("Content-Type"))
+    }
+}
@@ -0,0 +1,76 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// DefaultExcerptLength is the excerpt length used in API responses
+const DefaultExcerptLength = 200
+
+var (
+    // Same dialect as the frontend renderer (react-markdown with remark-gfm).
+    // Raw HTML in the source is omitted by goldmark and the output is sanitized on top of that.
+    renderer = goldmark.New(
+        goldmark.WithExtensions(extension.GFM),
+    )
+
+    // htmlPolicy allows the usual user generated content plus what GFM produces
+    htmlPolicy = newHTMLPolicy()
+
+    // textPolicy strips every tag, leaving escaped text
+    textPolicy = bluemonday.StrictPolicy()
+
+    whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+func newHTMLPolicy() *bluemonday.Policy {
+    policy := bluemonday.UGCPolicy()
+    // Language hints for syntax highlighting on the client
+    policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+    // Task list checkboxes
+    policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+    policy.AllowAttrs("checked", "disabled").OnElements("input")
+    policy.AddTargetBlankToFullyQualifiedLinks(true)
+    return policy
+}
+
+// Render converts Markdown source to sanitized HTML
+func Render(source string) string {
+    var buf bytes.Buffer
+    if err := renderer.Convert([]byte(source), &buf); err != nil {
+        // Fall back to the escaped source rather than failing the request
+        return "<p>" + html.EscapeString(source) + "</p>"
+    }
+    return htmlPolicy.Sanitize(buf.String())
+}
+
+// PlainText converts Markdown source to plain text with collapsed whitespace
+func PlainText(source string) string {
+    stripped := textPolicy.Sanitize(Render(source))
+    text := html.UnescapeString(stripped)
+    return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// Excerpt returns a plain-text excerpt of the Markdown source of at most maxLen characters,
+// cut at a word boundary when possible
+func Excerpt(source string, maxLen int) string {
+    text := PlainText(source)
+    if utf8.RuneCountInString(text) <= maxLen {
+        return text
+    }
+
+    runes := []rune(text)
+    cut := string(runes[:maxLen])
+    if i := strings.LastIndex(cut, " "); i > len(cut)/2 {
+        cut = cut[:i]
+    }
+    return strings.TrimRight(cut, " .,;:") + "…"
+}
@@ -0,0 +1,110 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRender(t *testing.T) {
+    tests := []struct {
+        name    string
+        source  string
+        want    []string // Parts of the output
+        notWant []string // Parts that must not be in the output
+    }{
+        {"paragraph", "Hello *world*", []string{"<p>Hello <em>world</em></p>"}, nil},
+        {"script block", "<script>alert(1)</script>\n\nafter", []string{"<p>after</p>"}, []string{"<script", "alert"}},
+        // Inline tags are dropped, their content stays as text
+        {"inline script", "a <script>alert(1)</script> b", []string{"alert(1)"}, []string{"<script"}},
+        {"image with onerror", "<img src=x onerror=alert(1)>", nil, []string{"<img", "onerror"}},
+        {"markdown image with data URL", "![i](data:image/png;base64,AAAA)", nil, []string{"data:"}},
+        {"javascript link", "[click](javascript:alert(1))", []string{"click"}, []string{"href", "javascript:"}},
+        {"data link", "[click](data:text/html;base64,PHNjcmlwdD4=)", []string{"click"}, []string{"href", "data:"}},
+        {"raw HTML block", "<div onclick=\"x()\">\n<b>raw</b>\n</div>", nil, []string{"<div", "onclick", "<b>", "raw"}},
+        {"raw inline HTML", "a <b onmouseover=\"x()\">bold</b> c", []string{"bold"}, []string{"<b", "onmouseover"}},
+        {"raw form input", `<input type="text" value="x">`, nil, []string{"<input"}},
+        {"external link", "[docs](https://example.com)", []string{`href="https://example.com"`, `rel="nofollow noopener"`, `target="_blank"`}, nil},
+        {"relative link", "[post](/post/1)", []string{`href="/post/1"`}, []string{"target="}},
+        {"code language", "```go\nfmt.Println()\n```", []string{`<code class="language-go">`}, nil},
+        {"code language with symbols", "```c++\nx\n```", []string{`<code class="language-c++">`}, nil},
+        {"code language with quotes", "```go\" onclick=\"x()\nx\n```", []string{"<code>"}, []string{"onclick", "class="}},
+        {"task list", "- [x] done\n- [ ] todo", []string{
+            `<input checked="" disabled="" type="checkbox">`,
+            `<input disabled="" type="checkbox">`,
+        }, nil},
+        {"table", "| a | b |\n|---|---|\n| 1 | 2 |", []string{"<table>", "<th>a</th>", "<td>2</td>"}, nil},
+        {"strikethrough", "~~gone~~", []string{"<del>gone</del>"}, nil},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := Render(tt.source)
+            for _, part := range tt.want {
+                if !strings.Contains(got, part) {
+                    t.Errorf("Render(%q) = %q, want it to contain %q", tt.source, got, part)
+                }
+            }
+            for _, part := range tt.notWant {
+                if strings.Contains(got, part) {
+                    t.Errorf("Render(%q) = %q, want no %q", tt.source, got, part)
+                }
+            }
+        })
+    }
+}
+
+func TestPlainText(t *testing.T) {
+    tests := []struct {
+        source string
+        want   string
+    }{
+        {"# Title\n\nSome **bold** and `code`.", "Title Some bold and code."},
+        {"line one\nline two\n\n\nline three", "line one line two line three"},
+        {"Fish &amp; chips < 5 €", "Fish & chips < 5 €"},
+        {"<script>alert(1)</script>\n\nvisible", "visible"},
+        {"- [x] done\n- [ ] todo", "done todo"},
+        {"[link text](https://example.com)", "link text"},
+        {"", ""},
+    }
+
+    for _, tt := range tests {
+        if got := PlainText(tt.source); got != tt.want {
+            t.Errorf("PlainText(%q) = %q, want %q", tt.source, got, tt.want)
+        }
+    }
+}
+
+func TestExcerpt(t *testing.T) {
+    tests := []struct {
+        name   string
+        source string
+        maxLen int
+        want   string
+    }{
+        {"short", "Short text", 20, "Short text"},
+        {"exact length", "12345", 5, "12345"},
+        {"word boundary", "The quick brown fox jumps", 18, "The quick brown…"},
+        {"trailing punctuation", "Hello, world and more", 12, "Hello, world…"},
+        {"no boundary", "Supercalifragilistic", 10, "Supercalif…"},
+        {"multi-byte", "héllo wörld ünïcode", 8, "héllo…"},
+        {"multi-byte without spaces", "日本語のテキストです", 4, "日本語の…"},
+        {"emoji", "😀😀😀😀😀😀", 3, "😀😀😀…"},
+        {"markdown is stripped first", "**Bold** text here", 9, "Bold text…"},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := Excerpt(tt.source, tt.maxLen)
+            if got != tt.want {
+                t.Errorf("Excerpt(%q, %d) = %q, want %q", tt.source, tt.maxLen, got, tt.want)
+            }
+            if !utf8.ValidString(got) {
+                t.Errorf("Excerpt(%q, %d) = %q, not valid UTF-8", tt.source, tt.maxLen, got)
+            }
+            if length := utf8.RuneCountInString(strings.TrimSuffix(got, "…")); length > tt.maxLen {
+                t.Errorf("Excerpt(%q, %d) has %d characters", tt.source, tt.maxLen, length)
+            }
+        })
+    }
+}
@@ -29,40 +29,44 @@ func JWTProtected() fiber.Handler {
             })
         }
         
-        token := authHeader[7:]
-        
-        // Get JWT secret
-        secret := os.Getenv("JWT_SECRET")
-        if secret == "" {
-            log.Println("ERROR: JWT_SECRET is not set")
-            return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-                "error": "Server misconfiguration",
-            })
-        }
-        
-        claims := jwt.MapClaims{}
-        t, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-            return []byte(secret), nil
+        return authenticate(c, authHeader[7:])
+    }
+}
+
+// authenticate validates a token and stores its user_id in the context for the next
+// handler, answering with an error when the token isn't accepted
+func authenticate(c *fiber.Ctx, token string) error {
+    // Get JWT secret
+    secret := os.Getenv("JWT_SECRET")
+    if secret == "" {
+        log.Println("ERROR: JWT_SECRET is not set")
+        return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+            "error": "Server misconfiguration",
+        })
+    }
+    
+    claims := jwt.MapClaims{}
+    t, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+        return []byte(secret), nil
+    })
+    
+    if err != nil || !t.Valid {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Invalid or expired token",
+        })
+    }
+    
+    userID := claims["user_id"]
+    if userID == nil {
+        return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+            "error": "Invalid token: missing user_id",
         })
-        
-        if err != nil || !t.Valid {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "error": "Invalid or expired token",
-            })
-        }
-        
-        userID := claims["user_id"]
-        if userID == nil {
-            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-                "error": "Invalid token: missing user_id",
-            })
-        }
-        
-        // Store user_id in context
-        c.Locals("user_id", userID)
-        
-        return c.Next()
     }
+    
+    // Store user_id in context
+    c.Locals("user_id", userID)
+    
+    return c.Next()
 }
 
 // OptionalAuth middleware that attempts to authenticate but doesn't require it
@@ -105,4 +109,25 @@ func OptionalAuth() fiber.Handler {
         
         return c.Next()
     }
-}
\ No newline at end of file
+}
+
+// StreamAuth middleware that protects event streams with JWT authentication
+// Browsers can't set headers on an EventSource, so the token may also be passed in the
+// access_token query parameter
+func StreamAuth() fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        // Get the token from the Authorization header or the query string
+        token := c.Query("access_token")
+        if authHeader := c.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+            token = authHeader[7:]
+        }
+        
+        if token == "" {
+            return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+                "error": "Authentication required",
+            })
+        }
+        
+        return authenticate(c, token)
+    }
+}
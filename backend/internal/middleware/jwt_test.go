@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func signedToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+    token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return token
+}
+
+func TestTokenAuth(t *testing.T) {
+    t.Setenv("JWT_SECRET", "secret")
+    valid := signedToken(t, "secret", jwt.MapClaims{"user_id": 7})
+    forged := signedToken(t, "other", jwt.MapClaims{"user_id": 7})
+    noUser := signedToken(t, "secret", jwt.MapClaims{"sub": "7"})
+
+    tests := []struct {
+        name       string
+        middleware fiber.Handler
+        url        string
+        header     string
+        wantStatus int
+    }{
+        {"header", JWTProtected(), "/", "Bearer " + valid, fiber.StatusOK},
+        {"no header", JWTProtected(), "/", "", fiber.StatusUnauthorized},
+        {"not bearer", JWTProtected(), "/", "Basic " + valid, fiber.StatusUnauthorized},
+        {"forged", JWTProtected(), "/", "Bearer " + forged, fiber.StatusUnauthorized},
+        {"missing user_id", JWTProtected(), "/", "Bearer " + noUser, fiber.StatusUnauthorized},
+        {"query token only for streams", JWTProtected(), "/?access_token=" + valid, "", fiber.StatusUnauthorized},
+        {"stream header", StreamAuth(), "/", "Bearer " + valid, fiber.StatusOK},
+        {"stream query", StreamAuth(), "/?access_token=" + valid, "", fiber.StatusOK},
+        {"stream header wins", StreamAuth(), "/?access_token=" + valid, "Bearer " + forged, fiber.StatusUnauthorized},
+        {"stream without token", StreamAuth(), "/", "", fiber.StatusUnauthorized},
+        {"stream missing user_id", StreamAuth(), "/?access_token=" + noUser, "", fiber.StatusUnauthorized},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            app := fiber.New()
+            app.Get("/", tt.middleware, func(c *fiber.Ctx) error {
+                if userID, ok := c.Locals("user_id").(float64); !ok || userID != 7 {
+                    t.Errorf("user_id = %v, want 7", c.Locals("user_id"))
+                }
+                return c.SendStatus(fiber.StatusOK)
+            })
+
+            req := httptest.NewRequest("GET", tt.url, nil)
+            if tt.header != "" {
+                req.Header.Set("Authorization", tt.header)
+            }
+            resp, err := app.Test(req)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if resp.StatusCode != tt.wantStatus {
+                t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+            }
+        })
+    }
+}
+
+func TestTokenAuthWithoutSecret(t *testing.T) {
+    t.Setenv("JWT_SECRET", "")
+    app := fiber.New()
+    app.Get("/", StreamAuth(), func(c *fiber.Ctx) error {
+        return c.SendStatus(fiber.StatusOK)
+    })
+    resp, err := app.Test(httptest.NewRequest("GET", "/?access_token=x", nil))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if resp.StatusCode != fiber.StatusInternalServerError {
+        t.Errorf("status = %d, want %d", resp.StatusCode, fiber.StatusInternalServerError)
+    }
+}
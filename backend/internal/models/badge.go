@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// UserBadge is a badge awarded to a user. Badges earned per tag are awarded once for
+// every tag, the others have an empty tag and are awarded once.
+type UserBadge struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;uniqueIndex:idx_user_badges_award" json:"user_id"`
+    Badge     string    `gorm:"not null;uniqueIndex:idx_user_badges_award;index" json:"badge"` // Badge of the catalog
+    Tag       string    `gorm:"not null;default:'';uniqueIndex:idx_user_badges_award" json:"tag"`
+    AwardedAt time.Time `gorm:"not null" json:"awarded_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Chat platforms
+const (
+    ChatSlack      = "slack"
+    ChatMattermost = "mattermost"
+)
+
+// ChatAccount links a Slack or Mattermost user to the TechQuire account slash commands
+// act as
+type ChatAccount struct {
+    ID           uint      `gorm:"primaryKey" json:"id"`
+    Platform     string    `gorm:"not null;uniqueIndex:idx_chat_accounts_chat_user" json:"platform"` // "slack" or "mattermost"
+    TeamID       string    `gorm:"not null;uniqueIndex:idx_chat_accounts_chat_user" json:"team_id"`
+    ChatUserID   string    `gorm:"not null;uniqueIndex:idx_chat_accounts_chat_user" json:"chat_user_id"`
+    ChatUserName string    `json:"chat_user_name"`
+    UserID       uint      `gorm:"not null;index" json:"user_id"`
+    CreatedAt    time.Time `json:"created_at"`
+    UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Digest frequencies
+const (
+    DigestOff    = "off"
+    DigestDaily  = "daily"
+    DigestWeekly = "weekly"
+)
+
+// DigestPreference is a user's choice of digest emails. Users without one get none.
+type DigestPreference struct {
+    ID         uint       `gorm:"primaryKey" json:"id"`
+    UserID     uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+    Frequency  string     `gorm:"not null;default:'off'" json:"frequency"` // "off", "daily" or "weekly"
+    LastSentAt *time.Time `json:"last_sent_at"` // End of the period covered by the last digest
+    // Signed into the unsubscribe links, bumping it revokes the links sent before
+    UnsubscribeVersion int       `gorm:"not null;default:0" json:"-"`
+    CreatedAt          time.Time `json:"created_at"`
+    UpdatedAt          time.Time `json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Mention records a user being mentioned with @username in a post or comment
+type Mention struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;index" json:"user_id"` // Mentioned user
+    AuthorID  uint      `gorm:"not null" json:"author_id"` // Author of the post or comment containing the mention
+    PostID    uint      `gorm:"not null;index" json:"post_id"`
+    CommentID *uint     `gorm:"index" json:"comment_id"` // Nil when the mention is in the post itself
+    IsRead    bool      `gorm:"default:false" json:"is_read"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
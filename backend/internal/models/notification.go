@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Notification types
+const (
+    NotificationComment        = "comment"         // New comment on the user's post
+    NotificationWatchedComment = "watched_comment" // New comment on a post the user watches
+    NotificationSolution       = "solution"        // The user's comment was marked as the solution
+    NotificationReaction       = "reaction"        // Reaction on the user's comment
+    NotificationRoleChange     = "role_change"     // The user's role was changed
+    NotificationBadge          = "badge"           // The user was awarded a badge
+)
+
+// Notification tells a user about activity concerning them
+type Notification struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;index:idx_notifications_user_read" json:"user_id"` // Notified user
+    Type      string    `gorm:"not null" json:"type"`
+    ActorID   *uint     `json:"actor_id"` // User who caused the notification, if any
+    PostID    *uint     `gorm:"index" json:"post_id"`
+    CommentID *uint     `gorm:"index" json:"comment_id"`
+    Detail    string    `json:"detail"` // Reaction type, new role or badge
+    IsRead    bool      `gorm:"default:false;index:idx_notifications_user_read" json:"is_read"`
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
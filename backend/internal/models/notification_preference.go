@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Event types users set notification channels for, besides the notification types. They
+// are delivered by the digest, the saved search alerts and the mentions inbox rather than
+// as notifications.
+const (
+    NotificationMention       = "mention"        // The user was mentioned in a post or comment
+    NotificationMetooSolution = "metoo_solution" // Solution on a post the user has the same problem as
+    NotificationFollowedTag   = "followed_tag"   // New post in a followed tag
+    NotificationSavedSearch   = "saved_search"   // New post matching a saved search with alerts
+)
+
+// NotificationPreference is the set of channels a user receives an event type on. Event
+// types without one use the defaults of the preferences package.
+type NotificationPreference struct {
+    ID        uint           `gorm:"primaryKey" json:"id"`
+    UserID    uint           `gorm:"not null;uniqueIndex:idx_notification_preferences_user_type" json:"user_id"`
+    EventType string         `gorm:"not null;uniqueIndex:idx_notification_preferences_user_type" json:"event_type"`
+    Channels  pq.StringArray `gorm:"type:text[]" json:"channels"` // "in_app", "email", "digest", empty for none
+    CreatedAt time.Time      `json:"created_at"`
+    UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// NotificationMute silences every notification about a post, or about posts with a tag
+type NotificationMute struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;uniqueIndex:idx_notification_mutes_user_post;uniqueIndex:idx_notification_mutes_user_tag" json:"user_id"`
+    PostID    *uint     `gorm:"uniqueIndex:idx_notification_mutes_user_post" json:"post_id"` // Set for post mutes
+    TagID     *uint     `gorm:"uniqueIndex:idx_notification_mutes_user_tag" json:"tag_id"` // Set for tag mutes
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// QuietHours is the daily period in which a user receives no emails, e.g. 22:00 to 07:00
+// in their time zone. Other channels aren't affected, notifications wait in the app.
+type QuietHours struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;uniqueIndex" json:"user_id"`
+    Start     string    `gorm:"column:start_time;not null" json:"start"` // "HH:MM"
+    End       string    `gorm:"column:end_time;not null" json:"end"` // "HH:MM", before Start when spanning midnight
+    TimeZone  string    `gorm:"not null;default:'UTC'" json:"time_zone"` // IANA name, e.g. "Europe/Berlin"
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
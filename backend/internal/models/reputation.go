@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Reputation event reasons
+const (
+    ReputationLike            = "like"             // Like on the user's comment
+    ReputationLikeRemoved     = "like_removed"     // A like was taken back
+    ReputationDislike         = "dislike"          // Dislike on the user's comment
+    ReputationDislikeRemoved  = "dislike_removed"  // A dislike was taken back
+    ReputationSolution        = "solution"         // The user's comment was marked as the solution
+    ReputationSolutionRemoved = "solution_removed" // The solution mark was taken back
+    ReputationCommentDeleted  = "comment_deleted"  // Reverses what a deleted comment earned
+)
+
+// ReputationEvent is an entry of the reputation ledger. A user's reputation is the sum of
+// their entries, User.Reputation only caches it.
+type ReputationEvent struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;index" json:"user_id"` // User whose reputation changes
+    Delta     int       `gorm:"not null" json:"delta"`
+    Reason    string    `gorm:"not null" json:"reason"`
+    PostID    *uint     `gorm:"index" json:"post_id"`
+    CommentID *uint     `gorm:"index" json:"comment_id"`
+    ActorID   *uint     `json:"actor_id"` // User whose action caused the change
+    CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// SavedSearch is a named set of GetPosts filters a user can run again or be alerted about
+type SavedSearch struct {
+    ID            uint      `gorm:"primaryKey" json:"id"`
+    UserID        uint      `gorm:"not null;index" json:"user_id"`
+    Name          string    `gorm:"not null" json:"name"`
+    Params        string    `gorm:"not null" json:"-"` // GetPosts query parameters, URL encoded
+    AlertsEnabled bool      `gorm:"default:false" json:"alerts_enabled"`
+    EmailAlerts   bool      `gorm:"default:false" json:"email_alerts"`
+    LastPostID    uint      `gorm:"default:0" json:"-"` // Newest post already matched by the alert worker
+    CreatedAt     time.Time `json:"created_at"`
+    UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SearchAlert records a new post matching one of a user's saved searches
+type SearchAlert struct {
+    ID            uint      `gorm:"primaryKey" json:"id"`
+    UserID        uint      `gorm:"not null;index" json:"user_id"`
+    SavedSearchID uint      `gorm:"not null;uniqueIndex:idx_search_alerts_search_post" json:"saved_search_id"`
+    PostID        uint      `gorm:"not null;uniqueIndex:idx_search_alerts_search_post" json:"post_id"`
+    IsRead        bool      `gorm:"default:false" json:"is_read"`
+    CreatedAt     time.Time `json:"created_at"`
+}
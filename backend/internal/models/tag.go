@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Tag is a normalized post tag with its documentation and usage statistics.
+// Posts reference tags by their normalized name in Post.Tags.
+type Tag struct {
+    ID          uint      `gorm:"primaryKey" json:"id"`
+    Name        string    `gorm:"uniqueIndex;not null" json:"name"` // Normalized name, e.g. "golang"
+    Description string    `json:"description"` // Short summary shown next to the tag
+    Wiki        string    `gorm:"type:text" json:"wiki"` // Longer Markdown documentation
+    UsageCount  int       `gorm:"default:0;index" json:"usage_count"` // Number of posts using the tag
+    CreatedAt   time.Time `json:"created_at"`
+    UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TagSynonym maps an alternative tag name to its canonical tag, e.g. "k8s" to "kubernetes".
+// Synonyms are replaced by the canonical name wherever tags are entered or filtered.
+type TagSynonym struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    Name      string    `gorm:"uniqueIndex;not null" json:"name"` // Normalized synonym name
+    TagID     uint      `gorm:"not null;index" json:"tag_id"` // Foreign key to the canonical Tag
+    CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Tag preference types
+const (
+    TagPreferenceFollow = "follow"
+    TagPreferenceIgnore = "ignore"
+)
+
+// TagPreference records a user following or ignoring a tag for their personal feed
+type TagPreference struct {
+    ID        uint      `gorm:"primaryKey" json:"id"`
+    UserID    uint      `gorm:"not null;uniqueIndex:idx_tag_preferences_user_tag" json:"user_id"`
+    TagID     uint      `gorm:"not null;uniqueIndex:idx_tag_preferences_user_tag" json:"tag_id"`
+    Type      string    `gorm:"not null" json:"type"` // "follow" or "ignore"
+    CreatedAt time.Time `json:"created_at"`
+    UpdatedAt time.Time `json:"updated_at"`
+}
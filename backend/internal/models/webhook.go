@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook delivery statuses
+const (
+    DeliveryPending   = "pending"
+    DeliverySucceeded = "succeeded"
+    DeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint that receives events as signed JSON POST requests
+type Webhook struct {
+    ID           uint           `gorm:"primaryKey" json:"id"`
+    URL          string         `gorm:"not null" json:"url"`
+    Secret       string         `gorm:"not null" json:"-"` // Key of the HMAC-SHA256 signatures
+    Events       pq.StringArray `gorm:"type:text[]" json:"events"` // Subscribed events, e.g. "post.created"
+    IsActive     bool           `gorm:"default:true" json:"is_active"`
+    FailureCount int            `gorm:"default:0" json:"failure_count"` // Consecutive failed deliveries
+    DisabledAt   *time.Time     `json:"disabled_at"` // When it was disabled for failing, nil otherwise
+    CreatedByID  uint           `gorm:"not null" json:"created_by_id"`
+    CreatedAt    time.Time      `json:"created_at"`
+    UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// WebhookDelivery is an event sent or to be sent to a webhook, with the outcome of the
+// last attempt
+type WebhookDelivery struct {
+    ID             uint       `gorm:"primaryKey" json:"id"`
+    WebhookID      uint       `gorm:"not null;index" json:"webhook_id"`
+    Event          string     `gorm:"not null" json:"event"`
+    Payload        string     `gorm:"type:text;not null" json:"payload"` // JSON request body
+    Status         string     `gorm:"not null;default:'pending';index:idx_webhook_deliveries_due" json:"status"` // "pending", "succeeded" or "failed"
+    Attempts       int        `gorm:"default:0" json:"attempts"`
+    NextAttemptAt  *time.Time `gorm:"index:idx_webhook_deliveries_due" json:"next_attempt_at"` // Nil once finished
+    ResponseStatus int        `json:"response_status"` // HTTP status of the last attempt, 0 without response
+    ResponseBody   string     `gorm:"type:text" json:"response_body"` // Start of the last response body
+    Error          string     `json:"error"` // Error of the last attempt
+    DeliveredAt    *time.Time `json:"delivered_at"`
+    CreatedAt      time.Time  `json:"created_at"`
+    UpdatedAt      time.Time  `json:"updated_at"`
+}
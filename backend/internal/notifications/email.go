@@ -0,0 +1,66 @@
+package notifications
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/badges"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+)
+
+// email sends a notification to its user by email
+func email(db *gorm.DB, m mailer.Mailer, notification models.Notification) error {
+    var user models.User
+    if err := db.Select("id", "email", "username").First(&user, notification.UserID).Error; err != nil {
+        return err
+    }
+
+    actorName := "Someone"
+    if notification.ActorID != nil {
+        var actor models.User
+        if err := db.Select("id", "username").First(&actor, *notification.ActorID).Error; err == nil {
+            actorName = actor.Username
+        }
+    }
+
+    var post models.Post
+    link := mailer.Link("/")
+    if notification.PostID != nil {
+        if err := db.Select("id", "title").First(&post, *notification.PostID).Error; err != nil {
+            return err
+        }
+        link = mailer.Link(fmt.Sprintf("/post/%d", post.ID))
+    }
+
+    var subject string
+    switch notification.Type {
+    case models.NotificationComment:
+        subject = fmt.Sprintf("%s commented on your post \"%s\"", actorName, post.Title)
+    case models.NotificationWatchedComment:
+        subject = fmt.Sprintf("%s commented on \"%s\"", actorName, post.Title)
+    case models.NotificationSolution:
+        subject = fmt.Sprintf("Your comment on \"%s\" was marked as the solution", post.Title)
+    case models.NotificationReaction:
+        subject = fmt.Sprintf("%s reacted to your comment on \"%s\"", actorName, post.Title)
+    case models.NotificationRoleChange:
+        subject = fmt.Sprintf("Your role was changed to %s", notification.Detail)
+    case models.NotificationBadge:
+        name := notification.Detail
+        if rule, found := badges.Find(notification.Detail); found {
+            name = rule.Name
+        }
+        subject = fmt.Sprintf("You were awarded the %s badge", name)
+    default:
+        subject = "New activity on TechQuire"
+    }
+
+    body := fmt.Sprintf("Hi %s,\n\n%s.\n\n%s\n\nYou can choose which emails you receive in your notification preferences.\n",
+        user.Username, subject, link)
+    return m.Send(mailer.Message{
+        To:      user.Email,
+        Subject: subject,
+        Body:    body,
+    })
+}
@@ -0,0 +1,184 @@
+package notifications
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+	"techquire-backend/internal/preferences"
+)
+
+// Sync creates notifications for the events that concern other users: comments on their
+// own or watched posts, their comments being marked as solution or reacted to, changes
+// to their role and badges they were awarded. Users who asked for it are also emailed
+// through m.
+func Sync(db *gorm.DB, m mailer.Mailer) {
+    events.Subscribe(func(event events.Event) {
+        var err error
+        switch event.Type {
+        case events.CommentCreated:
+            err = notifyComment(db, m, event)
+        case events.SolutionMarked:
+            err = notifyCommentAuthor(db, m, event, models.NotificationSolution)
+        case events.ReactionAdded:
+            err = notifyCommentAuthor(db, m, event, models.NotificationReaction)
+        case events.RoleChanged:
+            err = Create(db, m, models.Notification{
+                UserID:  event.SubjectID,
+                Type:    models.NotificationRoleChange,
+                ActorID: actor(event),
+                Detail:  event.Detail,
+            })
+        case events.BadgeAwarded:
+            err = Create(db, m, models.Notification{
+                UserID: event.SubjectID,
+                Type:   models.NotificationBadge,
+                Detail: event.Detail,
+            })
+        }
+        if err != nil {
+            log.Printf("[NOTIFICATIONS] Failed to notify about %s (post %d, comment %d): %v", event.Type, event.PostID, event.CommentID, err)
+        }
+    })
+}
+
+// Create delivers notifications on the channels their users chose: stored for the app,
+// emailed, or both. Users aren't notified about their own actions.
+func Create(db *gorm.DB, m mailer.Mailer, notifications ...models.Notification) error {
+    for _, notification := range notifications {
+        if notification.ActorID != nil && *notification.ActorID == notification.UserID {
+            continue
+        }
+
+        userPreferences, err := preferences.Load(db, notification.UserID)
+        if err != nil {
+            return err
+        }
+        event := preferences.Event{Type: notification.Type}
+        if notification.PostID != nil {
+            event.PostID = *notification.PostID
+        }
+        now := time.Now()
+
+        sendEmail, err := userPreferences.ShouldNotify(event, preferences.ChannelEmail, now)
+        if err != nil {
+            return err
+        }
+        if sendEmail && m != nil {
+            // Sent in the background, the mail server mustn't hold up the request
+            go func(notification models.Notification) {
+                if err := email(db, m, notification); err != nil {
+                    log.Printf("[NOTIFICATIONS] Failed to email user %d about %s: %v", notification.UserID, notification.Type, err)
+                }
+            }(notification)
+        }
+
+        inApp, err := userPreferences.ShouldNotify(event, preferences.ChannelInApp, now)
+        if err != nil {
+            return err
+        }
+        if !inApp {
+            continue
+        }
+        if err := db.Create(&notification).Error; err != nil {
+            return err
+        }
+
+        created := events.Event{
+            Type:      events.NotificationCreated,
+            SubjectID: notification.UserID,
+            Detail:    notification.Type,
+        }
+        if notification.ActorID != nil {
+            created.UserID = *notification.ActorID
+        }
+        if notification.PostID != nil {
+            created.PostID = *notification.PostID
+        }
+        if notification.CommentID != nil {
+            created.CommentID = *notification.CommentID
+        }
+        events.Publish(created)
+    }
+    return nil
+}
+
+// actor returns the user who caused an event, nil for the system
+func actor(event events.Event) *uint {
+    if event.UserID == 0 {
+        return nil
+    }
+    userID := event.UserID
+    return &userID
+}
+
+// notifyComment notifies the author and the watchers of a post about a new comment. The
+// author is notified once, even when watching their own post.
+func notifyComment(db *gorm.DB, m mailer.Mailer, event events.Event) error {
+    var post models.Post
+    if err := db.Select("id", "user_id").First(&post, event.PostID).Error; err != nil {
+        return err
+    }
+
+    var watcherIDs []uint
+    if err := db.Model(&models.UserWatchlist{}).
+        Where("post_id = ? AND user_id <> ?", post.ID, post.UserID).
+        Pluck("user_id", &watcherIDs).Error; err != nil {
+        return err
+    }
+
+    postID, commentID := post.ID, event.CommentID
+    notifications := []models.Notification{{
+        UserID:    post.UserID,
+        Type:      models.NotificationComment,
+        ActorID:   actor(event),
+        PostID:    &postID,
+        CommentID: &commentID,
+    }}
+    for _, watcherID := range watcherIDs {
+        notifications = append(notifications, models.Notification{
+            UserID:    watcherID,
+            Type:      models.NotificationWatchedComment,
+            ActorID:   actor(event),
+            PostID:    &postID,
+            CommentID: &commentID,
+        })
+    }
+    return Create(db, m, notifications...)
+}
+
+// notifyCommentAuthor notifies the author of a comment. Repeated reactions by the same
+// user, e.g. toggling a like, don't pile up while the notification is unread.
+func notifyCommentAuthor(db *gorm.DB, m mailer.Mailer, event events.Event, notificationType string) error {
+    var comment models.Comment
+    if err := db.Select("id", "post_id", "user_id").First(&comment, event.CommentID).Error; err != nil {
+        return err
+    }
+
+    if notificationType == models.NotificationReaction {
+        var unread int64
+        if err := db.Model(&models.Notification{}).
+            Where("user_id = ? AND type = ? AND comment_id = ? AND actor_id = ? AND is_read = ?",
+                comment.UserID, notificationType, comment.ID, event.UserID, false).
+            Count(&unread).Error; err != nil {
+            return err
+        }
+        if unread > 0 {
+            return nil
+        }
+    }
+
+    postID, commentID := comment.PostID, comment.ID
+    return Create(db, m, models.Notification{
+        UserID:    comment.UserID,
+        Type:      notificationType,
+        ActorID:   actor(event),
+        PostID:    &postID,
+        CommentID: &commentID,
+        Detail:    event.Detail,
+    })
+}
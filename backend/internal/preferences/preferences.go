@@ -0,0 +1,217 @@
+package preferences
+
+import (
+	"fmt"
+	"time"
+	// Quiet hours are set in IANA time zones, the runtime image has no zone database
+	_ "time/tzdata"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/models"
+)
+
+// Delivery channels
+const (
+    ChannelInApp  = "in_app" // Notification in the app, pushed live
+    ChannelEmail  = "email"  // Immediate email
+    ChannelDigest = "digest" // Part of the daily or weekly digest, if the user gets one
+)
+
+// Channels lists the delivery channels
+var Channels = []string{ChannelInApp, ChannelEmail, ChannelDigest}
+
+// defaultChannels are the channels of the event types a user hasn't set. Saved search
+// alerts are emailed by default, each search has its own switch for it.
+var defaultChannels = map[string][]string{
+    models.NotificationComment:        {ChannelInApp, ChannelDigest},
+    models.NotificationWatchedComment: {ChannelInApp, ChannelDigest},
+    models.NotificationSolution:       {ChannelInApp, ChannelDigest},
+    models.NotificationReaction:       {ChannelInApp},
+    models.NotificationRoleChange:     {ChannelInApp},
+    models.NotificationBadge:          {ChannelInApp},
+    models.NotificationMetooSolution:  {ChannelDigest},
+    models.NotificationFollowedTag:    {ChannelDigest},
+    models.NotificationSavedSearch:    {ChannelInApp, ChannelEmail},
+    models.NotificationMention:        {ChannelInApp},
+}
+
+// EventTypes lists the event types users set channels for
+var EventTypes = []string{
+    models.NotificationComment,
+    models.NotificationWatchedComment,
+    models.NotificationSolution,
+    models.NotificationReaction,
+    models.NotificationRoleChange,
+    models.NotificationBadge,
+    models.NotificationMetooSolution,
+    models.NotificationFollowedTag,
+    models.NotificationSavedSearch,
+    models.NotificationMention,
+}
+
+// Event is something a user may be notified about
+type Event struct {
+    Type   string // One of EventTypes
+    PostID uint   // Post the event is about, 0 for none
+}
+
+// Preferences are the notification settings of a user
+type Preferences struct {
+    db         *gorm.DB
+    UserID     uint
+    Channels   map[string][]string // Channels by event type, defaults included
+    MutedPosts map[uint]bool
+    MutedTags  map[string]bool // Names of muted tags
+    QuietHours *models.QuietHours
+}
+
+// ShouldNotify reports whether a user is to be told about an event on a channel now.
+// Every delivery goes through it, so the user's settings apply everywhere.
+func ShouldNotify(db *gorm.DB, userID uint, event Event, channel string) (bool, error) {
+    preferences, err := Load(db, userID)
+    if err != nil {
+        return false, err
+    }
+    return preferences.ShouldNotify(event, channel, time.Now())
+}
+
+// Load loads the preferences of a user, for deliveries that check many events of the
+// same user
+func Load(db *gorm.DB, userID uint) (*Preferences, error) {
+    preferences := &Preferences{
+        db:         db,
+        UserID:     userID,
+        Channels:   make(map[string][]string, len(EventTypes)),
+        MutedPosts: make(map[uint]bool),
+        MutedTags:  make(map[string]bool),
+    }
+    for eventType, channels := range defaultChannels {
+        preferences.Channels[eventType] = channels
+    }
+
+    var set []models.NotificationPreference
+    if err := db.Where("user_id = ?", userID).Find(&set).Error; err != nil {
+        return nil, err
+    }
+    for _, preference := range set {
+        preferences.Channels[preference.EventType] = preference.Channels
+    }
+
+    var mutes []models.NotificationMute
+    if err := db.Where("user_id = ? AND post_id IS NOT NULL", userID).Find(&mutes).Error; err != nil {
+        return nil, err
+    }
+    for _, mute := range mutes {
+        preferences.MutedPosts[*mute.PostID] = true
+    }
+
+    var tagNames []string
+    if err := db.Table("notification_mutes").
+        Joins("JOIN tags ON tags.id = notification_mutes.tag_id").
+        Where("notification_mutes.user_id = ?", userID).
+        Pluck("tags.name", &tagNames).Error; err != nil {
+        return nil, err
+    }
+    for _, name := range tagNames {
+        preferences.MutedTags[name] = true
+    }
+
+    var quietHours []models.QuietHours
+    if err := db.Where("user_id = ?", userID).Limit(1).Find(&quietHours).Error; err != nil {
+        return nil, err
+    }
+    if len(quietHours) > 0 {
+        preferences.QuietHours = &quietHours[0]
+    }
+    return preferences, nil
+}
+
+// ShouldNotify reports whether the user is to be told about an event on a channel at a
+// time: the channel is enabled for the event type, neither the post nor its tags are
+// muted, and emails are outside quiet hours
+func (p *Preferences) ShouldNotify(event Event, channel string, at time.Time) (bool, error) {
+    if !contains(p.Channels[event.Type], channel) {
+        return false, nil
+    }
+    if channel == ChannelEmail && p.InQuietHours(at) {
+        return false, nil
+    }
+    if event.PostID == 0 {
+        return true, nil
+    }
+    if p.MutedPosts[event.PostID] {
+        return false, nil
+    }
+
+    if len(p.MutedTags) > 0 {
+        var posts []models.Post
+        if err := p.db.Select("id", "tags").Where("id = ?", event.PostID).Limit(1).Find(&posts).Error; err != nil {
+            return false, err
+        }
+        if len(posts) == 0 {
+            return false, nil
+        }
+        for _, tag := range posts[0].Tags {
+            if p.MutedTags[tag] {
+                return false, nil
+            }
+        }
+    }
+    return true, nil
+}
+
+// InQuietHours reports whether a time falls in the user's quiet hours
+func (p *Preferences) InQuietHours(at time.Time) bool {
+    if p.QuietHours == nil {
+        return false
+    }
+    location, err := time.LoadLocation(p.QuietHours.TimeZone)
+    if err != nil {
+        location = time.UTC
+    }
+    start, err := ParseClock(p.QuietHours.Start)
+    if err != nil {
+        return false
+    }
+    end, err := ParseClock(p.QuietHours.End)
+    if err != nil {
+        return false
+    }
+
+    local := at.In(location)
+    minute := local.Hour()*60 + local.Minute()
+    if start <= end {
+        return minute >= start && minute < end
+    }
+    // Spanning midnight, e.g. 22:00 to 07:00
+    return minute >= start || minute < end
+}
+
+// ParseClock parses a time of day "HH:MM" into minutes after midnight
+func ParseClock(value string) (int, error) {
+    parsed, err := time.Parse("15:04", value)
+    if err != nil {
+        return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+    }
+    return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// ValidEventType reports whether users can set channels for an event type
+func ValidEventType(eventType string) bool {
+    return contains(EventTypes, eventType)
+}
+
+// ValidChannel reports whether a channel exists
+func ValidChannel(channel string) bool {
+    return contains(Channels, channel)
+}
+
+func contains(values []string, value string) bool {
+    for _, candidate := range values {
+        if candidate == value {
+            return true
+        }
+    }
+    return false
+}
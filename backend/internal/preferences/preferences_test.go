@@ -0,0 +1,130 @@
+package preferences
+
+import (
+	"testing"
+	"time"
+
+	"techquire-backend/internal/models"
+)
+
+func TestParseClock(t *testing.T) {
+    tests := []struct {
+        value   string
+        want    int
+        wantErr bool
+    }{
+        {"00:00", 0, false},
+        {"07:30", 450, false},
+        {"23:59", 1439, false},
+        {"24:00", 0, true},
+        {"12:60", 0, true},
+        {"noon", 0, true},
+        {"", 0, true},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.value, func(t *testing.T) {
+            got, err := ParseClock(tt.value)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("ParseClock(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+            }
+            if got != tt.want {
+                t.Errorf("ParseClock(%q) = %d, want %d", tt.value, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestInQuietHours(t *testing.T) {
+    utc := func(hour, minute int) time.Time {
+        return time.Date(2025, 1, 15, hour, minute, 0, 0, time.UTC)
+    }
+
+    tests := []struct {
+        name  string
+        quiet *models.QuietHours
+        at    time.Time
+        want  bool
+    }{
+        {"no quiet hours", nil, utc(3, 0), false},
+        {"inside", &models.QuietHours{Start: "09:00", End: "17:00", TimeZone: "UTC"}, utc(12, 0), true},
+        {"at the start", &models.QuietHours{Start: "09:00", End: "17:00", TimeZone: "UTC"}, utc(9, 0), true},
+        {"at the end", &models.QuietHours{Start: "09:00", End: "17:00", TimeZone: "UTC"}, utc(17, 0), false},
+        {"before", &models.QuietHours{Start: "09:00", End: "17:00", TimeZone: "UTC"}, utc(8, 59), false},
+        {"spanning midnight, late", &models.QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}, utc(23, 30), true},
+        {"spanning midnight, early", &models.QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}, utc(6, 59), true},
+        {"spanning midnight, at the end", &models.QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}, utc(7, 0), false},
+        {"spanning midnight, daytime", &models.QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"}, utc(12, 0), false},
+        {"empty range", &models.QuietHours{Start: "09:00", End: "09:00", TimeZone: "UTC"}, utc(9, 0), false},
+        // 22:30 UTC is 23:30 in Berlin in winter and 00:30 in summer
+        {"time zone", &models.QuietHours{Start: "23:00", End: "06:00", TimeZone: "Europe/Berlin"}, utc(22, 30), true},
+        {"time zone, daytime", &models.QuietHours{Start: "23:00", End: "06:00", TimeZone: "Europe/Berlin"}, utc(21, 30), false},
+        {"daylight saving time", &models.QuietHours{Start: "00:00", End: "01:00", TimeZone: "Europe/Berlin"},
+            time.Date(2025, 7, 15, 22, 30, 0, 0, time.UTC), true},
+        {"unknown time zone falls back to UTC", &models.QuietHours{Start: "09:00", End: "17:00", TimeZone: "Mars/Base"}, utc(12, 0), true},
+        {"invalid clock", &models.QuietHours{Start: "9am", End: "17:00", TimeZone: "UTC"}, utc(12, 0), false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            preferences := &Preferences{QuietHours: tt.quiet}
+            if got := preferences.InQuietHours(tt.at); got != tt.want {
+                t.Errorf("InQuietHours(%s) = %v, want %v", tt.at, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestShouldNotify(t *testing.T) {
+    night := time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC)
+    day := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+    preferences := &Preferences{
+        Channels: map[string][]string{
+            models.NotificationComment: {ChannelInApp, ChannelEmail},
+        },
+        MutedPosts: map[uint]bool{5: true},
+        QuietHours: &models.QuietHours{Start: "22:00", End: "07:00", TimeZone: "UTC"},
+    }
+
+    tests := []struct {
+        name    string
+        event   Event
+        channel string
+        at      time.Time
+        want    bool
+    }{
+        {"enabled channel", Event{Type: models.NotificationComment}, ChannelInApp, day, true},
+        {"disabled channel", Event{Type: models.NotificationComment}, ChannelDigest, day, false},
+        {"other event type", Event{Type: models.NotificationReaction}, ChannelInApp, day, false},
+        {"email outside quiet hours", Event{Type: models.NotificationComment}, ChannelEmail, day, true},
+        {"email in quiet hours", Event{Type: models.NotificationComment}, ChannelEmail, night, false},
+        {"in app in quiet hours", Event{Type: models.NotificationComment}, ChannelInApp, night, true},
+        {"muted post", Event{Type: models.NotificationComment, PostID: 5}, ChannelInApp, day, false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got, err := preferences.ShouldNotify(tt.event, tt.channel, tt.at)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if got != tt.want {
+                t.Errorf("ShouldNotify() = %v, want %v", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestEventTypesHaveDefaults(t *testing.T) {
+    for _, eventType := range EventTypes {
+        if _, ok := defaultChannels[eventType]; !ok {
+            t.Errorf("event type %s has no default channels", eventType)
+        }
+    }
+    if len(defaultChannels) != len(EventTypes) {
+        t.Errorf("%d default channel sets for %d event types", len(defaultChannels), len(EventTypes))
+    }
+    if !contains(defaultChannels[models.NotificationMention], ChannelInApp) {
+        t.Errorf("mentions are off in the app by default")
+    }
+}
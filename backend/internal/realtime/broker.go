@@ -0,0 +1,55 @@
+package realtime
+
+import (
+	"fmt"
+	"os"
+
+	"techquire-backend/internal/database"
+)
+
+// Broker carries messages between the server instances. Every published message is
+// handed to the listener of every instance, including the one that published it.
+type Broker interface {
+    Publish(message Message) error
+    // Listen starts handing the published messages to deliver
+    Listen(deliver func(Message)) error
+    Close() error
+}
+
+// Open opens the broker configured with REALTIME_BROKER: "local" (default), for a single
+// server instance, or "postgres", LISTEN/NOTIFY on the application database
+func Open() (Broker, error) {
+    switch broker := os.Getenv("REALTIME_BROKER"); broker {
+    case "", "local":
+        return NewLocalBroker(), nil
+    case "postgres":
+        return NewPostgresBroker(database.DSN(), database.DB), nil
+    default:
+        return nil, fmt.Errorf("unknown real-time broker %q", broker)
+    }
+}
+
+// LocalBroker hands messages straight to the listener of this instance
+type LocalBroker struct {
+    deliver func(Message)
+}
+
+func NewLocalBroker() *LocalBroker {
+    return &LocalBroker{}
+}
+
+func (b *LocalBroker) Publish(message Message) error {
+    if b.deliver != nil {
+        b.deliver(message)
+    }
+    return nil
+}
+
+func (b *LocalBroker) Listen(deliver func(Message)) error {
+    b.deliver = deliver
+    return nil
+}
+
+func (b *LocalBroker) Close() error {
+    return nil
+}
@@ -0,0 +1,116 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Message is an update published on a channel
+type Message struct {
+    Channel string          `json:"channel"`
+    Event   string          `json:"event"`
+    Data    json.RawMessage `json:"data"`
+}
+
+// PostChannel is the channel of the updates to a post and its comments
+func PostChannel(postID uint) string {
+    return fmt.Sprintf("post:%d", postID)
+}
+
+// UserChannel is the channel of the updates meant for a single user
+func UserChannel(userID uint) string {
+    return fmt.Sprintf("user:%d", userID)
+}
+
+// subscriptionBuffer is how many messages a subscriber may fall behind before messages
+// are dropped for it, a slow client must not hold up the others
+const subscriptionBuffer = 32
+
+// Subscription receives the messages of the channels it was opened for
+type Subscription struct {
+    C        chan Message
+    channels []string
+}
+
+// Hub fans the messages of a broker out to the subscribers of this server instance.
+// Messages are published through the broker, so subscribers on other instances sharing
+// it receive them too.
+type Hub struct {
+    broker      Broker
+    mu          sync.RWMutex
+    subscribers map[string]map[*Subscription]bool
+}
+
+// Default is the hub used by the handlers, set up at startup. Nil disables real-time
+// updates.
+var Default *Hub
+
+// NewHub creates a hub and starts listening on the broker
+func NewHub(broker Broker) (*Hub, error) {
+    hub := &Hub{
+        broker:      broker,
+        subscribers: make(map[string]map[*Subscription]bool),
+    }
+    if err := broker.Listen(hub.deliver); err != nil {
+        return nil, err
+    }
+    return hub, nil
+}
+
+// Publish publishes an event with its JSON-encoded data on a channel
+func (h *Hub) Publish(channel, event string, data interface{}) error {
+    encoded, err := json.Marshal(data)
+    if err != nil {
+        return err
+    }
+    return h.broker.Publish(Message{Channel: channel, Event: event, Data: encoded})
+}
+
+// Subscribe opens a subscription to channels
+func (h *Hub) Subscribe(channels ...string) *Subscription {
+    subscription := &Subscription{
+        C:        make(chan Message, subscriptionBuffer),
+        channels: channels,
+    }
+
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for _, channel := range channels {
+        if h.subscribers[channel] == nil {
+            h.subscribers[channel] = make(map[*Subscription]bool)
+        }
+        h.subscribers[channel][subscription] = true
+    }
+    return subscription
+}
+
+// Unsubscribe closes a subscription
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for _, channel := range subscription.channels {
+        delete(h.subscribers[channel], subscription)
+        if len(h.subscribers[channel]) == 0 {
+            delete(h.subscribers, channel)
+        }
+    }
+}
+
+// Close stops listening on the broker
+func (h *Hub) Close() error {
+    return h.broker.Close()
+}
+
+// deliver hands a message to the subscribers of its channel, dropping it for those
+// whose buffer is full
+func (h *Hub) deliver(message Message) {
+    h.mu.RLock()
+    defer h.mu.RUnlock()
+    for subscription := range h.subscribers[message.Channel] {
+        select {
+        case subscription.C <- message:
+        default:
+        }
+    }
+}
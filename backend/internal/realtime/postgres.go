@@ -0,0 +1,115 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// postgresChannel is the Postgres notification channel the messages are sent on
+const postgresChannel = "techquire_realtime"
+
+// maxPayload stays below the 8000 bytes Postgres allows for a notification payload
+const maxPayload = 7900
+
+// reconnectDelay is how long the listener waits before reconnecting after losing its
+// connection. Messages sent in between are lost, clients refetch on reconnecting.
+const reconnectDelay = 2 * time.Second
+
+// PostgresBroker sends messages with NOTIFY on the application database, so every server
+// instance connected to it receives them. Listening takes a dedicated connection, as
+// GORM's pool hands out a different one for every query.
+type PostgresBroker struct {
+    dsn    string
+    db     *gorm.DB
+    ctx    context.Context
+    cancel context.CancelFunc
+}
+
+// NewPostgresBroker creates a broker that listens on a connection to dsn and notifies
+// through db
+func NewPostgresBroker(dsn string, db *gorm.DB) *PostgresBroker {
+    ctx, cancel := context.WithCancel(context.Background())
+    return &PostgresBroker{dsn: dsn, db: db, ctx: ctx, cancel: cancel}
+}
+
+func (b *PostgresBroker) Publish(message Message) error {
+    payload, err := json.Marshal(message)
+    if err != nil {
+        return err
+    }
+    if len(payload) > maxPayload {
+        return fmt.Errorf("message of %d bytes exceeds the notification payload limit", len(payload))
+    }
+    return b.db.Exec("SELECT pg_notify(?, ?)", postgresChannel, string(payload)).Error
+}
+
+// Listen connects and starts listening in the background. Failing to connect at first
+// is an error, later connection losses are retried.
+func (b *PostgresBroker) Listen(deliver func(Message)) error {
+    conn, err := b.connect()
+    if err != nil {
+        return err
+    }
+    go b.listen(conn, deliver)
+    return nil
+}
+
+// Close stops listening
+func (b *PostgresBroker) Close() error {
+    b.cancel()
+    return nil
+}
+
+// connect opens the listening connection
+func (b *PostgresBroker) connect() (*pgx.Conn, error) {
+    conn, err := pgx.Connect(b.ctx, b.dsn)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := conn.Exec(b.ctx, "LISTEN "+postgresChannel); err != nil {
+        conn.Close(context.Background())
+        return nil, err
+    }
+    return conn, nil
+}
+
+// listen hands notifications to deliver until the broker is closed, reconnecting when
+// the connection is lost
+func (b *PostgresBroker) listen(conn *pgx.Conn, deliver func(Message)) {
+    for {
+        notification, err := conn.WaitForNotification(b.ctx)
+        if err == nil {
+            var message Message
+            if err := json.Unmarshal([]byte(notification.Payload), &message); err != nil {
+                log.Printf("[REALTIME] Ignoring malformed notification: %v", err)
+                continue
+            }
+            deliver(message)
+            continue
+        }
+
+        conn.Close(context.Background())
+        if b.ctx.Err() != nil {
+            return
+        }
+        log.Printf("[REALTIME] Lost the listening connection: %v", err)
+
+        for {
+            select {
+            case <-b.ctx.Done():
+                return
+            case <-time.After(reconnectDelay):
+            }
+            if conn, err = b.connect(); err == nil {
+                break
+            }
+            log.Printf("[REALTIME] Failed to reconnect: %v", err)
+        }
+    }
+}
@@ -0,0 +1,72 @@
+package realtime
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/models"
+)
+
+// Sync publishes application events to the channels of the posts and users they concern.
+// Messages carry what changed, not whole records: clients refetch what they display.
+func Sync(db *gorm.DB, hub *Hub) {
+    events.Subscribe(func(event events.Event) {
+        var err error
+        switch event.Type {
+        case events.PostUpdated, events.PostDeleted:
+            err = hub.Publish(PostChannel(event.PostID), string(event.Type), map[string]interface{}{
+                "post_id": event.PostID,
+            })
+        case events.CommentCreated, events.CommentDeleted:
+            err = hub.Publish(PostChannel(event.PostID), string(event.Type), map[string]interface{}{
+                "post_id":    event.PostID,
+                "comment_id": event.CommentID,
+                "user_id":    event.UserID,
+            })
+        case events.CommentUpdated, events.SolutionMarked, events.ReactionAdded, events.ReactionRemoved:
+            err = publishComment(db, hub, event)
+        case events.MetooAdded, events.MetooRemoved:
+            var metooCount int64
+            if err = db.Model(&models.MeToo{}).Where("post_id = ?", event.PostID).Count(&metooCount).Error; err == nil {
+                err = hub.Publish(PostChannel(event.PostID), string(event.Type), map[string]interface{}{
+                    "post_id":     event.PostID,
+                    "metoo_count": metooCount,
+                })
+            }
+        case events.NotificationCreated:
+            var unreadCount int64
+            if err = db.Model(&models.Notification{}).
+                Where("user_id = ? AND is_read = ?", event.SubjectID, false).
+                Count(&unreadCount).Error; err == nil {
+                err = hub.Publish(UserChannel(event.SubjectID), string(event.Type), map[string]interface{}{
+                    "type":         event.Detail,
+                    "post_id":      event.PostID,
+                    "comment_id":   event.CommentID,
+                    "actor_id":     event.UserID,
+                    "unread_count": unreadCount,
+                })
+            }
+        }
+        if err != nil {
+            log.Printf("[REALTIME] Failed to publish %s (post %d, comment %d): %v", event.Type, event.PostID, event.CommentID, err)
+        }
+    })
+}
+
+// publishComment publishes the current solution state and reaction counts of a comment
+func publishComment(db *gorm.DB, hub *Hub, event events.Event) error {
+    var comment models.Comment
+    if err := db.Select("id", "post_id", "is_solution", "likes", "dislikes").First(&comment, event.CommentID).Error; err != nil {
+        return err
+    }
+    return hub.Publish(PostChannel(comment.PostID), string(event.Type), map[string]interface{}{
+        "post_id":       comment.PostID,
+        "comment_id":    comment.ID,
+        "is_solution":   comment.IsSolution,
+        "like_count":    comment.Likes,
+        "dislike_count": comment.Dislikes,
+        "user_id":       event.UserID,
+    })
+}
@@ -0,0 +1,164 @@
+package reputation
+
+import (
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/models"
+)
+
+// Points of the reputation events
+var Points = map[string]int{
+    models.ReputationLike:            1,
+    models.ReputationLikeRemoved:     -1,
+    models.ReputationDislike:         -1,
+    models.ReputationDislikeRemoved:  1,
+    models.ReputationSolution:        10,
+    models.ReputationSolutionRemoved: -10,
+}
+
+// ReactionChange returns the reasons of the entries for changing a reaction from previous
+// to current, "like", "dislike" or "" for none
+func ReactionChange(previous, current string) []string {
+    if previous == current {
+        return nil
+    }
+    var reasons []string
+    switch previous {
+    case "like":
+        reasons = append(reasons, models.ReputationLikeRemoved)
+    case "dislike":
+        reasons = append(reasons, models.ReputationDislikeRemoved)
+    }
+    switch current {
+    case "like":
+        reasons = append(reasons, models.ReputationLike)
+    case "dislike":
+        reasons = append(reasons, models.ReputationDislike)
+    }
+    return reasons
+}
+
+// ShowsActor reports whether the history may show who caused entries for a reason.
+// Dislikes stay anonymous, and so do taken back reactions: a removed like next to a
+// dislike would give the disliker away.
+func ShowsActor(reason string) bool {
+    switch reason {
+    case models.ReputationDislike, models.ReputationDislikeRemoved, models.ReputationLikeRemoved:
+        return false
+    }
+    return true
+}
+
+// Event returns a ledger entry for a reason with its points. The post, comment and actor
+// may be 0 when they don't apply.
+func Event(userID uint, reason string, postID, commentID, actorID uint) models.ReputationEvent {
+    event := models.ReputationEvent{
+        UserID: userID,
+        Delta:  Points[reason],
+        Reason: reason,
+    }
+    if postID != 0 {
+        event.PostID = &postID
+    }
+    if commentID != 0 {
+        event.CommentID = &commentID
+    }
+    if actorID != 0 {
+        event.ActorID = &actorID
+    }
+    return event
+}
+
+// Record adds entries to the ledger and updates the reputation of their users. Run it in
+// the transaction of the change the entries are about.
+func Record(tx *gorm.DB, events ...models.ReputationEvent) error {
+    if len(events) == 0 {
+        return nil
+    }
+    if err := tx.Create(&events).Error; err != nil {
+        return err
+    }
+
+    userIDs := make([]uint, 0, len(events))
+    for _, event := range events {
+        userIDs = append(userIDs, event.UserID)
+    }
+    return Recompute(tx, userIDs...)
+}
+
+// ReverseComments adds entries cancelling what comments earned, before they are deleted.
+// The earlier entries stay, so the history still shows them.
+func ReverseComments(tx *gorm.DB, commentIDs []uint) error {
+    if len(commentIDs) == 0 {
+        return nil
+    }
+
+    var userIDs []uint
+    if err := tx.Model(&models.ReputationEvent{}).
+        Where("comment_id IN ?", commentIDs).
+        Distinct().
+        Pluck("user_id", &userIDs).Error; err != nil {
+        return err
+    }
+    if len(userIDs) == 0 {
+        return nil
+    }
+
+    if err := tx.Exec(`INSERT INTO reputation_events (user_id, delta, reason, post_id, comment_id, created_at)
+        SELECT user_id, -SUM(delta), ?, MAX(post_id), comment_id, NOW()
+        FROM reputation_events
+        WHERE comment_id IN ?
+        GROUP BY user_id, comment_id
+        HAVING SUM(delta) <> 0`, models.ReputationCommentDeleted, commentIDs).Error; err != nil {
+        return err
+    }
+    return Recompute(tx, userIDs...)
+}
+
+// Recompute sets the reputation of users to the sum of their ledger entries
+func Recompute(tx *gorm.DB, userIDs ...uint) error {
+    if len(userIDs) == 0 {
+        return nil
+    }
+    return tx.Exec(`UPDATE users SET reputation = COALESCE(
+        (SELECT SUM(delta) FROM reputation_events WHERE reputation_events.user_id = users.id), 0)
+        WHERE id IN ?`, userIDs).Error
+}
+
+// Rebuild replaces the ledger with entries derived from the current reactions and
+// solutions, and recomputes every user's reputation from it. History that no longer
+// has a source, like reactions taken back, is dropped.
+func Rebuild(db *gorm.DB) error {
+    return db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Exec("DELETE FROM reputation_events").Error; err != nil {
+            return err
+        }
+
+        // One entry per reaction, for the author of the comment
+        if err := tx.Exec(`INSERT INTO reputation_events (user_id, delta, reason, post_id, comment_id, actor_id, created_at)
+            SELECT comments.user_id,
+                CASE WHEN reactions.type = 'like' THEN ? ELSE ? END,
+                CASE WHEN reactions.type = 'like' THEN ? ELSE ? END,
+                comments.post_id, comments.id, reactions.user_id, reactions.created_at
+            FROM reactions
+            JOIN comments ON comments.id = reactions.comment_id
+            WHERE reactions.type IN ('like', 'dislike')`,
+            Points[models.ReputationLike], Points[models.ReputationDislike],
+            models.ReputationLike, models.ReputationDislike).Error; err != nil {
+            return err
+        }
+
+        // One entry per solution, marked by the author of the post as far as is known
+        if err := tx.Exec(`INSERT INTO reputation_events (user_id, delta, reason, post_id, comment_id, actor_id, created_at)
+            SELECT comments.user_id, ?, ?, comments.post_id, comments.id, posts.user_id, comments.updated_at
+            FROM comments
+            JOIN posts ON posts.id = comments.post_id
+            WHERE comments.is_solution = true`,
+            Points[models.ReputationSolution], models.ReputationSolution).Error; err != nil {
+            return err
+        }
+
+        return tx.Exec(`UPDATE users SET reputation = COALESCE(
+            (SELECT SUM(delta) FROM reputation_events WHERE reputation_events.user_id = users.id), 0)`).Error
+    })
+}
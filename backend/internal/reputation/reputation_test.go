@@ -0,0 +1,216 @@
+package reputation
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"techquire-backend/internal/models"
+)
+
+// mockDB returns a database whose statements are checked against the expectations of
+// the returned mock
+func mockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+    conn, mock, err := sqlmock.New()
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { conn.Close() })
+    db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{
+        SkipDefaultTransaction: true,
+        Logger:                 logger.Default.LogMode(logger.Silent),
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return db, mock
+}
+
+// expectationsMet fails the test when a statement the mock expected wasn't run
+func expectationsMet(t *testing.T, mock sqlmock.Sqlmock) {
+    t.Helper()
+    if err := mock.ExpectationsWereMet(); err != nil {
+        t.Error(err)
+    }
+}
+
+func TestReactionChange(t *testing.T) {
+    tests := []struct {
+        previous string
+        current  string
+        want     []string
+    }{
+        {"", "like", []string{models.ReputationLike}},
+        {"", "dislike", []string{models.ReputationDislike}},
+        {"like", "", []string{models.ReputationLikeRemoved}},
+        {"dislike", "", []string{models.ReputationDislikeRemoved}},
+        {"like", "dislike", []string{models.ReputationLikeRemoved, models.ReputationDislike}},
+        {"dislike", "like", []string{models.ReputationDislikeRemoved, models.ReputationLike}},
+        {"like", "like", nil},
+        {"", "", nil},
+    }
+
+    for _, tt := range tests {
+        got := ReactionChange(tt.previous, tt.current)
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("ReactionChange(%q, %q) = %v, want %v", tt.previous, tt.current, got, tt.want)
+        }
+    }
+}
+
+func TestReactionChangesAddUp(t *testing.T) {
+    // Liking, switching to a dislike and taking it back leaves nothing
+    total := 0
+    for _, step := range [][2]string{{"", "like"}, {"like", "dislike"}, {"dislike", ""}} {
+        for _, reason := range ReactionChange(step[0], step[1]) {
+            total += Points[reason]
+        }
+    }
+    if total != 0 {
+        t.Errorf("reputation after taking every reaction back = %d, want 0", total)
+    }
+}
+
+func TestShowsActor(t *testing.T) {
+    tests := []struct {
+        reason string
+        want   bool
+    }{
+        {models.ReputationLike, true},
+        {models.ReputationSolution, true},
+        {models.ReputationSolutionRemoved, true},
+        {models.ReputationDislike, false},
+        {models.ReputationDislikeRemoved, false},
+        {models.ReputationLikeRemoved, false},
+    }
+
+    for _, tt := range tests {
+        if got := ShowsActor(tt.reason); got != tt.want {
+            t.Errorf("ShowsActor(%q) = %v, want %v", tt.reason, got, tt.want)
+        }
+    }
+}
+
+func TestEvent(t *testing.T) {
+    event := Event(4, models.ReputationSolution, 2, 0, 9)
+    if event.UserID != 4 || event.Delta != 10 || event.Reason != models.ReputationSolution {
+        t.Errorf("Event() = %+v, want 10 points for user 4", event)
+    }
+    if event.PostID == nil || *event.PostID != 2 || event.CommentID != nil || event.ActorID == nil || *event.ActorID != 9 {
+        t.Errorf("Event() = %+v, want post 2, no comment and actor 9", event)
+    }
+}
+
+func TestRecord(t *testing.T) {
+    db, mock := mockDB(t)
+    mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reputation_events"`)).
+        WithArgs(4, 1, models.ReputationLike, 2, 3, 9, sqlmock.AnyArg(), 5, -1, models.ReputationDislike, 2, 3, 9, sqlmock.AnyArg()).
+        WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+    mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET reputation`)).
+        WithArgs(4, 5).
+        WillReturnResult(sqlmock.NewResult(0, 2))
+
+    if err := Record(db,
+        Event(4, models.ReputationLike, 2, 3, 9),
+        Event(5, models.ReputationDislike, 2, 3, 9),
+    ); err != nil {
+        t.Fatal(err)
+    }
+    expectationsMet(t, mock)
+}
+
+func TestRecordNothing(t *testing.T) {
+    db, mock := mockDB(t)
+    if err := Record(db); err != nil {
+        t.Fatal(err)
+    }
+    expectationsMet(t, mock)
+}
+
+func TestRecordFailure(t *testing.T) {
+    db, mock := mockDB(t)
+    mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "reputation_events"`)).
+        WillReturnError(errors.New("unavailable"))
+
+    // The reputation isn't recomputed from a ledger that wasn't written
+    if err := Record(db, Event(4, models.ReputationLike, 2, 3, 9)); err == nil {
+        t.Error("Record() = nil, want the insert error")
+    }
+    expectationsMet(t, mock)
+}
+
+func TestReverseComments(t *testing.T) {
+    db, mock := mockDB(t)
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "user_id" FROM "reputation_events" WHERE comment_id IN ($1,$2)`)).
+        WithArgs(3, 8).
+        WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(4).AddRow(5))
+    mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO reputation_events (user_id, delta, reason, post_id, comment_id, created_at)`)).
+        WithArgs(models.ReputationCommentDeleted, 3, 8).
+        WillReturnResult(sqlmock.NewResult(0, 2))
+    mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET reputation`)).
+        WithArgs(4, 5).
+        WillReturnResult(sqlmock.NewResult(0, 2))
+
+    if err := ReverseComments(db, []uint{3, 8}); err != nil {
+        t.Fatal(err)
+    }
+    expectationsMet(t, mock)
+}
+
+func TestReverseCommentsWithoutEntries(t *testing.T) {
+    db, mock := mockDB(t)
+    mock.ExpectQuery(regexp.QuoteMeta(`SELECT DISTINCT "user_id" FROM "reputation_events"`)).
+        WithArgs(3).
+        WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+    if err := ReverseComments(db, []uint{3}); err != nil {
+        t.Fatal(err)
+    }
+    if err := ReverseComments(db, nil); err != nil {
+        t.Fatal(err)
+    }
+    expectationsMet(t, mock)
+}
+
+func TestRebuild(t *testing.T) {
+    db, mock := mockDB(t)
+    mock.ExpectBegin()
+    mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM reputation_events`)).
+        WillReturnResult(sqlmock.NewResult(0, 7))
+    mock.ExpectExec(regexp.QuoteMeta(`FROM reactions`)).
+        WithArgs(1, -1, models.ReputationLike, models.ReputationDislike).
+        WillReturnResult(sqlmock.NewResult(0, 5))
+    mock.ExpectExec(regexp.QuoteMeta(`WHERE comments.is_solution = true`)).
+        WithArgs(10, models.ReputationSolution).
+        WillReturnResult(sqlmock.NewResult(0, 1))
+    mock.ExpectExec(regexp.QuoteMeta(`UPDATE users SET reputation`)).
+        WillReturnResult(sqlmock.NewResult(0, 3))
+    mock.ExpectCommit()
+
+    if err := Rebuild(db); err != nil {
+        t.Fatal(err)
+    }
+    expectationsMet(t, mock)
+}
+
+func TestRebuildFailure(t *testing.T) {
+    db, mock := mockDB(t)
+    mock.ExpectBegin()
+    mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM reputation_events`)).
+        WillReturnResult(sqlmock.NewResult(0, 7))
+    mock.ExpectExec(regexp.QuoteMeta(`FROM reactions`)).
+        WillReturnError(errors.New("unavailable"))
+    // The old ledger stays
+    mock.ExpectRollback()
+
+    if err := Rebuild(db); err == nil {
+        t.Error("Rebuild() = nil, want the insert error")
+    }
+    expectationsMet(t, mock)
+}
@@ -12,12 +12,41 @@ func SetupRoutes(app *fiber.App) {
     app.Post("/register", handlers.Register)
     app.Post("/check-auth", handlers.CheckAuth)
 
+    app.Get("/users", handlers.AutocompleteUsers)
     app.Get("/users/:username", handlers.GetUser)
     app.Get("/users/:user_id/posts", middleware.OptionalAuth(), handlers.GetUserPosts)
+    app.Get("/users/:user_id/reputation", handlers.GetReputationHistory)
     app.Put("/users/update-username", middleware.JWTProtected(), handlers.UpdateUsername)
     app.Put("/users/update-password", middleware.JWTProtected(), handlers.UpdatePassword)
     app.Put("/users/update-role", middleware.JWTProtected(), handlers.UpdateUserRole)
     app.Put("/users/update-profile-picture", middleware.JWTProtected(), handlers.UpdateProfilePicture)
+    app.Get("/users/me/tags", middleware.JWTProtected(), handlers.GetTagPreferences)
+    app.Get("/users/me/mentions", middleware.JWTProtected(), handlers.GetMentions)
+    app.Put("/users/me/mentions/read-all", middleware.JWTProtected(), handlers.MarkAllMentionsRead)
+    app.Put("/users/me/mentions/:mention_id/read", middleware.JWTProtected(), handlers.MarkMentionRead)
+    app.Get("/users/me/searches", middleware.JWTProtected(), handlers.GetSavedSearches)
+    app.Post("/users/me/searches", middleware.JWTProtected(), handlers.CreateSavedSearch)
+    app.Put("/users/me/searches/:search_id", middleware.JWTProtected(), handlers.UpdateSavedSearch)
+    app.Delete("/users/me/searches/:search_id", middleware.JWTProtected(), handlers.DeleteSavedSearch)
+    app.Get("/users/me/alerts", middleware.JWTProtected(), handlers.GetSearchAlerts)
+    app.Put("/users/me/alerts/read-all", middleware.JWTProtected(), handlers.MarkAllSearchAlertsRead)
+    app.Put("/users/me/alerts/:alert_id/read", middleware.JWTProtected(), handlers.MarkSearchAlertRead)
+    app.Get("/users/me/notifications", middleware.JWTProtected(), handlers.GetNotifications)
+    app.Get("/users/me/notifications/unread-count", middleware.JWTProtected(), handlers.GetUnreadNotificationCount)
+    app.Put("/users/me/notifications/read-all", middleware.JWTProtected(), handlers.MarkAllNotificationsRead)
+    app.Put("/users/me/notifications/:notification_id/read", middleware.JWTProtected(), handlers.MarkNotificationRead)
+    app.Delete("/users/me/notifications/:notification_id", middleware.JWTProtected(), handlers.DeleteNotification)
+    app.Get("/users/me/events", middleware.StreamAuth(), handlers.StreamEvents)
+    app.Get("/users/me/chat-accounts", middleware.JWTProtected(), handlers.GetChatAccounts)
+    app.Delete("/users/me/chat-accounts/:account_id", middleware.JWTProtected(), handlers.DeleteChatAccount)
+    app.Get("/users/me/notification-preferences", middleware.JWTProtected(), handlers.GetNotificationPreferences)
+    app.Put("/users/me/notification-preferences", middleware.JWTProtected(), handlers.UpdateNotificationPreferences)
+    app.Put("/users/me/notification-preferences/quiet-hours", middleware.JWTProtected(), handlers.SetQuietHours)
+    app.Delete("/users/me/notification-preferences/quiet-hours", middleware.JWTProtected(), handlers.DeleteQuietHours)
+    app.Get("/users/me/digest", middleware.JWTProtected(), handlers.GetDigestPreference)
+    app.Put("/users/me/digest", middleware.JWTProtected(), handlers.UpdateDigestPreference)
+    app.Get("/digests/unsubscribe", handlers.GetUnsubscribeDigest)
+    app.Post("/digests/unsubscribe", handlers.UnsubscribeDigest)
 
     app.Post("/posts", middleware.JWTProtected(), handlers.CreatePost)
     app.Delete("/posts/:post_id", middleware.JWTProtected(), handlers.DeletePost)
@@ -27,6 +56,7 @@ func SetupRoutes(app *fiber.App) {
     app.Get("/posts/:post_id", middleware.OptionalAuth(), handlers.GetPost)
     app.Post("/posts/:post_id/metoo", middleware.JWTProtected(), handlers.ToggleMetoo)
     app.Post("/posts/:post_id/watchlist", middleware.JWTProtected(), handlers.ToggleWatchlist)
+    app.Post("/posts/:post_id/mute", middleware.JWTProtected(), handlers.ToggleMutePost)
     app.Post("/posts/:post_id/comment", middleware.JWTProtected(), handlers.CreateComment)
     app.Put("posts/comment/:comment_id", middleware.JWTProtected(), handlers.EditComment)
     app.Delete("/posts/comment/:comment_id/picture/:picture_url", middleware.JWTProtected(), handlers.DeleteCommentPicture)
@@ -34,5 +64,39 @@ func SetupRoutes(app *fiber.App) {
     app.Post("/posts/comment/:comment_id/react", middleware.JWTProtected(), handlers.React)
     app.Put("/posts/comment/:comment_id/solution", middleware.JWTProtected(), handlers.ToggleMarkCommentAsSolution)
 
+    app.Get("/webhooks", middleware.JWTProtected(), handlers.GetWebhooks)
+    app.Post("/webhooks", middleware.JWTProtected(), handlers.CreateWebhook)
+    app.Put("/webhooks/:webhook_id", middleware.JWTProtected(), handlers.UpdateWebhook)
+    app.Delete("/webhooks/:webhook_id", middleware.JWTProtected(), handlers.DeleteWebhook)
+    app.Get("/webhooks/:webhook_id/deliveries", middleware.JWTProtected(), handlers.GetWebhookDeliveries)
+    app.Post("/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", middleware.JWTProtected(), handlers.RedeliverWebhookDelivery)
+
+    app.Get("/tags", handlers.GetTags)
+    app.Post("/tags/merge", middleware.JWTProtected(), handlers.MergeTags)
+    app.Get("/tags/:name", middleware.OptionalAuth(), handlers.GetTag)
+    app.Put("/tags/:name", middleware.JWTProtected(), handlers.UpdateTag)
+    app.Get("/tags/:name/synonyms", handlers.GetTagSynonyms)
+    app.Post("/tags/:name/synonyms", middleware.JWTProtected(), handlers.AddTagSynonym)
+    app.Delete("/tags/:name/synonyms/:synonym", middleware.JWTProtected(), handlers.DeleteTagSynonym)
+    app.Get("/tags/:name/experts", handlers.GetTagExperts)
+    app.Post("/tags/:name/follow", middleware.JWTProtected(), handlers.ToggleFollowTag)
+    app.Post("/tags/:name/ignore", middleware.JWTProtected(), handlers.ToggleIgnoreTag)
+    app.Post("/tags/:name/mute", middleware.JWTProtected(), handlers.ToggleMuteTag)
+
+    app.Post("/search/reindex", middleware.JWTProtected(), handlers.ReindexSearch)
+
+    app.Get("/badges", handlers.GetBadges)
+
+    app.Post("/chat/command", handlers.ChatCommand)
+    app.Get("/chat/link", middleware.JWTProtected(), handlers.GetChatLink)
+    app.Post("/chat/link", middleware.JWTProtected(), handlers.LinkChatAccount)
+
+    app.Get("/feeds/posts.:format", handlers.GetPostsFeed)
+    app.Get("/feeds/posts/:post_id/comments.:format", handlers.GetPostCommentsFeed)
+    app.Get("/feeds/tags/:tag_feed", handlers.GetTagFeed)
+    app.Get("/feeds/users/:user_id.:format", handlers.GetUserFeed)
+
+    app.Post("/markdown/preview", middleware.JWTProtected(), handlers.PreviewMarkdown)
+
     app.Static("/uploads", "./uploads")
 }
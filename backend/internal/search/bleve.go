@@ -0,0 +1,533 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	blevesearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/lib/pq"
+	bolt "go.etcd.io/bbolt"
+
+	"techquire-backend/internal/models"
+)
+
+// bleveBatchSize is the number of hits read, and documents deleted, at once. Searches
+// page through every hit, it doesn't limit them.
+const bleveBatchSize = 1000
+
+// bleveLockTimeout is how long opening the index waits for another process to release it
+var bleveLockTimeout = 5 * time.Second
+
+// ErrIndexLocked is returned when another process, e.g. a running server, has the index open
+var ErrIndexLocked = errors.New("search index is in use by another process")
+
+// Snippet length of highlighted content, in bytes
+const snippetLength = 240
+
+// Kinds of documents in the Bleve index
+const (
+    kindPost    = "post"
+    kindComment = "comment"
+)
+
+// bleveDocument is a post or comment in the Bleve index. All holds every searchable
+// text of the document, so a query's words may match across title, tags and content.
+type bleveDocument struct {
+    Kind       string `json:"kind"`
+    PostRef    string `json:"post_ref"`
+    Title      string `json:"title"`
+    Tags       string `json:"tags"`
+    Content    string `json:"content"`
+    All        string `json:"all"`
+    IsSolution bool   `json:"is_solution"`
+}
+
+// BleveIndex is an embedded on-disk search index kept in sync through Sync
+type BleveIndex struct {
+    index bleve.Index
+}
+
+// OpenBleveIndex opens the Bleve index at path, creating it if needed. Only one process
+// can have it open, ErrIndexLocked is returned while another one does.
+func OpenBleveIndex(path string) (*BleveIndex, error) {
+    config := map[string]interface{}{"bolt_timeout": bleveLockTimeout.String()}
+    index, err := bleve.OpenUsing(path, config)
+    if err == bleve.ErrorIndexPathDoesNotExist {
+        index, err = bleve.NewUsing(path, newBleveMapping(), bleve.Config.DefaultIndexType, bleve.Config.DefaultKVStore, config)
+    }
+    if errors.Is(err, bolt.ErrTimeout) {
+        return nil, ErrIndexLocked
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &BleveIndex{index: index}, nil
+}
+
+// newBleveMapping maps the document fields. Title and content are stored with term
+// vectors for snippets, "all" keeps term vectors for phrase queries and tags are only searched.
+func newBleveMapping() mapping.IndexMapping {
+    storedText := bleve.NewTextFieldMapping()
+    storedText.Analyzer = en.AnalyzerName
+
+    allText := bleve.NewTextFieldMapping()
+    allText.Analyzer = en.AnalyzerName
+    allText.Store = false
+
+    searchedText := bleve.NewTextFieldMapping()
+    searchedText.Analyzer = en.AnalyzerName
+    searchedText.Store = false
+    searchedText.IncludeTermVectors = false
+
+    document := bleve.NewDocumentStaticMapping()
+    document.AddFieldMappingsAt("kind", bleve.NewKeywordFieldMapping())
+    document.AddFieldMappingsAt("post_ref", bleve.NewKeywordFieldMapping())
+    document.AddFieldMappingsAt("title", storedText)
+    document.AddFieldMappingsAt("tags", searchedText)
+    document.AddFieldMappingsAt("content", storedText)
+    document.AddFieldMappingsAt("all", allText)
+    document.AddFieldMappingsAt("is_solution", bleve.NewBooleanFieldMapping())
+
+    indexMapping := bleve.NewIndexMapping()
+    indexMapping.DefaultMapping = document
+    indexMapping.DefaultAnalyzer = en.AnalyzerName
+    return indexMapping
+}
+
+func postDocID(postID uint) string {
+    return fmt.Sprintf("%s:%d", kindPost, postID)
+}
+
+func commentDocID(commentID uint) string {
+    return fmt.Sprintf("%s:%d", kindComment, commentID)
+}
+
+// IndexPost adds or replaces a post
+func (b *BleveIndex) IndexPost(post models.Post) error {
+    tags := strings.Join(post.Tags, " ")
+    return b.index.Index(postDocID(post.ID), bleveDocument{
+        Kind:    kindPost,
+        PostRef: strconv.FormatUint(uint64(post.ID), 10),
+        Title:   post.Title,
+        Tags:    tags,
+        Content: post.Content,
+        All:     post.Title + "\n" + tags + "\n" + post.Content,
+    })
+}
+
+// DeletePost removes a post and its comments. Comments are deleted in batches until the
+// query finds none left.
+func (b *BleveIndex) DeletePost(postID uint) error {
+    if err := b.index.Delete(postDocID(postID)); err != nil {
+        return err
+    }
+
+    comments := bleve.NewConjunctionQuery(
+        termQuery("kind", kindComment),
+        termQuery("post_ref", strconv.FormatUint(uint64(postID), 10)),
+    )
+    for {
+        result, err := b.index.Search(bleve.NewSearchRequestOptions(comments, bleveBatchSize, 0, false))
+        if err != nil {
+            return err
+        }
+        if len(result.Hits) == 0 {
+            return nil
+        }
+        batch := b.index.NewBatch()
+        for _, hit := range result.Hits {
+            batch.Delete(hit.ID)
+        }
+        if err := b.index.Batch(batch); err != nil {
+            return err
+        }
+    }
+}
+
+// IndexComment adds or replaces a comment
+func (b *BleveIndex) IndexComment(comment models.Comment) error {
+    return b.index.Index(commentDocID(comment.ID), bleveDocument{
+        Kind:       kindComment,
+        PostRef:    strconv.FormatUint(uint64(comment.PostID), 10),
+        Content:    comment.Content,
+        All:        comment.Content,
+        IsSolution: comment.IsSolution,
+    })
+}
+
+// DeleteComment removes a comment
+func (b *BleveIndex) DeleteComment(commentID uint) error {
+    return b.index.Delete(commentDocID(commentID))
+}
+
+// Close closes the index
+func (b *BleveIndex) Close() error {
+    return b.index.Close()
+}
+
+// searchAll runs a search page by page, calling fn for every hit in order until all
+// result.Total hits were read or fn returns false
+func (b *BleveIndex) searchAll(request *bleve.SearchRequest, fn func(hit *blevesearch.DocumentMatch) bool) error {
+    request.Size = bleveBatchSize
+    for request.From = 0; ; request.From += bleveBatchSize {
+        result, err := b.index.Search(request)
+        if err != nil {
+            return err
+        }
+        for _, hit := range result.Hits {
+            if !fn(hit) {
+                return nil
+            }
+        }
+        if len(result.Hits) < bleveBatchSize || uint64(request.From+len(result.Hits)) >= result.Total {
+            return nil
+        }
+    }
+}
+
+func termQuery(field, term string) query.Query {
+    q := bleve.NewTermQuery(term)
+    q.SetField(field)
+    return q
+}
+
+func matchQuery(field, text string, boost float64) *query.MatchQuery {
+    q := bleve.NewMatchQuery(text)
+    q.SetField(field)
+    q.SetBoost(boost)
+    return q
+}
+
+// websearchTerm matches the parts of a websearch query: -"excluded phrase", "phrase", -excluded or word
+var websearchTerm = regexp.MustCompile(`-?"[^"]*"|\S+`)
+
+// parsedQuery is a websearch query split into its parts
+type parsedQuery struct {
+    Words    []string
+    Phrases  []string
+    Excluded []string
+}
+
+// parseWebsearch splits a query the way websearch_to_tsquery reads it. OR is ignored.
+func parseWebsearch(q string) parsedQuery {
+    var parsed parsedQuery
+    for _, term := range websearchTerm.FindAllString(q, -1) {
+        excluded := strings.HasPrefix(term, "-") && len(term) > 1
+        term = strings.TrimPrefix(term, "-")
+        phrase := strings.HasPrefix(term, `"`)
+        term = strings.Trim(term, `"`)
+        switch {
+        case term == "" || strings.EqualFold(term, "or"):
+        case excluded:
+            parsed.Excluded = append(parsed.Excluded, term)
+        case phrase:
+            parsed.Phrases = append(parsed.Phrases, term)
+        default:
+            parsed.Words = append(parsed.Words, term)
+        }
+    }
+    return parsed
+}
+
+// allText joins the words and phrases, for scoring and highlighting
+func (p parsedQuery) allText() string {
+    return strings.Join(append(append([]string{}, p.Words...), p.Phrases...), " ")
+}
+
+// documentQuery matches the documents of a kind containing every word and phrase of the
+// query in their "all" field. Matches in posts' titles and tags score higher.
+func documentQuery(kind string, parsed parsedQuery, solutionsOnly bool) query.Query {
+    must := []query.Query{termQuery("kind", kind)}
+    if len(parsed.Words) > 0 {
+        words := matchQuery("all", strings.Join(parsed.Words, " "), 1)
+        words.SetOperator(query.MatchQueryOperatorAnd)
+        must = append(must, words)
+    }
+    for _, phrase := range parsed.Phrases {
+        phraseQuery := bleve.NewMatchPhraseQuery(phrase)
+        phraseQuery.SetField("all")
+        must = append(must, phraseQuery)
+    }
+    if len(must) == 1 {
+        // Only excluded terms, like websearch_to_tsquery this matches nothing
+        return bleve.NewMatchNoneQuery()
+    }
+    if kind == kindComment && solutionsOnly {
+        isSolution := bleve.NewBoolFieldQuery(true)
+        isSolution.SetField("is_solution")
+        must = append(must, isSolution)
+    }
+
+    // Optional clauses boost posts matching in title and tags, and locate matches for snippets
+    var should []query.Query
+    if kind == kindPost {
+        should = append(should, matchQuery("title", parsed.allText(), 3), matchQuery("tags", parsed.allText(), 2))
+    } else {
+        should = append(should, matchQuery("content", parsed.allText(), 1))
+    }
+
+    var mustNot []query.Query
+    for _, excluded := range parsed.Excluded {
+        mustNot = append(mustNot, matchQuery("all", excluded, 1))
+    }
+    return query.NewBooleanQuery(must, should, mustNot)
+}
+
+// searchPosts returns the best score of every post matching the request
+func (b *BleveIndex) searchPosts(req Request) (map[uint]float64, error) {
+    parsed := parseWebsearch(req.Query)
+    scores := make(map[uint]float64)
+
+    kinds := []string{kindPost}
+    switch req.Scope {
+    case ScopeComments:
+        kinds = []string{kindComment}
+    case ScopeAll:
+        kinds = []string{kindPost, kindComment}
+    }
+
+    for _, kind := range kinds {
+        request := bleve.NewSearchRequest(documentQuery(kind, parsed, req.SolutionsOnly))
+        request.Fields = []string{"post_ref"}
+        if err := b.searchAll(request, func(hit *blevesearch.DocumentMatch) bool {
+            postID, ok := postRef(hit)
+            if ok && hit.Score > scores[postID] {
+                scores[postID] = hit.Score
+            }
+            return true
+        }); err != nil {
+            return nil, err
+        }
+    }
+    return scores, nil
+}
+
+// postRef reads the post ID of a hit
+func postRef(hit *blevesearch.DocumentMatch) (uint, bool) {
+    ref, _ := hit.Fields["post_ref"].(string)
+    postID, err := strconv.ParseUint(ref, 10, 64)
+    return uint(postID), err == nil
+}
+
+// Match searches the index and selects the matching posts by ID, ranked by their score.
+// The IDs and scores are passed as one array and one JSON object, however many posts
+// match: a placeholder per ID would run into Postgres's limit of 65535 parameters.
+// JSON objects keep their keys sorted, so looking up a score takes logarithmic time.
+func (b *BleveIndex) Match(req Request) (Match, error) {
+    scores, err := b.searchPosts(req)
+    if err != nil {
+        return Match{}, err
+    }
+    if len(scores) == 0 {
+        return Match{Condition: "FALSE", Relevance: "0"}, nil
+    }
+
+    postIDs := make(pq.Int64Array, 0, len(scores))
+    scoresByID := make(map[string]float64, len(scores))
+    for postID, score := range scores {
+        postIDs = append(postIDs, int64(postID))
+        scoresByID[strconv.FormatUint(uint64(postID), 10)] = score
+    }
+    scoresJSON, err := json.Marshal(scoresByID)
+    if err != nil {
+        return Match{}, err
+    }
+
+    return Match{
+        Condition:     "posts.id = ANY(CAST(? AS bigint[]))",
+        Args:          []interface{}{postIDs},
+        Relevance:     "COALESCE(CAST(CAST(? AS jsonb) ->> CAST(posts.id AS text) AS double precision), 0)",
+        RelevanceArgs: []interface{}{string(scoresJSON)},
+    }, nil
+}
+
+// Highlights returns highlighted title and content snippets for the given posts
+func (b *BleveIndex) Highlights(req Request, postIDs []uint) (map[uint]Highlight, error) {
+    highlights := make(map[uint]Highlight)
+    if len(postIDs) == 0 {
+        return highlights, nil
+    }
+
+    docIDs := make([]string, 0, len(postIDs))
+    for _, postID := range postIDs {
+        docIDs = append(docIDs, postDocID(postID))
+    }
+
+    // Every requested post is returned, the optional clauses locate the matches
+    text := parseWebsearch(req.Query).allText()
+    q := query.NewBooleanQuery(
+        []query.Query{bleve.NewDocIDQuery(docIDs)},
+        []query.Query{matchQuery("title", text, 1), matchQuery("content", text, 1)},
+        nil,
+    )
+    request := bleve.NewSearchRequestOptions(q, len(docIDs), 0, false)
+    request.Fields = []string{"post_ref", "title", "content"}
+    request.IncludeLocations = true
+    result, err := b.index.Search(request)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, hit := range result.Hits {
+        postID, ok := postRef(hit)
+        if !ok {
+            continue
+        }
+        title, _ := hit.Fields["title"].(string)
+        content, _ := hit.Fields["content"].(string)
+        highlights[postID] = Highlight{
+            Title:   markHighlights(markLocations(title, hit.Locations["title"], 0)),
+            Content: markHighlights(markLocations(content, hit.Locations["content"], snippetLength)),
+        }
+    }
+    return highlights, nil
+}
+
+// CommentMatches returns the best matching comment of each of the given posts
+func (b *BleveIndex) CommentMatches(req Request, postIDs []uint) (map[uint]CommentMatch, error) {
+    matches := make(map[uint]CommentMatch)
+    if len(postIDs) == 0 {
+        return matches, nil
+    }
+
+    postRefs := make([]query.Query, 0, len(postIDs))
+    for _, postID := range postIDs {
+        postRefs = append(postRefs, termQuery("post_ref", strconv.FormatUint(uint64(postID), 10)))
+    }
+    q := bleve.NewConjunctionQuery(
+        documentQuery(kindComment, parseWebsearch(req.Query), req.SolutionsOnly),
+        bleve.NewDisjunctionQuery(postRefs...),
+    )
+    request := bleve.NewSearchRequest(q)
+    request.Fields = []string{"post_ref", "content", "is_solution"}
+    request.IncludeLocations = true
+
+    // Hits are sorted by score, so the first hit of a post is its best comment. Paging
+    // stops once every post has one.
+    err := b.searchAll(request, func(hit *blevesearch.DocumentMatch) bool {
+        postID, ok := postRef(hit)
+        if !ok {
+            return true
+        }
+        if _, exists := matches[postID]; exists {
+            return true
+        }
+        commentID, err := strconv.ParseUint(strings.TrimPrefix(hit.ID, kindComment+":"), 10, 64)
+        if err != nil {
+            return true
+        }
+        content, _ := hit.Fields["content"].(string)
+        isSolution, _ := hit.Fields["is_solution"].(bool)
+        matches[postID] = CommentMatch{
+            ID:         uint(commentID),
+            Snippet:    markHighlights(markLocations(content, hit.Locations["content"], snippetLength)),
+            IsSolution: isSolution,
+        }
+        return len(matches) < len(postIDs)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return matches, nil
+}
+
+// markLocations wraps the matched terms of a field in highlight markers. With a maxLen,
+// the text is cut to a window of about that many bytes around the first match.
+func markLocations(text string, locations blevesearch.TermLocationMap, maxLen int) string {
+    type span struct{ start, end int }
+    var spans []span
+    for _, termLocations := range locations {
+        for _, location := range termLocations {
+            if int(location.End) <= len(text) && location.Start < location.End {
+                spans = append(spans, span{int(location.Start), int(location.End)})
+            }
+        }
+    }
+    sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+    windowStart, windowEnd := 0, len(text)
+    if maxLen > 0 && len(text) > maxLen {
+        if len(spans) > 0 {
+            windowStart = spans[0].start - maxLen/4
+        }
+        if windowStart < 0 {
+            windowStart = 0
+        }
+        windowEnd = windowStart + maxLen
+        if windowEnd > len(text) {
+            windowEnd = len(text)
+        }
+        for windowStart > 0 && !utf8.RuneStart(text[windowStart]) {
+            windowStart--
+        }
+        for windowEnd < len(text) && !utf8.RuneStart(text[windowEnd]) {
+            windowEnd--
+        }
+    }
+
+    var marked strings.Builder
+    if windowStart > 0 {
+        marked.WriteString("… ")
+    }
+    position := windowStart
+    for _, s := range spans {
+        if s.start < position || s.end > windowEnd {
+            continue
+        }
+        marked.WriteString(text[position:s.start])
+        marked.WriteString(highlightStart + text[s.start:s.end] + highlightStop)
+        position = s.end
+    }
+    marked.WriteString(text[position:windowEnd])
+    if windowEnd < len(text) {
+        marked.WriteString(" …")
+    }
+    return marked.String()
+}
+
+// Prune deletes the documents of posts and comments that aren't in the given sets, after
+// a reindex. The index stays in use meanwhile, unlike when it is recreated.
+func (b *BleveIndex) Prune(postIDs, commentIDs map[uint]bool) error {
+    var stale []string
+    request := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+    request.SortBy([]string{"_id"})
+    if err := b.searchAll(request, func(hit *blevesearch.DocumentMatch) bool {
+        kind, ref, found := strings.Cut(hit.ID, ":")
+        id, err := strconv.ParseUint(ref, 10, 64)
+        switch {
+        case !found || err != nil:
+            stale = append(stale, hit.ID)
+        case kind == kindPost && !postIDs[uint(id)], kind == kindComment && !commentIDs[uint(id)]:
+            stale = append(stale, hit.ID)
+        }
+        return true
+    }); err != nil {
+        return err
+    }
+
+    for start := 0; start < len(stale); start += bleveBatchSize {
+        end := start + bleveBatchSize
+        if end > len(stale) {
+            end = len(stale)
+        }
+        batch := b.index.NewBatch()
+        for _, docID := range stale[start:end] {
+            batch.Delete(docID)
+        }
+        if err := b.index.Batch(batch); err != nil {
+            return err
+        }
+    }
+    return nil
+}
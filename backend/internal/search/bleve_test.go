@@ -0,0 +1,178 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"techquire-backend/internal/models"
+)
+
+// More documents than a batch, so every path has to page
+const testDocuments = bleveBatchSize + 250
+
+func openTestIndex(t *testing.T) (*BleveIndex, string) {
+    t.Helper()
+    path := filepath.Join(t.TempDir(), "search.bleve")
+    index, err := OpenBleveIndex(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    t.Cleanup(func() { index.Close() })
+    return index, path
+}
+
+func indexPosts(t *testing.T, index *BleveIndex, count int) {
+    t.Helper()
+    batch := index.index.NewBatch()
+    for id := 1; id <= count; id++ {
+        post := models.Post{ID: uint(id), Title: fmt.Sprintf("Timeout number %d", id), Content: "connection reset"}
+        if err := batch.Index(postDocID(post.ID), bleveDocument{Kind: kindPost, PostRef: fmt.Sprint(id), Title: post.Title, Content: post.Content, All: post.Title + "\n" + post.Content}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := index.index.Batch(batch); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func indexComments(t *testing.T, index *BleveIndex, postID uint, count int) {
+    t.Helper()
+    batch := index.index.NewBatch()
+    for id := 1; id <= count; id++ {
+        if err := batch.Index(commentDocID(uint(id)), bleveDocument{Kind: kindComment, PostRef: fmt.Sprint(postID), Content: "try again", All: "try again"}); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if err := index.index.Batch(batch); err != nil {
+        t.Fatal(err)
+    }
+}
+
+func docCount(t *testing.T, index *BleveIndex) uint64 {
+    t.Helper()
+    count, err := index.index.DocCount()
+    if err != nil {
+        t.Fatal(err)
+    }
+    return count
+}
+
+func TestBleveMatchesEveryPost(t *testing.T) {
+    index, _ := openTestIndex(t)
+    indexPosts(t, index, testDocuments)
+
+    scores, err := index.searchPosts(Request{Query: "timeout", Scope: ScopePosts})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(scores) != testDocuments {
+        t.Errorf("matched %d posts, want %d", len(scores), testDocuments)
+    }
+}
+
+func TestBleveMatchBindsTwoArguments(t *testing.T) {
+    index, _ := openTestIndex(t)
+    indexPosts(t, index, testDocuments)
+
+    match, err := index.Match(Request{Query: "timeout", Scope: ScopePosts})
+    if err != nil {
+        t.Fatal(err)
+    }
+    // However many posts match, the condition and the relevance take one argument each
+    if len(match.Args) != 1 || len(match.RelevanceArgs) != 1 {
+        t.Fatalf("Match() has %d and %d arguments, want 1 and 1", len(match.Args), len(match.RelevanceArgs))
+    }
+    postIDs, ok := match.Args[0].(pq.Int64Array)
+    if !ok || len(postIDs) != testDocuments {
+        t.Errorf("Match() selects %d posts, want %d", len(postIDs), testDocuments)
+    }
+    var scores map[string]float64
+    if err := json.Unmarshal([]byte(match.RelevanceArgs[0].(string)), &scores); err != nil {
+        t.Fatal(err)
+    }
+    if len(scores) != testDocuments || scores["1"] <= 0 {
+        t.Errorf("Match() scores %d posts, post 1 with %f", len(scores), scores["1"])
+    }
+}
+
+func TestBleveMatchNothing(t *testing.T) {
+    index, _ := openTestIndex(t)
+    indexPosts(t, index, 3)
+
+    match, err := index.Match(Request{Query: "unrelated", Scope: ScopePosts})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if match.Condition != "FALSE" || len(match.Args) != 0 {
+        t.Errorf("Match() = %+v, want no posts", match)
+    }
+}
+
+func TestBleveCommentMatchesPages(t *testing.T) {
+    index, _ := openTestIndex(t)
+    indexComments(t, index, 1, testDocuments)
+    // A comment of another post ranked below every comment of post 1
+    if err := index.IndexComment(models.Comment{ID: testDocuments + 1, PostID: 2, Content: "try"}); err != nil {
+        t.Fatal(err)
+    }
+
+    matches, err := index.CommentMatches(Request{Query: "try", Scope: ScopeComments}, []uint{1, 2})
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(matches) != 2 {
+        t.Errorf("matched comments of %d posts, want 2", len(matches))
+    }
+}
+
+func TestBleveDeletePostDeletesEveryComment(t *testing.T) {
+    index, _ := openTestIndex(t)
+    indexPosts(t, index, 1)
+    indexComments(t, index, 1, testDocuments)
+    if err := index.IndexComment(models.Comment{ID: testDocuments + 1, PostID: 2, Content: "other post"}); err != nil {
+        t.Fatal(err)
+    }
+
+    if err := index.DeletePost(1); err != nil {
+        t.Fatal(err)
+    }
+    if count := docCount(t, index); count != 1 {
+        t.Errorf("%d documents left, want only the other post's comment", count)
+    }
+}
+
+func TestBlevePrune(t *testing.T) {
+    index, _ := openTestIndex(t)
+    indexPosts(t, index, testDocuments)
+    indexComments(t, index, 1, 10)
+
+    keepPosts := map[uint]bool{1: true, testDocuments: true}
+    keepComments := map[uint]bool{5: true}
+    if err := index.Prune(keepPosts, keepComments); err != nil {
+        t.Fatal(err)
+    }
+    if count := docCount(t, index); count != 3 {
+        t.Errorf("%d documents left after pruning, want 3", count)
+    }
+    for _, docID := range []string{postDocID(1), postDocID(testDocuments), commentDocID(5)} {
+        if doc, err := index.index.Document(docID); err != nil || doc == nil {
+            t.Errorf("document %s was pruned", docID)
+        }
+    }
+}
+
+func TestBleveLocked(t *testing.T) {
+    timeout := bleveLockTimeout
+    bleveLockTimeout = 100 * time.Millisecond
+    defer func() { bleveLockTimeout = timeout }()
+
+    _, path := openTestIndex(t)
+    if _, err := OpenBleveIndex(path); err != ErrIndexLocked {
+        t.Errorf("opening an index in use returned %v, want ErrIndexLocked", err)
+    }
+}
@@ -0,0 +1,190 @@
+package search
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/models"
+)
+
+// Search scopes: where a query matches
+const (
+    ScopePosts    = "posts"
+    ScopeComments = "comments"
+    ScopeAll      = "all"
+)
+
+// Request is a full-text search of the post feed
+type Request struct {
+    Query         string // Query in websearch syntax: words, "quoted phrases" and -excluded words
+    Scope         string // ScopePosts, ScopeComments or ScopeAll
+    SolutionsOnly bool   // Only match comments that are accepted solutions
+}
+
+// SearchesComments reports whether the request matches comments
+func (r Request) SearchesComments() bool {
+    return r.Scope == ScopeComments || r.Scope == ScopeAll
+}
+
+// Match is the SQL form of a search, applied to a query on the posts table
+type Match struct {
+    Condition     string        // WHERE condition selecting the matching posts
+    Args          []interface{} // Arguments of Condition
+    Relevance     string        // Expression ranking a matching post, higher is better
+    RelevanceArgs []interface{} // Arguments of Relevance
+}
+
+// Highlight holds search snippets of a post with matches wrapped in <mark> tags
+type Highlight struct {
+    Title   string `json:"title"`
+    Content string `json:"content"`
+}
+
+// CommentMatch is the best matching comment of a post with a highlighted snippet
+type CommentMatch struct {
+    ID         uint   `json:"id"`
+    Snippet    string `json:"snippet"`
+    IsSolution bool   `json:"is_solution"`
+}
+
+// Index is a full-text search backend for the post feed. Backends that keep their own
+// index are updated through IndexPost and friends, see Sync.
+type Index interface {
+    // Match finds the posts matching a request
+    Match(req Request) (Match, error)
+    // Highlights returns highlighted snippets of the given matching posts
+    Highlights(req Request, postIDs []uint) (map[uint]Highlight, error)
+    // CommentMatches returns the best matching comment of each of the given posts
+    CommentMatches(req Request, postIDs []uint) (map[uint]CommentMatch, error)
+
+    IndexPost(post models.Post) error
+    DeletePost(postID uint) error
+    IndexComment(comment models.Comment) error
+    DeleteComment(commentID uint) error
+    Close() error
+}
+
+// rebuilder is implemented by backends that can rebuild their index in one go
+type rebuilder interface {
+    Rebuild() error
+}
+
+// pruner is implemented by backends that keep documents until they are deleted, so a
+// reindex drops the ones no longer in the database
+type pruner interface {
+    Prune(postIDs, commentIDs map[uint]bool) error
+}
+
+// Default is the index used by the post feed, set up at startup
+var Default Index
+
+// Open opens the backend configured with SEARCH_BACKEND: "postgres" (default) or "bleve",
+// whose index is stored at SEARCH_INDEX_PATH
+func Open(db *gorm.DB) (Index, error) {
+    switch backend := os.Getenv("SEARCH_BACKEND"); backend {
+    case "", "postgres":
+        return NewSQLIndex(db), nil
+    case "bleve":
+        path := os.Getenv("SEARCH_INDEX_PATH")
+        if path == "" {
+            path = "./data/search.bleve"
+        }
+        return OpenBleveIndex(path)
+    default:
+        return nil, fmt.Errorf("unknown search backend %q", backend)
+    }
+}
+
+// reindexBatchSize is the number of rows loaded at once by Reindex
+const reindexBatchSize = 500
+
+// Reindex rebuilds an index from every post and comment in the database. Documents are
+// replaced in place, so the index keeps serving searches while it runs.
+func Reindex(db *gorm.DB, index Index) error {
+    if r, ok := index.(rebuilder); ok {
+        return r.Rebuild()
+    }
+
+    var posts []models.Post
+    postIDs := make(map[uint]bool)
+    if err := db.FindInBatches(&posts, reindexBatchSize, func(tx *gorm.DB, batch int) error {
+        for _, post := range posts {
+            if err := index.IndexPost(post); err != nil {
+                return err
+            }
+            postIDs[post.ID] = true
+        }
+        return nil
+    }).Error; err != nil {
+        return err
+    }
+
+    var comments []models.Comment
+    commentIDs := make(map[uint]bool)
+    if err := db.FindInBatches(&comments, reindexBatchSize, func(tx *gorm.DB, batch int) error {
+        for _, comment := range comments {
+            if err := index.IndexComment(comment); err != nil {
+                return err
+            }
+            commentIDs[comment.ID] = true
+        }
+        return nil
+    }).Error; err != nil {
+        return err
+    }
+
+    if p, ok := index.(pruner); ok {
+        if err := p.Prune(postIDs, commentIDs); err != nil {
+            return err
+        }
+    }
+
+    log.Printf("[SEARCH] Reindexed %d posts and %d comments", len(postIDs), len(commentIDs))
+    return nil
+}
+
+// Sync keeps an index up to date with the post and comment events
+func Sync(db *gorm.DB, index Index) {
+    events.Subscribe(func(event events.Event) {
+        var err error
+        switch event.Type {
+        case events.PostCreated, events.PostUpdated:
+            var post models.Post
+            if err = db.First(&post, event.PostID).Error; err == nil {
+                err = index.IndexPost(post)
+            }
+        case events.PostDeleted:
+            err = index.DeletePost(event.PostID)
+        case events.CommentCreated, events.CommentUpdated:
+            var comment models.Comment
+            if err = db.First(&comment, event.CommentID).Error; err == nil {
+                err = index.IndexComment(comment)
+            }
+        case events.CommentDeleted:
+            err = index.DeleteComment(event.CommentID)
+        }
+        if err != nil {
+            log.Printf("[SEARCH] Failed to index %s (post %d, comment %d): %v", event.Type, event.PostID, event.CommentID, err)
+        }
+    })
+}
+
+// Markers placed around matches in snippets. They are turned into <mark> tags after the
+// rest of the snippet has been HTML escaped, so stored content can't inject markup.
+const (
+    highlightStart = "⟦hl⟧"
+    highlightStop  = "⟦/hl⟧"
+)
+
+// markHighlights escapes a snippet and replaces the markers with <mark> tags
+func markHighlights(snippet string) string {
+    escaped := html.EscapeString(snippet)
+    escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+    return strings.ReplaceAll(escaped, highlightStop, "</mark>")
+}
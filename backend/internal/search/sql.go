@@ -0,0 +1,162 @@
+package search
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/models"
+)
+
+// SearchConfig is the text search configuration used for indexing and querying
+const SearchConfig = "english"
+
+// Search query in websearch syntax: quoted phrases, OR and -excluded words
+const searchQuery = "websearch_to_tsquery('" + SearchConfig + "', ?)"
+
+const (
+    titleHeadlineOptions   = `HighlightAll=true, StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+    contentHeadlineOptions = `MaxFragments=2, MaxWords=30, MinWords=10, FragmentDelimiter=" … ", StartSel="` + highlightStart + `", StopSel="` + highlightStop + `"`
+)
+
+// SQLIndex searches the search_vector columns Postgres keeps up to date with triggers
+// (see database.MigrateSearch), so it needs no indexing of its own
+type SQLIndex struct {
+    db *gorm.DB
+}
+
+// NewSQLIndex creates a Postgres full-text search index
+func NewSQLIndex(db *gorm.DB) *SQLIndex {
+    return &SQLIndex{db: db}
+}
+
+// commentMatch selects the given columns of the post's comments matching the search
+func commentMatch(req Request, columns string) string {
+    match := "SELECT " + columns + " FROM comments WHERE comments.post_id = posts.id AND comments.search_vector @@ " + searchQuery
+    if req.SolutionsOnly {
+        match += " AND comments.is_solution = true"
+    }
+    return match
+}
+
+// Match matches the post and/or comment search vectors. A post matched through its
+// comments ranks as its best comment.
+func (s *SQLIndex) Match(req Request) (Match, error) {
+    postRank := "ts_rank_cd(posts.search_vector, " + searchQuery + ")"
+    commentRank := "COALESCE((" + commentMatch(req, "MAX(ts_rank_cd(comments.search_vector, "+searchQuery+"))") + "), 0)"
+
+    switch req.Scope {
+    case ScopeComments:
+        return Match{
+            Condition:     "EXISTS (" + commentMatch(req, "1") + ")",
+            Args:          []interface{}{req.Query},
+            Relevance:     commentRank,
+            RelevanceArgs: []interface{}{req.Query, req.Query},
+        }, nil
+    case ScopeAll:
+        return Match{
+            Condition:     "(posts.search_vector @@ " + searchQuery + " OR EXISTS (" + commentMatch(req, "1") + "))",
+            Args:          []interface{}{req.Query, req.Query},
+            Relevance:     "GREATEST(" + postRank + ", " + commentRank + ")",
+            RelevanceArgs: []interface{}{req.Query, req.Query, req.Query},
+        }, nil
+    default:
+        return Match{
+            Condition:     "posts.search_vector @@ " + searchQuery,
+            Args:          []interface{}{req.Query},
+            Relevance:     postRank,
+            RelevanceArgs: []interface{}{req.Query},
+        }, nil
+    }
+}
+
+// Highlights returns highlighted title and content snippets for the given posts
+func (s *SQLIndex) Highlights(req Request, postIDs []uint) (map[uint]Highlight, error) {
+    highlights := make(map[uint]Highlight)
+    if len(postIDs) == 0 {
+        return highlights, nil
+    }
+
+    type HeadlineResult struct {
+        ID      uint
+        Title   string
+        Content string
+    }
+    var headlines []HeadlineResult
+    if err := s.db.Table("posts").
+        Select(`posts.id,
+            ts_headline('`+SearchConfig+`', posts.title, `+searchQuery+`, ?) AS title,
+            ts_headline('`+SearchConfig+`', posts.content, `+searchQuery+`, ?) AS content`,
+            req.Query, titleHeadlineOptions, req.Query, contentHeadlineOptions).
+        Where("posts.id IN ?", postIDs).
+        Scan(&headlines).Error; err != nil {
+        return nil, err
+    }
+
+    for _, headline := range headlines {
+        highlights[headline.ID] = Highlight{
+            Title:   markHighlights(headline.Title),
+            Content: markHighlights(headline.Content),
+        }
+    }
+    return highlights, nil
+}
+
+// CommentMatches returns the best matching comment of each of the given posts
+func (s *SQLIndex) CommentMatches(req Request, postIDs []uint) (map[uint]CommentMatch, error) {
+    matches := make(map[uint]CommentMatch)
+    if len(postIDs) == 0 {
+        return matches, nil
+    }
+
+    // Pick the best ranked comment per post first, so only those get a headline
+    best := s.db.Table("comments").
+        Select("DISTINCT ON (comments.post_id) comments.post_id, comments.id, comments.content, comments.is_solution").
+        Where("comments.post_id IN ?", postIDs).
+        Where("comments.search_vector @@ "+searchQuery, req.Query).
+        Order(clause.OrderBy{Expression: clause.Expr{
+            SQL:  "comments.post_id, ts_rank_cd(comments.search_vector, " + searchQuery + ") DESC, comments.id ASC",
+            Vars: []interface{}{req.Query},
+        }})
+    if req.SolutionsOnly {
+        best = best.Where("comments.is_solution = true")
+    }
+
+    type MatchResult struct {
+        PostID     uint
+        ID         uint
+        IsSolution bool
+        Snippet    string
+    }
+    var results []MatchResult
+    if err := s.db.Table("(?) AS best", best).
+        Select("best.post_id, best.id, best.is_solution, ts_headline('"+SearchConfig+"', best.content, "+searchQuery+", ?) AS snippet",
+            req.Query, contentHeadlineOptions).
+        Scan(&results).Error; err != nil {
+        return nil, err
+    }
+
+    for _, result := range results {
+        matches[result.PostID] = CommentMatch{
+            ID:         result.ID,
+            Snippet:    markHighlights(result.Snippet),
+            IsSolution: result.IsSolution,
+        }
+    }
+    return matches, nil
+}
+
+// Postgres triggers keep the search vectors current, the SQLIndex doesn't index anything itself
+
+func (s *SQLIndex) IndexPost(post models.Post) error          { return nil }
+func (s *SQLIndex) DeletePost(postID uint) error              { return nil }
+func (s *SQLIndex) IndexComment(comment models.Comment) error { return nil }
+func (s *SQLIndex) DeleteComment(commentID uint) error        { return nil }
+func (s *SQLIndex) Close() error                              { return nil }
+
+// Rebuild recomputes every search vector by firing the triggers
+func (s *SQLIndex) Rebuild() error {
+    if err := s.db.Exec("UPDATE posts SET title = title").Error; err != nil {
+        return err
+    }
+    return s.db.Exec("UPDATE comments SET content = content").Error
+}
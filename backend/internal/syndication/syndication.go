@@ -0,0 +1,217 @@
+package syndication
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Supported feed formats
+const (
+    FormatRSS  = "rss"
+    FormatAtom = "atom"
+)
+
+// ContentTypes maps the feed formats to their media types
+var ContentTypes = map[string]string{
+    FormatRSS:  "application/rss+xml; charset=utf-8",
+    FormatAtom: "application/atom+xml; charset=utf-8",
+}
+
+// Feed is a list of entries that can be rendered as RSS 2.0 or Atom
+type Feed struct {
+    ID          string // Permanent identifier, the same for every URL of the feed
+    Title       string
+    Description string
+    Author      string // Author of the feed, for entries without their own
+    Link        string // Page the feed is about
+    SelfLink    string // URL of the feed itself
+    Updated     time.Time
+    Entries     []Entry
+}
+
+// Entry is a post or comment in a feed
+type Entry struct {
+    ID          string // Permanent unique identifier, usually the link
+    Title       string
+    Link        string
+    Author      string
+    ContentHTML string
+    Categories  []string
+    Published   time.Time
+    Updated     time.Time
+}
+
+// LastUpdated returns the latest update of the feed's entries, or the feed's own
+// Updated time when it is later. A feed without either was updated now.
+func (f Feed) LastUpdated() time.Time {
+    updated := f.Updated
+    for _, entry := range f.Entries {
+        if entry.Updated.After(updated) {
+            updated = entry.Updated
+        }
+    }
+    if updated.IsZero() {
+        updated = time.Now()
+    }
+    return updated.UTC()
+}
+
+// Render encodes the feed in the given format
+func (f Feed) Render(format string) ([]byte, error) {
+    var doc interface{}
+    if format == FormatAtom {
+        doc = f.atom()
+    } else {
+        doc = f.rss()
+    }
+    body, err := xml.MarshalIndent(doc, "", "  ")
+    if err != nil {
+        return nil, err
+    }
+    return append([]byte(xml.Header), body...), nil
+}
+
+// RSS 2.0 documents, see https://www.rssboard.org/rss-specification
+
+type rssDocument struct {
+    XMLName xml.Name   `xml:"rss"`
+    Version string     `xml:"version,attr"`
+    AtomNS  string     `xml:"xmlns:atom,attr"`
+    DCNS    string     `xml:"xmlns:dc,attr"`
+    Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+    Title         string    `xml:"title"`
+    Link          string    `xml:"link"`
+    Description   string    `xml:"description"`
+    AtomLink      atomLink  `xml:"atom:link"`
+    LastBuildDate string    `xml:"lastBuildDate"`
+    Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+    Title       string   `xml:"title"`
+    Link        string   `xml:"link"`
+    GUID        rssGUID  `xml:"guid"`
+    Author      string   `xml:"dc:creator,omitempty"`
+    Categories  []string `xml:"category"`
+    PubDate     string   `xml:"pubDate"`
+    Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+    IsPermaLink bool   `xml:"isPermaLink,attr"`
+    Value       string `xml:",chardata"`
+}
+
+func (f Feed) rss() rssDocument {
+    channel := rssChannel{
+        Title:         f.Title,
+        Link:          f.Link,
+        Description:   f.Description,
+        AtomLink:      atomLink{Rel: "self", Type: "application/rss+xml", Href: f.SelfLink},
+        LastBuildDate: f.LastUpdated().Format(time.RFC1123Z),
+        Items:         make([]rssItem, 0, len(f.Entries)),
+    }
+    for _, entry := range f.Entries {
+        channel.Items = append(channel.Items, rssItem{
+            Title:       entry.Title,
+            Link:        entry.Link,
+            GUID:        rssGUID{IsPermaLink: entry.ID == entry.Link, Value: entry.ID},
+            Author:      entry.Author,
+            Categories:  entry.Categories,
+            PubDate:     entry.Published.UTC().Format(time.RFC1123Z),
+            Description: entry.ContentHTML,
+        })
+    }
+    return rssDocument{
+        Version: "2.0",
+        AtomNS:  "http://www.w3.org/2005/Atom",
+        DCNS:    "http://purl.org/dc/elements/1.1/",
+        Channel: channel,
+    }
+}
+
+// Atom documents, see RFC 4287
+
+type atomDocument struct {
+    XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+    ID       string      `xml:"id"`
+    Title    string      `xml:"title"`
+    Subtitle string      `xml:"subtitle,omitempty"`
+    Updated  string      `xml:"updated"`
+    Author   *atomAuthor `xml:"author,omitempty"`
+    Links    []atomLink  `xml:"link"`
+    Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+    Rel  string `xml:"rel,attr,omitempty"`
+    Type string `xml:"type,attr,omitempty"`
+    Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+    ID         string         `xml:"id"`
+    Title      string         `xml:"title"`
+    Links      []atomLink     `xml:"link"`
+    Author     *atomAuthor    `xml:"author,omitempty"`
+    Categories []atomCategory `xml:"category"`
+    Published  string         `xml:"published"`
+    Updated    string         `xml:"updated"`
+    Content    atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+    Name string `xml:"name"`
+}
+
+type atomCategory struct {
+    Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+    Type  string `xml:"type,attr"`
+    Value string `xml:",chardata"`
+}
+
+func (f Feed) atom() atomDocument {
+    id := f.ID
+    if id == "" {
+        id = f.SelfLink
+    }
+    doc := atomDocument{
+        ID:       id,
+        Title:    f.Title,
+        Subtitle: f.Description,
+        Updated:  f.LastUpdated().Format(time.RFC3339),
+        Links: []atomLink{
+            {Rel: "self", Type: "application/atom+xml", Href: f.SelfLink},
+            {Rel: "alternate", Type: "text/html", Href: f.Link},
+        },
+        Entries: make([]atomEntry, 0, len(f.Entries)),
+    }
+    // Entries without an author inherit the feed's
+    if f.Author != "" {
+        doc.Author = &atomAuthor{Name: f.Author}
+    }
+    for _, entry := range f.Entries {
+        atomEntry := atomEntry{
+            ID:        entry.ID,
+            Title:     entry.Title,
+            Links:     []atomLink{{Rel: "alternate", Type: "text/html", Href: entry.Link}},
+            Published: entry.Published.UTC().Format(time.RFC3339),
+            Updated:   entry.Updated.UTC().Format(time.RFC3339),
+            Content:   atomContent{Type: "html", Value: entry.ContentHTML},
+        }
+        if entry.Author != "" {
+            atomEntry.Author = &atomAuthor{Name: entry.Author}
+        }
+        for _, category := range entry.Categories {
+            atomEntry.Categories = append(atomEntry.Categories, atomCategory{Term: category})
+        }
+        doc.Entries = append(doc.Entries, atomEntry)
+    }
+    return doc
+}
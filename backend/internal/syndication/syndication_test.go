@@ -0,0 +1,182 @@
+package syndication
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+    published := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+    return Feed{
+        ID:          "https://api.example.com/feed.atom?tags=go",
+        Title:       "Questions & answers",
+        Description: "The newest questions",
+        Author:      "TechQuire",
+        Link:        "https://example.com/feed?tags=go",
+        SelfLink:    "https://api.example.com/feed.atom?limit=5&tags=go",
+        Updated:     published,
+        Entries: []Entry{
+            {
+                ID:          "https://example.com/post/1",
+                Title:       "Why <b> isn't bold",
+                Link:        "https://example.com/post/1",
+                Author:      "alice",
+                ContentHTML: "<p>Text &amp; code</p>",
+                Categories:  []string{"go", "html"},
+                Published:   published,
+                Updated:     published.Add(time.Hour),
+            },
+            {
+                ID:          "https://example.com/post/2#comment-3",
+                Title:       "Anonymous",
+                Link:        "https://example.com/post/2",
+                ContentHTML: "<p>No author</p>",
+                Published:   published,
+                Updated:     published,
+            },
+        },
+    }
+}
+
+func TestRenderAtom(t *testing.T) {
+    body, err := testFeed().Render(FormatAtom)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.HasPrefix(string(body), xml.Header) {
+        t.Errorf("document lacks the XML header")
+    }
+
+    var doc atomDocument
+    if err := xml.Unmarshal(body, &doc); err != nil {
+        t.Fatalf("invalid XML: %v\n%s", err, body)
+    }
+    if doc.ID != "https://api.example.com/feed.atom?tags=go" {
+        t.Errorf("feed id = %q, want the canonical ID, not the self link", doc.ID)
+    }
+    if doc.Title != "Questions & answers" {
+        t.Errorf("title = %q", doc.Title)
+    }
+    if doc.Author == nil || doc.Author.Name != "TechQuire" {
+        t.Errorf("feed author = %v, want TechQuire", doc.Author)
+    }
+    if doc.Updated != "2025-03-01T11:00:00Z" {
+        t.Errorf("updated = %q, want the latest entry update", doc.Updated)
+    }
+    if len(doc.Links) != 2 || doc.Links[0].Rel != "self" || doc.Links[0].Href != "https://api.example.com/feed.atom?limit=5&tags=go" {
+        t.Errorf("links = %+v, want the self link first", doc.Links)
+    }
+
+    if len(doc.Entries) != 2 {
+        t.Fatalf("%d entries, want 2", len(doc.Entries))
+    }
+    first := doc.Entries[0]
+    if first.Title != "Why <b> isn't bold" || first.Content.Type != "html" || first.Content.Value != "<p>Text &amp; code</p>" {
+        t.Errorf("first entry = %+v, want the title and HTML content unchanged", first)
+    }
+    if first.Author == nil || first.Author.Name != "alice" {
+        t.Errorf("first entry author = %v, want alice", first.Author)
+    }
+    if len(first.Categories) != 2 || first.Categories[1].Term != "html" {
+        t.Errorf("first entry categories = %+v", first.Categories)
+    }
+    if doc.Entries[1].Author != nil {
+        t.Errorf("second entry author = %v, want none", doc.Entries[1].Author)
+    }
+}
+
+func TestRenderAtomFallsBackToSelfLink(t *testing.T) {
+    f := testFeed()
+    f.ID = ""
+    if id := f.atom().ID; id != f.SelfLink {
+        t.Errorf("feed id = %q, want the self link %q", id, f.SelfLink)
+    }
+}
+
+func TestRenderRSS(t *testing.T) {
+    body, err := testFeed().Render(FormatRSS)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    var doc struct {
+        Version string `xml:"version,attr"`
+        Channel struct {
+            Title         string `xml:"title"`
+            LastBuildDate string `xml:"lastBuildDate"`
+            Items         []struct {
+                Title   string   `xml:"title"`
+                Creator string   `xml:"creator"`
+                Tags    []string `xml:"category"`
+                GUID    struct {
+                    IsPermaLink bool   `xml:"isPermaLink,attr"`
+                    Value       string `xml:",chardata"`
+                } `xml:"guid"`
+                Description string `xml:"description"`
+            } `xml:"item"`
+        } `xml:"channel"`
+    }
+    if err := xml.Unmarshal(body, &doc); err != nil {
+        t.Fatalf("invalid XML: %v\n%s", err, body)
+    }
+    if doc.Version != "2.0" || doc.Channel.Title != "Questions & answers" {
+        t.Errorf("channel = %+v", doc.Channel)
+    }
+    // Unmarshaling can't tell the channel link from atom:link, so both are checked in the text
+    for _, link := range []string{
+        "<link>https://example.com/feed?tags=go</link>",
+        `<atom:link rel="self" type="application/rss+xml" href="https://api.example.com/feed.atom?limit=5&amp;tags=go"></atom:link>`,
+    } {
+        if !strings.Contains(string(body), link) {
+            t.Errorf("document lacks %s", link)
+        }
+    }
+    if doc.Channel.LastBuildDate != "Sat, 01 Mar 2025 11:00:00 +0000" {
+        t.Errorf("lastBuildDate = %q", doc.Channel.LastBuildDate)
+    }
+    if len(doc.Channel.Items) != 2 {
+        t.Fatalf("%d items, want 2", len(doc.Channel.Items))
+    }
+
+    first, second := doc.Channel.Items[0], doc.Channel.Items[1]
+    if first.Creator != "alice" || len(first.Tags) != 2 || first.Description != "<p>Text &amp; code</p>" {
+        t.Errorf("first item = %+v", first)
+    }
+    if !first.GUID.IsPermaLink {
+        t.Errorf("GUID of an entry identified by its link isn't a permalink")
+    }
+    if second.GUID.IsPermaLink || second.GUID.Value != "https://example.com/post/2#comment-3" {
+        t.Errorf("second item GUID = %+v, want a non-permalink ID", second.GUID)
+    }
+    if strings.Contains(string(body), "<dc:creator></dc:creator>") {
+        t.Errorf("item without an author has an empty creator")
+    }
+}
+
+func TestLastUpdated(t *testing.T) {
+    f := testFeed()
+    if got := f.LastUpdated(); !got.Equal(time.Date(2025, 3, 1, 11, 0, 0, 0, time.UTC)) {
+        t.Errorf("LastUpdated() = %v, want the latest entry", got)
+    }
+
+    f.Updated = time.Date(2025, 4, 1, 0, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+    if got := f.LastUpdated(); !got.Equal(f.Updated) || got.Location() != time.UTC {
+        t.Errorf("LastUpdated() = %v, want the feed's own later update in UTC", got)
+    }
+
+    // An empty feed was updated now, not in year 1
+    before := time.Now().Add(-time.Second)
+    empty := Feed{Title: "Empty"}
+    if got := empty.LastUpdated(); got.Before(before) {
+        t.Errorf("LastUpdated() of an empty feed = %v, want the current time", got)
+    }
+    body, err := empty.Render(FormatAtom)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if strings.Contains(string(body), "0001-01-01") {
+        t.Errorf("empty feed is dated year 1:\n%s", body)
+    }
+}
@@ -0,0 +1,96 @@
+package tagging
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Expert score weights. A solution weighs as much as the reputation it earns.
+const (
+    SolutionScore = 10
+    LikeScore     = 1
+)
+
+// ErrInvalidWindow is returned for an unknown ranking window
+var ErrInvalidWindow = errors.New("window must be 'week', 'month' or 'all'")
+
+// Expert is a user's score in a tag
+type Expert struct {
+    UserID            uint    `json:"user_id"`
+    Username          string  `json:"username"`
+    ProfilePictureURL *string `json:"profile_picture_url" gorm:"column:profile_picture_url"`
+    Score             int64   `json:"score"`
+    Solutions         int64   `json:"solutions"`
+    Likes             int64   `json:"likes"`
+}
+
+// TagScore is a user's score in one of their tags
+type TagScore struct {
+    Tag       string `json:"tag"`
+    Score     int64  `json:"score"`
+    Solutions int64  `json:"solutions"`
+    Likes     int64  `json:"likes"`
+}
+
+// scoreColumns computes solutions, likes and the combined score of grouped comments
+const scoreColumns = `COUNT(*) FILTER (WHERE comments.is_solution) AS solutions,
+    COALESCE(SUM(comments.likes), 0) AS likes,
+    COUNT(*) FILTER (WHERE comments.is_solution) * ? + COALESCE(SUM(comments.likes), 0) * ? AS score`
+
+// scoreHaving keeps only users with a positive score
+const scoreHaving = `COUNT(*) FILTER (WHERE comments.is_solution) * ? + COALESCE(SUM(comments.likes), 0) * ? > 0`
+
+// WindowStart returns the start of a ranking window: "week", "month" or "all".
+// The zero time is returned for "all".
+func WindowStart(window string) (time.Time, error) {
+    switch window {
+    case "week":
+        return time.Now().AddDate(0, 0, -7), nil
+    case "month":
+        return time.Now().AddDate(0, -1, 0), nil
+    case "", "all":
+        return time.Time{}, nil
+    default:
+        return time.Time{}, ErrInvalidWindow
+    }
+}
+
+// Experts ranks the users answering posts with the given tag by the solutions and likes
+// their comments received, counting comments written since the given time
+func Experts(db *gorm.DB, tag string, since time.Time, limit int) ([]Expert, error) {
+    query := db.Table("comments").
+        Select("users.id AS user_id, users.username, users.profile_picture_url, "+scoreColumns, SolutionScore, LikeScore).
+        Joins("JOIN posts ON posts.id = comments.post_id").
+        Joins("JOIN users ON users.id = comments.user_id").
+        Where("? = ANY(posts.tags)", tag)
+    if !since.IsZero() {
+        query = query.Where("comments.created_at >= ?", since)
+    }
+
+    var experts []Expert
+    err := query.
+        Group("users.id").
+        Having(scoreHaving, SolutionScore, LikeScore).
+        Order("score DESC, solutions DESC, users.username ASC").
+        Limit(limit).
+        Scan(&experts).Error
+    return experts, err
+}
+
+// TopTags returns the tags in which a user scores highest across all time
+func TopTags(db *gorm.DB, userID uint, limit int) ([]TagScore, error) {
+    var scores []TagScore
+    err := db.Table("comments").
+        Select("tag, "+scoreColumns, SolutionScore, LikeScore).
+        Joins("JOIN posts ON posts.id = comments.post_id").
+        Joins("CROSS JOIN UNNEST(posts.tags) AS tag").
+        Where("comments.user_id = ?", userID).
+        Group("tag").
+        Having(scoreHaving, SolutionScore, LikeScore).
+        Order("score DESC, tag ASC").
+        Limit(limit).
+        Scan(&scores).Error
+    return scores, err
+}
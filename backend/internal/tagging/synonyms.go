@@ -0,0 +1,203 @@
+package tagging
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/models"
+)
+
+var (
+    // ErrTagNotFound is returned when a tag taking part in a synonym or merge does not exist
+    ErrTagNotFound = errors.New("tag not found")
+    // ErrSameTag is returned when a tag would be mapped or merged onto itself
+    ErrSameTag = errors.New("source and target tag are the same")
+    // ErrSynonymIsTag is returned when a synonym name is already a tag; such tags need to be merged instead
+    ErrSynonymIsTag = errors.New("synonym is an existing tag, merge it instead")
+    // ErrSynonymExists is returned when a synonym name is already mapped
+    ErrSynonymExists = errors.New("synonym already exists")
+)
+
+// Canonicalize normalizes tag names and replaces synonyms with their canonical tag,
+// dropping duplicates that result from the mapping
+func Canonicalize(db *gorm.DB, names []string) ([]string, error) {
+    normalized := NormalizeAll(names)
+    if len(normalized) == 0 {
+        return normalized, nil
+    }
+
+    type SynonymResult struct {
+        Synonym string
+        TagName string
+    }
+    var synonyms []SynonymResult
+    if err := db.Table("tag_synonyms").
+        Select("tag_synonyms.name AS synonym, tags.name AS tag_name").
+        Joins("JOIN tags ON tags.id = tag_synonyms.tag_id").
+        Where("tag_synonyms.name IN ?", normalized).
+        Scan(&synonyms).Error; err != nil {
+        return nil, err
+    }
+    if len(synonyms) == 0 {
+        return normalized, nil
+    }
+
+    synonymMap := make(map[string]string)
+    for _, synonym := range synonyms {
+        synonymMap[synonym.Synonym] = synonym.TagName
+    }
+
+    seen := make(map[string]bool)
+    canonical := make([]string, 0, len(normalized))
+    for _, tag := range normalized {
+        if target, ok := synonymMap[tag]; ok {
+            tag = target
+        }
+        if seen[tag] {
+            continue
+        }
+        seen[tag] = true
+        canonical = append(canonical, tag)
+    }
+    return canonical, nil
+}
+
+// AddSynonym maps the synonym name to the given canonical tag
+func AddSynonym(db *gorm.DB, synonymName string, tagName string) (*models.TagSynonym, error) {
+    synonymName = Normalize(synonymName)
+    tagName = Normalize(tagName)
+    if synonymName == "" || tagName == "" {
+        return nil, ErrTagNotFound
+    }
+    if synonymName == tagName {
+        return nil, ErrSameTag
+    }
+
+    var tag models.Tag
+    if err := db.Where("name = ?", tagName).First(&tag).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            return nil, ErrTagNotFound
+        }
+        return nil, err
+    }
+
+    var count int64
+    if err := db.Model(&models.Tag{}).Where("name = ?", synonymName).Count(&count).Error; err != nil {
+        return nil, err
+    }
+    if count > 0 {
+        return nil, ErrSynonymIsTag
+    }
+    if err := db.Model(&models.TagSynonym{}).Where("name = ?", synonymName).Count(&count).Error; err != nil {
+        return nil, err
+    }
+    if count > 0 {
+        return nil, ErrSynonymExists
+    }
+
+    synonym := models.TagSynonym{
+        Name:  synonymName,
+        TagID: tag.ID,
+    }
+    if err := db.Create(&synonym).Error; err != nil {
+        return nil, err
+    }
+    return &synonym, nil
+}
+
+// Merge rewrites every post from the source tag to the target tag in one transaction.
+// The source tag is deleted and becomes a synonym of the target, its own synonyms and
+// follow/ignore preferences are moved to the target and its description and wiki are
+// kept if the target has none.
+// It returns the IDs of the rewritten posts, for the caller to publish their update once
+// the merge is committed.
+func Merge(db *gorm.DB, sourceName string, targetName string) ([]uint, error) {
+    sourceName = Normalize(sourceName)
+    targetName = Normalize(targetName)
+    if sourceName == targetName {
+        return nil, ErrSameTag
+    }
+
+    var rewritten []uint
+    err := db.Transaction(func(tx *gorm.DB) error {
+        var source, target models.Tag
+        if err := tx.Where("name = ?", sourceName).First(&source).Error; err != nil {
+            if err == gorm.ErrRecordNotFound {
+                return ErrTagNotFound
+            }
+            return err
+        }
+        if err := tx.Where("name = ?", targetName).First(&target).Error; err != nil {
+            if err == gorm.ErrRecordNotFound {
+                return ErrTagNotFound
+            }
+            return err
+        }
+
+        if err := tx.Model(&models.Post{}).
+            Where("? = ANY(tags)", source.Name).
+            Order("id").
+            Pluck("id", &rewritten).Error; err != nil {
+            return err
+        }
+
+        // Posts that already have the target tag only lose the source tag. The posts count
+        // as updated, so their ETags change.
+        if err := tx.Exec(`UPDATE posts SET tags = array_remove(tags, ?), updated_at = NOW()
+            WHERE ? = ANY(tags) AND ? = ANY(tags)`, source.Name, source.Name, target.Name).Error; err != nil {
+            return err
+        }
+
+        // The others get the source tag replaced in place
+        if err := tx.Exec(`UPDATE posts SET tags = array_replace(tags, ?, ?), updated_at = NOW()
+            WHERE ? = ANY(tags)`, source.Name, target.Name, source.Name).Error; err != nil {
+            return err
+        }
+
+        // Keep the documentation of the source tag if the target has none
+        if target.Description == "" {
+            target.Description = source.Description
+        }
+        if target.Wiki == "" {
+            target.Wiki = source.Wiki
+        }
+        if err := tx.Save(&target).Error; err != nil {
+            return err
+        }
+
+        // Move follow and ignore preferences to the target, unless the user already has one for it
+        if err := tx.Exec(`DELETE FROM tag_preferences WHERE tag_id = ? AND user_id IN (
+                SELECT user_id FROM tag_preferences WHERE tag_id = ?
+            )`, source.ID, target.ID).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&models.TagPreference{}).
+            Where("tag_id = ?", source.ID).
+            Update("tag_id", target.ID).Error; err != nil {
+            return err
+        }
+
+        // Move synonyms of the source to the target and map the source name itself
+        if err := tx.Model(&models.TagSynonym{}).
+            Where("tag_id = ?", source.ID).
+            Update("tag_id", target.ID).Error; err != nil {
+            return err
+        }
+        if err := tx.Delete(&source).Error; err != nil {
+            return err
+        }
+        if err := tx.Create(&models.TagSynonym{Name: source.Name, TagID: target.ID}).Error; err != nil {
+            return err
+        }
+
+        // Recount the target from the rewritten posts
+        return tx.Exec(`UPDATE tags SET usage_count = (
+                SELECT COUNT(*) FROM posts WHERE tags.name = ANY(posts.tags)
+            ) WHERE id = ?`, target.ID).Error
+    })
+    if err != nil {
+        return nil, err
+    }
+    return rewritten, nil
+}
@@ -0,0 +1,111 @@
+package tagging
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/models"
+)
+
+// MaxTagLength is the maximum length of a normalized tag name, in characters
+const MaxTagLength = 35
+
+var separatorPattern = regexp.MustCompile(`[\s_]+`)
+
+// Normalize turns user input into the canonical form of a tag name:
+// lowercase, trimmed, without a leading '#' and with inner spaces replaced by dashes.
+// "Go ", "#go" and "GO" all become "go"; "Spring Boot" becomes "spring-boot".
+func Normalize(name string) string {
+    name = strings.ToLower(strings.TrimSpace(name))
+    name = strings.TrimLeft(name, "#")
+    name = separatorPattern.ReplaceAllString(strings.TrimSpace(name), "-")
+    name = strings.Trim(name, "-")
+    // Truncated by characters, cutting bytes could split one and leave invalid UTF-8
+    if runes := []rune(name); len(runes) > MaxTagLength {
+        name = strings.TrimRight(string(runes[:MaxTagLength]), "-")
+    }
+    return name
+}
+
+// NormalizeAll normalizes a list of tag names, dropping empty and duplicate entries
+// while keeping the original order
+func NormalizeAll(names []string) []string {
+    seen := make(map[string]bool)
+    normalized := make([]string, 0, len(names))
+    for _, name := range names {
+        tag := Normalize(name)
+        if tag == "" || seen[tag] {
+            continue
+        }
+        seen[tag] = true
+        normalized = append(normalized, tag)
+    }
+    return normalized
+}
+
+// UpdateUsage adjusts tag usage counts for a post whose tags changed from oldTags to newTags.
+// Tags that do not exist yet are created. Pass nil oldTags for a new post and nil newTags
+// for a deleted one.
+func UpdateUsage(db *gorm.DB, oldTags, newTags []string) error {
+    oldSet := make(map[string]bool)
+    for _, tag := range oldTags {
+        oldSet[tag] = true
+    }
+    newSet := make(map[string]bool)
+    for _, tag := range newTags {
+        newSet[tag] = true
+    }
+
+    // Increment counts of added tags, creating them if needed
+    now := time.Now()
+    for tag := range newSet {
+        if oldSet[tag] {
+            continue
+        }
+        newTag := models.Tag{Name: tag, UsageCount: 1, CreatedAt: now, UpdatedAt: now}
+        if err := db.Clauses(clause.OnConflict{
+            Columns: []clause.Column{{Name: "name"}},
+            DoUpdates: clause.Assignments(map[string]interface{}{
+                "usage_count": gorm.Expr("tags.usage_count + 1"),
+                "updated_at":  now,
+            }),
+        }).Create(&newTag).Error; err != nil {
+            return err
+        }
+    }
+
+    // Decrement counts of removed tags. Unused tags are kept for their descriptions.
+    removed := []string{}
+    for tag := range oldSet {
+        if !newSet[tag] {
+            removed = append(removed, tag)
+        }
+    }
+    if len(removed) > 0 {
+        if err := db.Model(&models.Tag{}).
+            Where("name IN ?", removed).
+            Update("usage_count", gorm.Expr("GREATEST(usage_count - 1, 0)")).Error; err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// RecountUsage creates missing tags and recomputes every usage count from the posts table
+func RecountUsage(db *gorm.DB) error {
+    if err := db.Exec(`
+        INSERT INTO tags (name, usage_count, created_at, updated_at)
+        SELECT DISTINCT tag, 0, NOW(), NOW() FROM posts, UNNEST(posts.tags) AS tag
+        ON CONFLICT (name) DO NOTHING`).Error; err != nil {
+        return err
+    }
+    return db.Exec(`
+        UPDATE tags SET usage_count = (
+            SELECT COUNT(*) FROM posts WHERE tags.name = ANY(posts.tags)
+        )`).Error
+}
@@ -0,0 +1,55 @@
+package tagging
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalize(t *testing.T) {
+    tests := []struct {
+        name  string
+        input string
+        want  string
+    }{
+        {"lowercase", "GO", "go"},
+        {"trimmed", "  go ", "go"},
+        {"leading hash", "#go", "go"},
+        {"several hashes", "##go", "go"},
+        {"spaces become dashes", "Spring Boot", "spring-boot"},
+        {"underscores become dashes", "spring_boot", "spring-boot"},
+        {"runs of separators", "spring \t _ boot", "spring-boot"},
+        {"outer dashes", "-go-", "go"},
+        {"empty", "   ", ""},
+        {"only hash", "#", ""},
+        {"non-ASCII", "Überwachung", "überwachung"},
+        {"truncated", strings.Repeat("a", 40), strings.Repeat("a", MaxTagLength)},
+        {"truncated before a dash", strings.Repeat("a", 34) + "-bcd", strings.Repeat("a", 34)},
+        {"truncated by characters", strings.Repeat("é", 40), strings.Repeat("é", MaxTagLength)},
+        {"truncated mixed width", "a" + strings.Repeat("日本", 20), "a" + strings.Repeat("日本", 17)},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            got := Normalize(tt.input)
+            if got != tt.want {
+                t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+            }
+            if !utf8.ValidString(got) {
+                t.Errorf("Normalize(%q) = %q is not valid UTF-8", tt.input, got)
+            }
+            if utf8.RuneCountInString(got) > MaxTagLength {
+                t.Errorf("Normalize(%q) = %q is longer than %d characters", tt.input, got, MaxTagLength)
+            }
+        })
+    }
+}
+
+func TestNormalizeAll(t *testing.T) {
+    got := NormalizeAll([]string{"Go", "#go", "", "Spring Boot", "  ", "spring-boot", "rust"})
+    want := []string{"go", "spring-boot", "rust"}
+    if !reflect.DeepEqual(got, want) {
+        t.Errorf("NormalizeAll() = %q, want %q", got, want)
+    }
+}
@@ -0,0 +1,261 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"techquire-backend/internal/models"
+)
+
+// DefaultInterval is how often the worker looks for deliveries that are due
+const DefaultInterval = 10 * time.Second
+
+const (
+    // maxAttempts is how often a delivery is tried before it fails for good. With the
+    // backoff, the last attempt is about half an hour after the first.
+    maxAttempts = 6
+    // retryBackoff is the delay before the first retry, doubled for every further one
+    retryBackoff = time.Minute
+    // maxConsecutiveFailures is how many deliveries in a row may fail before the webhook
+    // is disabled
+    maxConsecutiveFailures = 5
+
+    requestTimeout  = 5 * time.Second
+    maxResponseBody = 1024
+    batchSize       = 50
+    // claimLease is how long claimed deliveries are hidden from other runs. It outlasts
+    // sending a whole batch to one webhook, and a crashed run's claims lapse after it.
+    claimLease = batchSize*requestTimeout + time.Minute
+)
+
+// Headers of the webhook requests. The signature covers the timestamp and the body, so a
+// captured request can't be replayed later with a new timestamp.
+const (
+    EventHeader     = "X-Techquire-Event"
+    DeliveryHeader  = "X-Techquire-Delivery"
+    TimestampHeader = "X-Techquire-Timestamp"
+    SignatureHeader = "X-Techquire-Signature"
+)
+
+var client = &http.Client{Timeout: requestTimeout}
+
+// StartWorker sends the deliveries that are due in the background
+func StartWorker(db *gorm.DB, interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            if err := Run(db); err != nil {
+                log.Printf("[WEBHOOKS] Failed to send deliveries: %v", err)
+            }
+        }
+    }()
+}
+
+// Run attempts the pending deliveries whose next attempt is due, oldest first. Webhooks
+// are sent to concurrently, so a slow endpoint only holds up its own deliveries.
+func Run(db *gorm.DB) error {
+    deliveries, err := claim(db, time.Now())
+    if err != nil {
+        return err
+    }
+
+    var webhookIDs []uint
+    byWebhook := make(map[uint][]models.WebhookDelivery)
+    for _, delivery := range deliveries {
+        if _, ok := byWebhook[delivery.WebhookID]; !ok {
+            webhookIDs = append(webhookIDs, delivery.WebhookID)
+        }
+        byWebhook[delivery.WebhookID] = append(byWebhook[delivery.WebhookID], delivery)
+    }
+
+    var wg sync.WaitGroup
+    for _, webhookID := range webhookIDs {
+        wg.Add(1)
+        go func(webhookID uint) {
+            defer wg.Done()
+            if err := Deliver(db, webhookID, byWebhook[webhookID]); err != nil {
+                log.Printf("[WEBHOOKS] Failed to record deliveries of webhook %d: %v", webhookID, err)
+            }
+        }(webhookID)
+    }
+    wg.Wait()
+    return nil
+}
+
+// claim takes the deliveries due at now by moving their next attempt past the lease.
+// Rows another instance is claiming are skipped rather than waited for, so every
+// delivery is claimed by one run only.
+func claim(db *gorm.DB, now time.Time) ([]models.WebhookDelivery, error) {
+    var deliveries []models.WebhookDelivery
+    err := db.Transaction(func(tx *gorm.DB) error {
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+            Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+            Order("next_attempt_at ASC, id ASC").
+            Limit(batchSize).
+            Find(&deliveries).Error; err != nil {
+            return err
+        }
+        if len(deliveries) == 0 {
+            return nil
+        }
+
+        ids := make([]uint, len(deliveries))
+        for i, delivery := range deliveries {
+            ids[i] = delivery.ID
+        }
+        return tx.Model(&models.WebhookDelivery{}).
+            Where("id IN ?", ids).
+            Update("next_attempt_at", now.Add(claimLease)).Error
+    })
+    return deliveries, err
+}
+
+// Sign returns the signature header value of a request body sent at a timestamp:
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret
+func Sign(secret string, timestamp int64, body []byte) string {
+    mac := hmac.New(sha256.New, []byte(secret))
+    fmt.Fprintf(mac, "%d.", timestamp)
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliver attempts claimed deliveries of a webhook in order, once each. Deliveries to
+// webhooks that were deleted or disabled in the meantime fail without an attempt. After
+// a failed attempt the rest are given back for the next run, so an unreachable endpoint
+// costs one request timeout per run rather than one per delivery.
+func Deliver(db *gorm.DB, webhookID uint, deliveries []models.WebhookDelivery) error {
+    ids := make([]uint, len(deliveries))
+    for i, delivery := range deliveries {
+        ids[i] = delivery.ID
+    }
+
+    var webhook models.Webhook
+    if err := db.First(&webhook, webhookID).Error; err != nil {
+        if err != gorm.ErrRecordNotFound {
+            return err
+        }
+        webhook.IsActive = false
+    }
+    if !webhook.IsActive {
+        return db.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+            "status":          models.DeliveryFailed,
+            "next_attempt_at": nil,
+            "error":           "webhook is disabled",
+        }).Error
+    }
+
+    for i, delivery := range deliveries {
+        succeeded, err := attempt(db, &webhook, delivery)
+        if err != nil {
+            return err
+        }
+        if !succeeded {
+            if rest := ids[i+1:]; len(rest) > 0 {
+                return db.Model(&models.WebhookDelivery{}).
+                    Where("id IN ?", rest).
+                    Update("next_attempt_at", time.Now()).Error
+            }
+            return nil
+        }
+    }
+    return nil
+}
+
+// attempt sends a delivery once and records the outcome: success, a retry after the
+// backoff, or failure once the attempts are used up. It reports whether it succeeded.
+func attempt(db *gorm.DB, webhook *models.Webhook, delivery models.WebhookDelivery) (bool, error) {
+    status, body, err := send(*webhook, delivery)
+    delivery.Attempts++
+    delivery.ResponseStatus = status
+    delivery.ResponseBody = body
+    delivery.Error = ""
+    if err != nil {
+        delivery.Error = err.Error()
+    }
+
+    now := time.Now()
+    switch {
+    case err == nil:
+        delivery.Status = models.DeliverySucceeded
+        delivery.NextAttemptAt = nil
+        delivery.DeliveredAt = &now
+    case delivery.Attempts < maxAttempts:
+        next := now.Add(retryBackoff << (delivery.Attempts - 1))
+        delivery.NextAttemptAt = &next
+    default:
+        delivery.Status = models.DeliveryFailed
+        delivery.NextAttemptAt = nil
+    }
+    if err := db.Save(&delivery).Error; err != nil {
+        return false, err
+    }
+
+    switch delivery.Status {
+    case models.DeliverySucceeded:
+        if webhook.FailureCount > 0 {
+            webhook.FailureCount = 0
+            return true, db.Model(webhook).Update("failure_count", 0).Error
+        }
+        return true, nil
+    case models.DeliveryFailed:
+        return false, recordFailure(db, *webhook)
+    }
+    return false, nil
+}
+
+// recordFailure counts a failed delivery and disables the webhook after too many in a row
+func recordFailure(db *gorm.DB, webhook models.Webhook) error {
+    updates := map[string]interface{}{"failure_count": gorm.Expr("failure_count + 1")}
+    if webhook.FailureCount+1 >= maxConsecutiveFailures {
+        updates["is_active"] = false
+        updates["disabled_at"] = time.Now()
+        log.Printf("[WEBHOOKS] Disabled webhook %d after %d failed deliveries", webhook.ID, webhook.FailureCount+1)
+    }
+    return db.Model(&webhook).Updates(updates).Error
+}
+
+// send posts a delivery and returns the response status and the start of its body. Any
+// status outside 2xx is an error.
+func send(webhook models.Webhook, delivery models.WebhookDelivery) (int, string, error) {
+    body := []byte(delivery.Payload)
+    timestamp := time.Now().Unix()
+
+    request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+    if err != nil {
+        return 0, "", err
+    }
+    request.Header.Set("Content-Type", "application/json")
+    request.Header.Set("User-Agent", "TechQuire-Webhooks/1.0")
+    request.Header.Set(EventHeader, delivery.Event)
+    request.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(delivery.ID), 10))
+    request.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+    request.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, body))
+
+    response, err := client.Do(request)
+    if err != nil {
+        return 0, "", err
+    }
+    defer response.Body.Close()
+
+    // Postgres text can't hold NUL bytes or invalid UTF-8, the body is kept for display only
+    responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+    printable := strings.ToValidUTF8(strings.ReplaceAll(string(responseBody), "\x00", ""), "")
+    if response.StatusCode < 200 || response.StatusCode > 299 {
+        return response.StatusCode, printable, fmt.Errorf("endpoint responded with %s", response.Status)
+    }
+    return response.StatusCode, printable, nil
+}
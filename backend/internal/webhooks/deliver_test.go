@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"techquire-backend/internal/models"
+)
+
+func TestSign(t *testing.T) {
+    const body = `{"event":"post.created"}`
+    // Computed with: printf '%s' '<timestamp>.<body>' | openssl dgst -sha256 -hmac secret
+    const signed = "sha256=ce7ebc251a37a25867cae2a4ed02967911662d8d668255c48239a28f21c35edc"
+
+    tests := []struct {
+        name      string
+        secret    string
+        timestamp int64
+        body      string
+        want      bool
+    }{
+        {"matches openssl", "secret", 1700000000, body, true},
+        {"other secret", "other", 1700000000, body, false},
+        {"other timestamp", "secret", 1700000001, body, false},
+        {"other body", "secret", 1700000000, body + " ", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)) == signed; got != tt.want {
+                t.Errorf("Sign() == %q is %v, want %v", signed, got, tt.want)
+            }
+        })
+    }
+
+    if got, want := Sign("secret", 0, nil), "sha256=3445798a051818ef95def46c2eb62b43d377ce6e3c29b4d0aec3da0e59577f79"; got != want {
+        t.Errorf("Sign() of an empty body = %q, want %q", got, want)
+    }
+}
+
+func TestSend(t *testing.T) {
+    tests := []struct {
+        name     string
+        status   int
+        response string
+        wantBody string
+        wantErr  bool
+    }{
+        {"accepted", http.StatusOK, "ok", "ok", false},
+        {"no content", http.StatusNoContent, "", "", false},
+        {"server error", http.StatusInternalServerError, "boom", "boom", true},
+        {"3xx is not a success", http.StatusNotModified, "", "", true},
+        {"unprintable body", http.StatusOK, "a\x00b\xff", "ab", false},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var request *http.Request
+            var signature string
+            server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                request = r
+                timestamp, _ := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+                signature = Sign("secret", timestamp, []byte(`{"x":1}`))
+                w.WriteHeader(tt.status)
+                w.Write([]byte(tt.response))
+            }))
+            defer server.Close()
+
+            webhook := models.Webhook{URL: server.URL, Secret: "secret"}
+            delivery := models.WebhookDelivery{ID: 42, Event: "post.created", Payload: `{"x":1}`}
+            status, body, err := send(webhook, delivery)
+            if (err != nil) != tt.wantErr {
+                t.Fatalf("send() error = %v, want error %v", err, tt.wantErr)
+            }
+            if status != tt.status || body != tt.wantBody {
+                t.Errorf("send() = %d, %q, want %d, %q", status, body, tt.status, tt.wantBody)
+            }
+            if got := request.Header.Get(SignatureHeader); got != signature {
+                t.Errorf("%s = %q, want %q", SignatureHeader, got, signature)
+            }
+            if request.Header.Get(EventHeader) != "post.created" || request.Header.Get(DeliveryHeader) != "42" {
+                t.Errorf("event headers = %q, %q", request.Header.Get(EventHeader), request.Header.Get(DeliveryHeader))
+            }
+        })
+    }
+}
+
+func TestSendUnreachable(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+    server.Close()
+
+    status, _, err := send(models.Webhook{URL: server.URL}, models.WebhookDelivery{Payload: "{}"})
+    if err == nil || status != 0 {
+        t.Errorf("send() = %d, %v, want a transport error without status", status, err)
+    }
+}
@@ -0,0 +1,143 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+
+	"techquire-backend/internal/events"
+	"techquire-backend/internal/mailer"
+	"techquire-backend/internal/models"
+)
+
+// eventNames maps the application events webhooks can subscribe to onto the names
+// used in payloads. The names are part of the public API and don't follow renames
+// of the internal events.
+var eventNames = map[events.Type]string{
+    events.PostCreated:    "post.created",
+    events.PostUpdated:    "post.updated",
+    events.CommentCreated: "comment.created",
+    events.SolutionMarked: "solution.marked",
+    events.MetooAdded:     "metoo.added",
+}
+
+// EventNames returns the events webhooks can subscribe to, sorted
+func EventNames() []string {
+    names := make([]string, 0, len(eventNames))
+    for _, name := range eventNames {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// ValidEvent reports whether webhooks can subscribe to an event
+func ValidEvent(name string) bool {
+    for _, known := range eventNames {
+        if known == name {
+            return true
+        }
+    }
+    return false
+}
+
+// NewSecret generates a random signing secret for a webhook
+func NewSecret() (string, error) {
+    buf := make([]byte, 32)
+    if _, err := rand.Read(buf); err != nil {
+        return "", err
+    }
+    return hex.EncodeToString(buf), nil
+}
+
+// Payload is the JSON body of a webhook request
+type Payload struct {
+    Event      string      `json:"event"`
+    OccurredAt time.Time   `json:"occurred_at"`
+    Data       PayloadData `json:"data"`
+}
+
+// PayloadData identifies what an event is about. Receivers fetch the details they need
+// from the API.
+type PayloadData struct {
+    PostID    uint   `json:"post_id"`
+    CommentID uint   `json:"comment_id,omitempty"`
+    UserID    uint   `json:"user_id"`
+    URL       string `json:"url"`
+}
+
+// Sync queues a delivery to every active webhook subscribed to an event. Deliveries are
+// sent by the worker, so a slow endpoint doesn't hold up the request that caused it.
+func Sync(db *gorm.DB) {
+    events.Subscribe(func(event events.Event) {
+        name, ok := eventNames[event.Type]
+        if !ok {
+            return
+        }
+        if err := enqueue(db, name, event); err != nil {
+            log.Printf("[WEBHOOKS] Failed to queue %s (post %d, comment %d): %v", name, event.PostID, event.CommentID, err)
+        }
+    })
+}
+
+// enqueue creates the pending deliveries of an event
+func enqueue(db *gorm.DB, name string, event events.Event) error {
+    var webhooks []models.Webhook
+    if err := db.Where("is_active = ? AND ? = ANY(events)", true, name).Find(&webhooks).Error; err != nil {
+        return err
+    }
+    if len(webhooks) == 0 {
+        return nil
+    }
+
+    url := mailer.Link(fmt.Sprintf("/post/%d", event.PostID))
+    if event.CommentID != 0 {
+        url += fmt.Sprintf("#comment-%d", event.CommentID)
+    }
+    payload, err := json.Marshal(Payload{
+        Event:      name,
+        OccurredAt: time.Now().UTC(),
+        Data: PayloadData{
+            PostID:    event.PostID,
+            CommentID: event.CommentID,
+            UserID:    event.UserID,
+            URL:       url,
+        },
+    })
+    if err != nil {
+        return err
+    }
+
+    now := time.Now()
+    deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+    for _, webhook := range webhooks {
+        deliveries = append(deliveries, models.WebhookDelivery{
+            WebhookID:     webhook.ID,
+            Event:         name,
+            Payload:       string(payload),
+            Status:        models.DeliveryPending,
+            NextAttemptAt: &now,
+        })
+    }
+    return db.Create(&deliveries).Error
+}
+
+// Redeliver queues a new delivery of the payload of an earlier one
+func Redeliver(db *gorm.DB, delivery models.WebhookDelivery) (models.WebhookDelivery, error) {
+    now := time.Now()
+    redelivery := models.WebhookDelivery{
+        WebhookID:     delivery.WebhookID,
+        Event:         delivery.Event,
+        Payload:       delivery.Payload,
+        Status:        models.DeliveryPending,
+        NextAttemptAt: &now,
+    }
+    err := db.Create(&redelivery).Error
+    return redelivery, err
+}
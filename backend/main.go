@@ -9,9 +9,19 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/joho/godotenv"
 
+	"techquire-backend/internal/alerts"
+	"techquire-backend/internal/badges"
+	"techquire-backend/internal/cache"
 	"techquire-backend/internal/database"
+	"techquire-backend/internal/digest"
+	"techquire-backend/internal/mailer"
 	"techquire-backend/internal/models"
+	"techquire-backend/internal/notifications"
+	"techquire-backend/internal/realtime"
+	"techquire-backend/internal/reputation"
 	"techquire-backend/internal/routes"
+	"techquire-backend/internal/search"
+	"techquire-backend/internal/webhooks"
 )
 
 func main() {
@@ -46,16 +56,75 @@ func main() {
         log.Printf("WARNING: JWT_SECRET is not set!")
     }
 
+    // Commands run against the existing database instead of starting the server
+    if len(os.Args) > 1 {
+        runCommand(os.Args[1])
+        return
+    }
+
     // 1. Connect DB
     database.ConnectDB()
 
     // 2. Auto-migrate models
     database.DB.AutoMigrate(&models.User{})
 
-    // 3. Initialize Fiber
+    // 3. Open the search index, response cache and real-time hub, start background workers
+    index, err := search.Open(database.DB)
+    if err != nil {
+        log.Fatalf("Failed to open search index: %v", err)
+    }
+    defer index.Close()
+    search.Default = index
+    search.Sync(database.DB, index)
+    // The index is kept in sync through events. SEARCH_REINDEX_ON_START rebuilds it from the
+    // database, which an index of its own (Bleve) needs after the development re-seeding.
+    if os.Getenv("SEARCH_REINDEX_ON_START") == "true" {
+        if err := search.Reindex(database.DB, index); err != nil {
+            log.Fatalf("Failed to build search index: %v", err)
+        }
+    }
+
+    responseCache, err := cache.Open()
+    if err != nil {
+        log.Fatalf("Failed to open cache: %v", err)
+    }
+    if responseCache != nil {
+        defer responseCache.Close()
+        cache.Default = responseCache
+        cache.Sync(responseCache)
+    }
+
+    broker, err := realtime.Open()
+    if err != nil {
+        log.Fatalf("Failed to open real-time broker: %v", err)
+    }
+    hub, err := realtime.NewHub(broker)
+    if err != nil {
+        log.Fatalf("Failed to start real-time hub: %v", err)
+    }
+    defer hub.Close()
+    realtime.Default = hub
+    realtime.Sync(database.DB, hub)
+
+    mail := mailer.FromEnv()
+    notifications.Sync(database.DB, mail)
+    alerts.StartWorker(database.DB, mail, alerts.DefaultInterval)
+    digest.StartWorker(database.DB, mail, digest.DefaultInterval)
+
+    webhooks.Sync(database.DB)
+    webhooks.StartWorker(database.DB, webhooks.DefaultInterval)
+
+    badgeRules, err := badges.LoadRules()
+    if err != nil {
+        log.Fatalf("Failed to load badge rules: %v", err)
+    }
+    badges.Catalog = badgeRules
+    badges.StartWorker(database.DB, badges.DefaultInterval)
+
+    // 4. Initialize Fiber
     app := fiber.New()
 
-    // 4. Enable CORS (allows all origins, methods, etc.)
+    // 5. Enable CORS (allows all origins, methods, etc.)
     app.Use(cors.New(cors.Config{
         AllowOrigins: "*",
         AllowMethods: "GET,POST,PUT,DELETE,OPTIONS",
@@ -87,9 +156,40 @@ func main() {
     // Set up static file serving
     app.Static("/static", "./static")
 
-    // 5. Setup routes
+    // 6. Setup routes
     routes.SetupRoutes(app)
 
-    // 6. Start server
+    // 7. Start server
     log.Fatal(app.Listen(":8080"))
 }
+
+// runCommand runs a maintenance command:
+//   reindex             rebuilds the search index from the database, while no server has
+//                       it open (POST /search/reindex reindexes a running server)
+//   rebuild-reputation  rebuilds the reputation ledger from reactions and solutions
+func runCommand(command string) {
+    database.Connect()
+
+    switch command {
+    case "reindex":
+        index, err := search.Open(database.DB)
+        if err == search.ErrIndexLocked {
+            log.Fatalf("The search index is open in a running server, reindex it with POST /search/reindex instead")
+        }
+        if err != nil {
+            log.Fatalf("Failed to open search index: %v", err)
+        }
+        defer index.Close()
+        if err := search.Reindex(database.DB, index); err != nil {
+            log.Fatalf("Failed to reindex: %v", err)
+        }
+        log.Println("Search index rebuilt")
+    case "rebuild-reputation":
+        if err := reputation.Rebuild(database.DB); err != nil {
+            log.Fatalf("Failed to rebuild reputation: %v", err)
+        }
+        log.Println("Reputation rebuilt")
+    default:
+        log.Fatalf("Unknown command %q, expected: reindex, rebuild-reputation", command)
+    }
+}
@@ -13,6 +13,7 @@ import Feed from "./routes/Feed";
 import Profile from "./routes/Profile";
 import AccountSettings from "./routes/AccountSettings";
 import OpenedPost from "./routes/OpenedPost";
+import ChatLink from "./routes/ChatLink";
 
 function App() {
   const dispatch = useDispatch<AppDispatch>();
@@ -34,6 +35,7 @@ function App() {
             <Route path="/post/:post_id" element={<OpenedPost />} />
             <Route path="/profile/:handle" element={<Profile />} />
             <Route path="/settings" element={<AccountSettings />} />
+            <Route path="/chat/link" element={<ChatLink />} />
           </Routes>
         </MainLayout>
       </BrowserRouter>
@@ -0,0 +1,168 @@
+import { Button } from "@/components/atoms/Button";
+import { RootState } from "@/lib/store";
+import { cn } from "@/lib/utils";
+import axios from "axios";
+import { Link2, Loader2 } from "lucide-react";
+import { useEffect, useState } from "react";
+import { useSelector } from "react-redux";
+import { Link, useNavigate, useSearchParams } from "react-router";
+import { toast } from "react-toastify";
+
+type ChatLinkData = {
+  platform: string;
+  team_id: string;
+  chat_user_name: string;
+  expires_at: string;
+  linked: boolean;
+  linked_to_other: boolean;
+};
+
+const platformNames: Record<string, string> = {
+  slack: "Slack",
+  mattermost: "Mattermost",
+};
+
+const ChatLink = () => {
+  const navigate = useNavigate();
+  const [searchParams] = useSearchParams();
+  const linkToken = searchParams.get("token") ?? "";
+  const { id, token, username, loading } = useSelector(
+    (state: RootState) => state.user,
+  );
+  const [linkData, setLinkData] = useState<ChatLinkData>();
+  const [error, setError] = useState("");
+  const [submitting, setSubmitting] = useState(false);
+
+  useEffect(() => {
+    if (!token) return;
+    const fetchLinkData = async () => {
+      await axios
+        .get(`${import.meta.env.VITE_SERVICE_URL}/chat/link`, {
+          params: { token: linkToken },
+          headers: {
+            Authorization: `Bearer ${token}`,
+          },
+          responseType: "json",
+        })
+        .then((res) => setLinkData(res.data))
+        .catch((err) =>
+          setError(err.response?.data.error ?? "Service is not available"),
+        );
+    };
+
+    fetchLinkData();
+  }, [token, linkToken]);
+
+  const handleConfirm = async () => {
+    setSubmitting(true);
+    await axios
+      .post(
+        `${import.meta.env.VITE_SERVICE_URL}/chat/link`,
+        { token: linkToken, confirm: true },
+        {
+          headers: {
+            Authorization: `Bearer ${token}`,
+            "Content-Type": "application/json",
+          },
+          responseType: "json",
+        },
+      )
+      .then((res) => {
+        toast.success(res.data.message);
+        navigate("/feed", { replace: true });
+      })
+      .catch((err) =>
+        toast.error(err.response?.data.error ?? "Service is not available"),
+      )
+      .finally(() => setSubmitting(false));
+  };
+
+  const container = cn(
+    "flex w-full flex-col gap-4 rounded-xl from-background-800 to-background-900 py-4 sm:m-auto sm:max-w-md sm:border sm:border-background-600/75 sm:bg-gradient-to-br sm:p-4 sm:shadow-md",
+  );
+
+  if (id === -1 && !loading) {
+    return (
+      <div className={container}>
+        <h1 className={cn("py-1 text-2xl font-light")}>Link chat account</h1>
+        <p className={cn("text-text-500")}>
+          Log in to link your chat account, then open the link from the chat
+          again.
+        </p>
+        <Button asChild className={cn("w-fit")}>
+          <Link to="/login">Login</Link>
+        </Button>
+      </div>
+    );
+  }
+
+  if (error) {
+    return (
+      <div className={container}>
+        <h1 className={cn("py-1 text-2xl font-light")}>Link chat account</h1>
+        <p className={cn("text-error")}>{error}</p>
+      </div>
+    );
+  }
+
+  if (!linkData) {
+    return (
+      <div className={cn("flex justify-center py-8")}>
+        <Loader2 className="h-8 w-8 animate-spin" />
+      </div>
+    );
+  }
+
+  const platform = platformNames[linkData.platform] ?? linkData.platform;
+
+  return (
+    <div className={container}>
+      <h1 className={cn("py-1 text-2xl font-light")}>Link chat account</h1>
+      <dl className={cn("grid grid-cols-[auto_1fr] gap-x-4 gap-y-1")}>
+        <dt className={cn("text-text-500")}>Platform</dt>
+        <dd>{platform}</dd>
+        <dt className={cn("text-text-500")}>Team</dt>
+        <dd>{linkData.team_id}</dd>
+        <dt className={cn("text-text-500")}>Chat user</dt>
+        <dd>{linkData.chat_user_name || "Unknown"}</dd>
+      </dl>
+      {linkData.linked_to_other ? (
+        <p className={cn("text-error")}>
+          This chat account is linked to another TechQuire account. Unlink it
+          there first.
+        </p>
+      ) : linkData.linked ? (
+        <p className={cn("text-text-500")}>
+          This chat account is already linked to your account.
+        </p>
+      ) : (
+        <>
+          <p className={cn("text-text-500")}>
+            Commands run by this {platform} user will act as{" "}
+            <span className={cn("font-medium text-text-100")}>{username}</span>,
+            including posting questions. Only continue if you ran{" "}
+            <code>/techquire link</code> yourself.
+          </p>
+          <div className={cn("flex gap-2")}>
+            <Button onClick={handleConfirm} disabled={submitting}>
+              {submitting ? (
+                <Loader2 className="h-4 w-4 animate-spin" />
+              ) : (
+                <Link2 size={20} />
+              )}
+              Link account
+            </Button>
+            <Button
+              variant="neutral"
+              onClick={() => navigate("/feed", { replace: true })}
+            >
+              Cancel
+            </Button>
+          </div>
+        </>
+      )}
+    </div>
+  );
+};
+
+export default ChatLink;